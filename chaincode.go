@@ -5,27 +5,163 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"runtime/debug"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/erc20/controller"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
+// maxParamLength is the max byte length of a single param of Invoke,
+// addresses & amounts are never that large, so larger params are rejected before dispatch
+const maxParamLength = 1 << 20
+
+var logger = shim.NewLogger("erc20")
+
+// handler is the definition of function handler dispatched by Invoke
+type handler func(stub shim.ChaincodeStubInterface, params []string) sc.Response
+
+// functionEntry is the definition of an entry of the dispatch table
+// description & params are returned by functions query for tooling
+type functionEntry struct {
+	handler     handler
+	description string
+	params      string
+}
+
+// implementationStatuses is the registry of the tutorial functions which started as stubs
+// Update the entry when a stub is completed, so the learning path stays explicit
+var implementationStatuses = map[string]model.ImplementationStatus{
+	"transferFrom":      {Status: model.StatusImplemented},
+	"increaseAllowance": {Status: model.StatusImplemented},
+	"decreaseAllowance": {Status: model.StatusImplemented},
+	"mint":              {Status: model.StatusImplemented},
+	"burn":              {Status: model.StatusImplemented},
+}
+
 // ERC20Chaincode is the definition of the chaincode structure.
 type ERC20Chaincode struct {
 	controller *controller.Controller
+	functions  map[string]functionEntry
 }
 
 // NewChaincode is constructor function for ERC20Chaincode
 func NewChaincode() *ERC20Chaincode {
 	controller := controller.NewController()
-	return &ERC20Chaincode{controller}
+	cc := &ERC20Chaincode{controller: controller}
+
+	// the dispatch table of Invoke
+	cc.functions = map[string]functionEntry{
+		"totalSupply":              {controller.TotalSupply, "query the total supply of token", "tokenID"},
+		"balanceOf":                {controller.BalanceOf, "query the balance of address", "address, [\"formatted\" or format]"},
+		"chaincodeBalanceOf":       {controller.ChaincodeBalanceOf, "query the balance of address for other chaincodes, stable JSON format", "address"},
+		"transfer":                 {controller.Transfer, "move amount from the caller to recipient", "caller, recipient, amount"},
+		"categorizedTransfer":      {controller.CategorizedTransfer, "move amount from the caller to recipient tagged with category", "caller, recipient, amount, category"},
+		"allowance":                {controller.Allowance, "query the allowance of spender over the owner tokens", "owner, spender"},
+		"approve":                  {controller.Approve, "set the allowance of spender over the owner tokens", "owner, spender, amount"},
+		"approvalList":             {controller.ApprovalList, "query all allowances the owner granted", "owner"},
+		"spenderCount":             {controller.SpenderCount, "query the number of spenders holding a non-zero allowance of owner", "owner"},
+		"totalAllowanceGranted":    {controller.TotalAllowanceGranted, "query the sum of the non-zero allowances of owner", "owner"},
+		"revokeAllAllowances":      {controller.RevokeAllAllowances, "set every allowance over the owner tokens to zero", "owner"},
+		"transferFrom":             {controller.TransferFrom, "move amount from owner to recipient using the allowance of spender", "owner, spender, recipient, amount"},
+		"transferOtherToken":       {controller.TransferOtherToken, "transfer token of other chaincode", "chaincodeName, caller, recipient, amount"},
+		"setRecurringAllowance":    {controller.SetRecurringAllowance, "approve amount reset at the start of every period, 0 period stops resetting", "owner, spender, amount, period(seconds)"},
+		"increaseAllowance":        {controller.IncreaseAllowance, "increase the allowance of spender", "owner, spender, amount"},
+		"decreaseAllowance":        {controller.DecreaseAllowance, "decrease the allowance of spender", "owner, spender, amount"},
+		"approveCompareAndSet":     {controller.ApproveCompareAndSet, "set the allowance of spender if the current allowance is expected", "owner, spender, expected, amount"},
+		"mint":                     {controller.Mint, "create amount tokens for recipient (owner or minters)", "tokenID, caller, recipient, amount, [mintRequestId]"},
+		"mintTo":                   {controller.MintTo, "create amount tokens for a valid recipient with the supply in the event (owner or minters)", "tokenID, caller, recipient, amount"},
+		"burn":                     {controller.Burn, "destroy amount tokens of address", "tokenID, address, amount"},
+		"burnFrom":                 {controller.BurnFrom, "destroy amount of owner using allowance of spender", "tokenID, owner, spender, amount"},
+		"recentTransfers":          {controller.RecentTransfers, "query the transfers of address page by page", "address, pageSize, bookmark, [format]"},
+		"categoryTransfers":        {controller.CategoryTransfers, "query the categorized transfers of category page by page", "category, pageSize, bookmark"},
+		"eventByTxId":              {controller.EventByTxID, "query the transfer events of txID indexed for address", "address, txID, [format]"},
+		"setName":                  {controller.SetName, "change the name of token (owner)", "tokenID, caller, name"},
+		"setSymbol":                {controller.SetSymbol, "change the symbol of token (owner)", "tokenID, caller, symbol"},
+		"lockMetadata":             {controller.LockMetadata, "lock the name & symbol of token for good (owner)", "tokenID, caller"},
+		"approveAndCall":           {controller.ApproveAndCall, "approve spender and call a function of other chaincode", "owner, spender, amount, chaincodeName, functionName"},
+		"listTokens":               {controller.ListTokens, "query the tokens page by page", "pageSize, bookmark"},
+		"tokenAge":                 {controller.TokenAge, "query the creation txID & time of token and the seconds since then", "tokenID"},
+		"getMetadata":              {controller.GetMetadata, "query the metadata of token", "tokenID, [format]"},
+		"clawback":                 {controller.Clawback, "move amount between addresses (owner)", "tokenID, caller, from, to, amount"},
+		"sweep":                    {controller.Sweep, "move one page of balances below threshold to collector (owner)", "tokenID, caller, collector, threshold, pageSize, bookmark"},
+		"setRateLimit":             {controller.SetRateLimit, "configure the per-address transfer limit (owner)", "tokenID, caller, windowSeconds, maxTransfers, maxAmount"},
+		"setTransferCooldown":      {controller.SetTransferCooldown, "configure the per-address transfer cooldown (owner)", "tokenID, caller, cooldownSeconds"},
+		"setCircuitBreaker":        {controller.SetCircuitBreaker, "configure the transfer volume threshold which pauses the token (owner)", "tokenID, caller, windowSeconds, maxVolume"},
+		"unpause":                  {controller.Unpause, "lift the pause tripped by the circuit breaker (owner)", "tokenID, caller"},
+		"setReserve":               {controller.SetReserve, "set the reserve supply burn cannot go below (owner)", "tokenID, caller, reserve"},
+		"setEmitEvents":            {controller.SetEmitEvents, "enable or disable the transfer event of transfer, mint and burn (owner)", "tokenID, caller, emitEvents(true or false)"},
+		"renounceOwnership":        {controller.RenounceOwnership, "give up the ownership, owner-gated functions reject afterwards (owner)", "tokenID, caller"},
+		"claimOwnership":           {controller.ClaimOwnership, "take the ownership of the inactive owner (backup owner)", "tokenID, caller"},
+		"setLabel":                 {controller.SetLabel, "set the label of address, e.g. the KYC tier (owner)", "tokenID, caller, address, label"},
+		"getLabel":                 {controller.GetLabel, "query the label of address", "address"},
+		"setVerifiedThreshold":     {controller.SetVerifiedThreshold, "set the amount above which only verified addresses can receive (owner)", "tokenID, caller, threshold"},
+		"circulatingSupply":        {controller.CirculatingSupply, "query the total supply minus excluded balances & locked escrows", "tokenID"},
+		"remainingMintable":        {controller.RemainingMintable, "query how much more can be minted under the supply cap, \"unlimited\" without cap", "tokenID"},
+		"addExcludedAddress":       {controller.AddExcludedAddress, "exclude the balance of address from the circulating supply (owner)", "tokenID, caller, address"},
+		"removeExcludedAddress":    {controller.RemoveExcludedAddress, "count the balance of address in the circulating supply again (owner)", "tokenID, caller, address"},
+		"setBurnAddress":           {controller.SetBurnAddress, "set the address whose incoming transfers burn the amount, empty disables (owner)", "tokenID, caller, burnAddress"},
+		"setKeyPolicy":             {controller.SetKeyPolicy, "require peers of orgs to endorse writes of the metadata or a balance key, empty orgs remove it (owner)", "tokenID, caller, target(metadata or balance), address, orgs(JSON)"},
+		"setWhitelistMode":         {controller.SetWhitelistMode, "allow transfers only to owner and allowed addresses, or to all (owner)", "tokenID, caller, whitelistMode(true or false)"},
+		"addAllowed":               {controller.AddAllowed, "add address to the recipient whitelist (owner)", "tokenID, caller, address"},
+		"removeAllowed":            {controller.RemoveAllowed, "remove address from the recipient whitelist (owner)", "tokenID, caller, address"},
+		"setStrictApprovals":       {controller.SetStrictApprovals, "reject or allow approvals exceeding the owner balance (owner)", "tokenID, caller, strictApprovals(true or false)"},
+		"admin":                    {controller.Admin, "run an owner-only sub-command after one owner check (owner)", "command, tokenID, caller, [params of command...]"},
+		"migrateBalances":          {controller.MigrateBalances, "move one page of bare address balances to composite keys (owner)", "tokenID, caller, pageSize, bookmark"},
+		"seedBalances":             {controller.SeedBalances, "set the balances of a snapshot once and recompute the total supply (owner)", "tokenID, caller, balances(JSON)"},
+		"transferBatch":            {controller.TransferBatch, "move amounts from the caller to many recipients", "caller, batch(JSON)"},
+		"treasuryDistribute":       {controller.TreasuryDistribute, "move amounts from the owner balance to many recipients, supply unchanged (owner)", "tokenID, caller, batch(JSON)"},
+		"mintBatch":                {controller.MintBatch, "create amounts for many recipients (owner or minters)", "tokenID, caller, batch(JSON)"},
+		"reconcileSupply":          {controller.ReconcileSupply, "set the total supply to the sum of balances & locked escrows (owner)", "tokenID, caller"},
+		"checkInvariant":           {controller.CheckInvariant, "query whether balances & escrows sum to the total supply", "tokenID"},
+		"reconcileReport":          {controller.ReconcileReport, "query one page of balances with the running sum, the last page is compared with the total supply", "pageSize, bookmark, partialSum"},
+		"accountInfo":              {controller.AccountInfo, "query the account-created marker of address", "address"},
+		"permit":                   {controller.Permit, "set allowance with the owner's signature", "owner, spender, amount, deadline, publicKey, signature"},
+		"getNonce":                 {controller.Nonce, "query the nonce of the next signed operation of address", "address"},
+		"spendableBalanceOf":       {controller.SpendableBalanceOf, "query the portion of balance transferable right now", "address"},
+		"simulateTransfer":         {controller.SimulateTransfer, "query the result of each check of transfer without moving tokens", "caller, recipient, amount"},
+		"estimateFee":              {controller.EstimateFee, "query the fee & net amount the recipient would receive for amount", "amount"},
+		"balanceAndAllowance":      {controller.BalanceAndAllowance, "query the balance of owner and the allowance of spender", "owner, spender"},
+		"hasActivity":              {controller.HasActivity, "query whether address has ever held tokens", "address"},
+		"isNameAvailable":          {controller.IsNameAvailable, "query whether no token uses name as its tokenID, name or symbol", "name"},
+		"topHolders":               {controller.TopHolders, "query the n largest holders", "n"},
+		"queryBalances":            {controller.QueryBalances, "query the balances of an address range page by page, optionally at least minBalance", "startAddress, endAddress, pageSize, bookmark, [minBalance]"},
+		"conditionalTransfer":      {controller.ConditionalTransfer, "lock amount in escrow for recipient until deadline", "caller, recipient, amount, deadline"},
+		"claim":                    {controller.Claim, "claim escrow until deadline (recipient)", "escrowID, caller"},
+		"refund":                   {controller.Refund, "refund escrow after deadline (sender)", "escrowID, caller"},
+		"addMinter":                {controller.AddMinter, "add minter (owner)", "tokenID, caller, minter"},
+		"removeMinter":             {controller.RemoveMinter, "remove minter (owner)", "tokenID, caller, minter"},
+		"allowanceHistory":         {controller.AllowanceHistory, "query the changes of allowance", "owner, spender"},
+		"balanceOfAtTime":          {controller.BalanceOfAtTime, "query the balance of address at a unix timestamp, needs the history database", "address, timestamp"},
+		"transferSplit":            {controller.TransferSplit, "move amounts from the caller to two recipients", "caller, recipient1, amount1, recipient2, amount2"},
+		"atomicSwap":               {controller.AtomicSwap, "swap this token of party1 with tokenB of party2 by allowances", "party1, party2, amountA, chaincodeName, tokenIDB, amountB"},
+		"setMaintenance":           {controller.SetMaintenance, "block every function except the maintenance whitelist (owner)", "tokenID, caller, maintenance(true or false)"},
+		"setUsageMetering":         {controller.SetUsageMetering, "enable or disable recording invocations for usageStats (owner)", "tokenID, caller, usageMetering(true or false)"},
+		"usageStats":               {controller.UsageStats, "query the number of invocations per function", "-"},
+		"stateSchema":              {controller.StateSchema, "query the key layout & value format of every state", "-"},
+		"verifyNamespaceIntegrity": {controller.VerifyNamespaceIntegrity, "query the state keys matching no layout of stateSchema (owner)", "tokenID, caller"},
+		"functions":                {cc.listFunctions, "query the supported functions", "-"},
+		"implementationStatus":     {cc.implementationStatus, "tutorial: query which tutorial functions are still stubs", "-"},
+		"transactionAPI":           {cc.transactionAPI, "tutorial: print the transaction APIs", "-"},
+		"putDummyData":             {cc.putDummyData, "tutorial: put dummy state data", "-"},
+		"stateDataAPI":             {cc.stateDataAPI, "tutorial: print the state in key range", "startKey, endKey"},
+		"stateDataAPI2":            {cc.stateDataAPI2, "tutorial: print the state in key range page by page", "startKey, endKey, bookmark"},
+		"historyAPI":               {cc.historyAPI, "tutorial: print the history of key", "key"},
+	}
+
+	return cc
 }
 
 // Init is called when the chaincode is instantiated by the blockchain network.
-// params - tokenName, symbol, owner(address), amount
+// params - tokenID, tokenName, symbol, owner(address), amount, [options(JSON)]
 func (cc *ERC20Chaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
 	_, params := stub.GetFunctionAndParameters()
 	fmt.Println("Init called with params: ", params)
@@ -34,47 +170,87 @@ func (cc *ERC20Chaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
 }
 
 // Invoke is called as a result of an application request to run the chaincode.
-func (cc *ERC20Chaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+func (cc *ERC20Chaincode) Invoke(stub shim.ChaincodeStubInterface) (response sc.Response) {
 	fcn, params := stub.GetFunctionAndParameters()
 
-	switch fcn {
-	case "totalSupply":
-		return cc.controller.TotalSupply(stub, params)
-	case "balanceOf":
-		return cc.controller.BalanceOf(stub, params)
-	case "transfer":
-		return cc.controller.Transfer(stub, params)
-	case "allowance":
-		return cc.controller.Allowance(stub, params)
-	case "approve":
-		return cc.controller.Approve(stub, params)
-	case "approvalList":
-		return cc.controller.ApprovalList(stub, params)
-	case "transferFrom":
-		return cc.controller.TransferFrom(stub, params)
-	case "transferOtherToken":
-		return cc.controller.TransferOtherToken(stub, params)
-	case "increaseAllowance":
-		return cc.controller.IncreaseAllowance(stub, params)
-	case "decreaseAllowance":
-		return cc.controller.DecreaseAllowance(stub, params)
-	case "mint":
-		return cc.controller.Mint(stub, params)
-	case "burn":
-		return cc.controller.Burn(stub, params)
-	case "transactionAPI":
-		return cc.transactionAPI(stub, params)
-	case "putDummyData":
-		return cc.putDummyData(stub, params)
-	case "stateDataAPI":
-		return cc.stateDataAPI(stub, params)
-	case "stateDataAPI2":
-		return cc.stateDataAPI2(stub, params)
-	case "historyAPI":
-		return cc.historyAPI(stub, params)
-	default:
-		return sc.Response{Status: 404, Message: "404 Not Found", Payload: nil}
+	// convert a panic of handler (e.g. indexing missing params) into an error response,
+	// so a bad call never crashes the chaincode container
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("panic in %s: %v\n%s", fcn, r, debug.Stack())
+			response = shim.Error(fmt.Sprintf("failed to invoke %s, error: %v", fcn, r))
+		}
+	}()
+
+	// reject huge params before they reach any handler
+	for i, param := range params {
+		if len(param) > maxParamLength {
+			return util.BadRequest(fmt.Sprintf("parameter too large, param %d exceeds %d bytes", i, maxParamLength))
+		}
+	}
+
+	entry, ok := cc.functions[fcn]
+	if !ok {
+		message := fmt.Sprintf("404 Not Found - function %q is not supported, supported functions: %s", fcn, strings.Join(cc.supportedFunctions(), ", "))
+		return util.NotFound(message)
+	}
+
+	// the metadata is read once for maintenance & usage metering, both are skipped until the token is created
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err == nil {
+		err = cc.controller.CheckMaintenance(erc20Metadata, fcn)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+
+		// usage metering is best-effort, a failure never fails the invocation
+		err = cc.controller.MeterUsage(stub, erc20Metadata, fcn)
+		if err != nil {
+			logger.Warningf("failed to meter usage of %s, error: %s", fcn, err.Error())
+		}
 	}
+
+	return entry.handler(stub, params)
+}
+
+// supportedFunctions returns the sorted function names of the dispatch table
+func (cc *ERC20Chaincode) supportedFunctions() []string {
+	functions := []string{}
+	for fcn := range cc.functions {
+		functions = append(functions, fcn)
+	}
+	sort.Strings(functions)
+
+	return functions
+}
+
+// implementationStatus is query function
+// Returns JSON mapping the tutorial function names to the status (stub or implemented) and TODO note
+func (cc *ERC20Chaincode) implementationStatus(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	response, err := json.Marshal(implementationStatuses)
+	if err != nil {
+		return shim.Error("failed to Marshal implementationStatus, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// listFunctions is query function
+// Returns the supported functions with description & params hint sorted by name
+func (cc *ERC20Chaincode) listFunctions(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	functions := []model.FunctionInfo{}
+	for _, fcn := range cc.supportedFunctions() {
+		entry := cc.functions[fcn]
+		functions = append(functions, model.FunctionInfo{Name: fcn, Description: entry.description, Params: entry.params})
+	}
+
+	// convert functions to bytes for return
+	response, err := json.Marshal(functions)
+	if err != nil {
+		return shim.Error("failed to Marshal functions, error: " + err.Error())
+	}
+
+	return shim.Success(response)
 }
 
 // <Transaction API>
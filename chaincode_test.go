@@ -5,13 +5,32 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/erc20/model"
+	"github.com/erc20/pb"
 	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
 var function = []byte("mint")
@@ -20,27 +39,145 @@ const txMint = "txMint"
 const address = "dappcampus"
 const initAmount = 100000
 
-const tokenName = "dappToken"
+const tokenID = "dappToken"
+const tokenName = "dapp token"
 
 func Test_Init_success(t *testing.T) {
 	cc := NewChaincode()
 	stub := shim.NewMockStub("erc20", cc)
-	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount))})
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount))})
 	if res.Status != shim.OK {
 		t.FailNow()
 	}
 
 	// check totalSupply
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, _ := stub.GetState(tokenName)
-	json.Unmarshal(erc20Bytes, &erc20)
-	if *erc20.GetTotalSupply() != initAmount {
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if *erc20.GetID() != tokenID || *erc20.GetName() != tokenName || erc20.GetTotalSupply().Int64() != initAmount {
 		t.FailNow()
 	}
 
 	// check dappcampus balance
 	balance, _ := repository.GetBalance(stub, address, true)
-	if *balance != initAmount {
+	if balance.Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// emit token created event
+	data := <-stub.ChaincodeEventsChannel
+	event := model.TokenCreatedEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.TokenCreatedEventKey, tokenID) || event.TokenID != tokenID || event.TotalSupply.Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// event name is scoped by token, payload carries the base name
+	if data.GetEventName() != "tokenCreatedEvent.dappToken" || event.EventType != repository.TokenCreatedEventKey {
+		t.FailNow()
+	}
+}
+
+func Test_Init_eventPrefix_success(t *testing.T) {
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount)), []byte(`{"eventPrefix": "acme"}`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != "acme.tokenCreatedEvent.dappToken" {
+		t.FailNow()
+	}
+
+	// every event of token carries the prefix
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+	data = <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.PrefixedEventName("acme", repository.TransferEventKey, tokenID) || data.GetEventName() != "acme.transferEvent.dappToken" {
+		t.FailNow()
+	}
+
+	// eventPrefix must be a safe event name part
+	for _, eventPrefix := range []string{"acme.corp", "acme corp", "^acme", strings.Repeat("a", 33)} {
+		stub := shim.NewMockStub("erc20", NewChaincode())
+		res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount)), []byte(`{"eventPrefix": "` + eventPrefix + `"}`)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_Init_invalidOwner_failure(t *testing.T) {
+	for _, owner := range []string{"dapp campus", "dapp\x00campus", "dapp\ncampus", "dappcampus\u00e9", strings.Repeat("a", util.MaxAddressLength+1)} {
+		stub := shim.NewMockStub("erc20", NewChaincode())
+		res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(owner), []byte(strconv.Itoa(initAmount))})
+		if res.Status != model.StatusBadRequest || !strings.Contains(res.Message, "owner") {
+			t.Fatalf("owner %q: %s", owner, res.Message)
+		}
+		if len(stub.State) != 0 {
+			t.FailNow()
+		}
+	}
+
+	// the longest well-formed owner is accepted
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(strings.Repeat("a", util.MaxAddressLength)), []byte(strconv.Itoa(initAmount))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_Init_invalidAmount_failure(t *testing.T) {
+	for amount, message := range map[string]string{"": "amount cannot be empty", "-1": "amount cannot be negative", "abc": "amount must be a number"} {
+		stub := shim.NewMockStub("erc20", NewChaincode())
+		res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(amount)})
+		if res.Status < shim.ERRORTHRESHOLD || res.Message != message {
+			t.Fatalf("amount %q: %s", amount, res.Message)
+		}
+	}
+}
+
+func Test_Init_ownerBalanceFormat_success(t *testing.T) {
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("000100000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balanceKey := func(owner string) string {
+		key, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{owner})
+		return key
+	}
+
+	// the owner's genesis balance is stored in the format of a post-transfer balance
+	if string(stub.State[balanceKey(address)]) != strconv.Itoa(initAmount) {
+		t.FailNow()
+	}
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("000100")})
+	ownerBalance, recipientBalance := string(stub.State[balanceKey(address)]), string(stub.State[balanceKey("recipient")])
+	if ownerBalance != strconv.Itoa(initAmount-100) || recipientBalance != "100" {
+		t.FailNow()
+	}
+
+	// balanceOf returns both in the same format
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if string(res.GetPayload()) != ownerBalance {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte("recipient")})
+	if string(res.GetPayload()) != recipientBalance {
+		t.FailNow()
+	}
+}
+
+func Test_Init_amountBeyondUint64_success(t *testing.T) {
+	const largeAmount = "340282366920938463463374607431768211456"
+	cc := NewChaincode()
+	stub := shim.NewMockStub("erc20", cc)
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(largeAmount)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	balance, _ := repository.GetBalance(stub, address, true)
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	if balance.String() != largeAmount || totalSupply.String() != largeAmount {
 		t.FailNow()
 	}
 }
@@ -48,30 +185,45 @@ func Test_Init_success(t *testing.T) {
 func initERC20(t *testing.T) *shim.MockStub {
 	cc := NewChaincode()
 	stub := shim.NewMockStub("erc20", cc)
-	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount))})
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount))})
 	if res.Status != shim.OK {
 		t.FailNow()
 	}
+
+	// drain token created event
+	<-stub.ChaincodeEventsChannel
 	return stub
 }
 
 func Test_Mint_lengthIsInvalid_failure(t *testing.T) {
 	stub := initERC20(t)
-	arguments := [][]byte{function, []byte(tokenName), []byte(address)}
+	arguments := [][]byte{function, []byte(tokenID), []byte(address), []byte(address)}
 	res := stub.MockInvoke(txMint, arguments)
-	if res.Status != shim.ERROR {
+	if res.Status < shim.ERRORTHRESHOLD {
 		t.FailNow()
 	}
 }
 
 func Test_Mint_amountIsNotPositive_failure(t *testing.T) {
 	stub := initERC20(t)
-	arguments := [][]byte{function, []byte(tokenName), []byte(address), []byte("-100")}
-	arguments2 := [][]byte{function, []byte(tokenName), []byte(address), []byte("abcde")}
+	arguments := [][]byte{function, []byte(tokenID), []byte(address), []byte(address), []byte("-100")}
+	arguments2 := [][]byte{function, []byte(tokenID), []byte(address), []byte(address), []byte("abcde")}
 	res := stub.MockInvoke(txMint, arguments)
 	res2 := stub.MockInvoke(txMint, arguments2)
 
-	if res.Status != shim.ERROR && res2.Status != shim.ERROR {
+	if res.Status < shim.ERRORTHRESHOLD && res2.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_hexAmount_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("0x10")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if string(res.GetPayload()) != strconv.Itoa(initAmount) {
 		t.FailNow()
 	}
 }
@@ -79,32 +231,3916 @@ func Test_Mint_amountIsNotPositive_failure(t *testing.T) {
 func Test_Mint_success(t *testing.T) {
 	stub := initERC20(t)
 	const increaseAmount = 10000
-	arguments := [][]byte{function, []byte(tokenName), []byte(address), []byte(strconv.Itoa(increaseAmount))}
+	arguments := [][]byte{function, []byte(tokenID), []byte(address), []byte(address), []byte(strconv.Itoa(increaseAmount))}
 	res := stub.MockInvoke(txMint, arguments)
 	if res.Status != shim.OK {
 		t.FailNow()
 	}
 
 	// increase TotalSupply
-	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenName)
-	if *totalSupply != initAmount+increaseAmount {
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	if totalSupply.Int64() != initAmount+increaseAmount {
 		t.FailNow()
 	}
 
 	// increase owner balance
 	balance, _ := repository.GetBalance(stub, address, true)
-	if *balance != initAmount+increaseAmount {
+	if balance.Int64() != initAmount+increaseAmount {
 		t.FailNow()
 	}
 
 	// emit trasnfer event
 	data := <-stub.ChaincodeEventsChannel
-	if data.GetEventName() != repository.TransferEventKey {
+	if data.GetEventName() != repository.EventName(repository.TransferEventKey, tokenID) {
 		t.FailNow()
 	}
-	event := model.NewTransferEvent("admin", address, increaseAmount)
+	event := model.NewTransferEvent("admin", address, big.NewInt(increaseAmount))
+	event.EventType = repository.TransferEventKey
+	decimals := uint8(0)
+	event.Decimals = &decimals
+	txTimestamp, _ := stub.GetTxTimestamp()
+	event.Timestamp = txTimestamp.GetSeconds()
 	eventBytes, _ := json.Marshal(event)
 	if string(data.GetPayload()) != string(eventBytes) {
 		t.FailNow()
 	}
 }
+
+// testStub wraps MockStub to support the pagination APIs
+// which MockStub leaves unimplemented, to fix the transaction timestamp
+// to fail PutState of the keys starting with failKeyPrefix
+// and to fail the paginated iterators after failIteratorAfter entries
+type testStub struct {
+	*shim.MockStub
+	args              [][]byte
+	txTimestamp       *timestamp.Timestamp
+	history           map[string][]*queryresult.KeyModification
+	failKeyPrefix     string
+	failIteratorAfter int
+}
+
+func newTestStub(stub *shim.MockStub) *testStub {
+	return &testStub{MockStub: stub}
+}
+
+func (stub *testStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if stub.txTimestamp != nil {
+		return stub.txTimestamp, nil
+	}
+	return stub.MockStub.GetTxTimestamp()
+}
+
+func (stub *testStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *testStub) GetFunctionAndParameters() (string, []string) {
+	args := []string{}
+	for _, arg := range stub.args {
+		args = append(args, string(arg))
+	}
+	return args[0], args[1:]
+}
+
+func (stub *testStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *sc.QueryResponseMetadata, error) {
+	partialKey, err := stub.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	startKey, endKey := partialKey, partialKey+string(utf8.MaxRune)
+	if bookmark != "" {
+		startKey = bookmark
+	}
+
+	// the key following the last key of page is the next bookmark
+	count, nextKey := int32(0), ""
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey || key >= endKey {
+			continue
+		}
+		if count == pageSize {
+			nextKey = key
+			break
+		}
+		count++
+	}
+	if nextKey != "" {
+		endKey = nextKey
+	}
+
+	var iterator shim.StateQueryIteratorInterface = shim.NewMockStateRangeQueryIterator(stub.MockStub, startKey, endKey)
+	if stub.failIteratorAfter > 0 {
+		iterator = &testFailingIterator{StateQueryIteratorInterface: iterator, remaining: stub.failIteratorAfter}
+	}
+	return iterator, &sc.QueryResponseMetadata{FetchedRecordsCount: count, Bookmark: nextKey}, nil
+}
+
+// testFailingIterator fails Next after remaining entries
+type testFailingIterator struct {
+	shim.StateQueryIteratorInterface
+	remaining int
+}
+
+func (iterator *testFailingIterator) Next() (*queryresult.KV, error) {
+	if iterator.remaining == 0 {
+		return nil, errors.New("iterator failed")
+	}
+	iterator.remaining--
+	return iterator.StateQueryIteratorInterface.Next()
+}
+
+func (stub *testStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *sc.QueryResponseMetadata, error) {
+	// the empty keys are open-ended, composite keys are excluded like Fabric
+	if startKey == "" {
+		startKey = "\x01"
+	}
+	if endKey == "" {
+		endKey = string(utf8.MaxRune)
+	}
+	if bookmark != "" {
+		startKey = bookmark
+	}
+
+	// the key following the last key of page is the next bookmark
+	count, nextKey := int32(0), ""
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		if key < startKey || key >= endKey {
+			continue
+		}
+		if count == pageSize {
+			nextKey = key
+			break
+		}
+		count++
+	}
+	if nextKey != "" {
+		endKey = nextKey
+	}
+
+	iterator := shim.NewMockStateRangeQueryIterator(stub.MockStub, startKey, endKey)
+	return iterator, &sc.QueryResponseMetadata{FetchedRecordsCount: count, Bookmark: nextKey}, nil
+}
+
+// PutState records the history of key, MockStub doesn't keep the history of keys
+func (stub *testStub) PutState(key string, value []byte) error {
+	if len(stub.failKeyPrefix) != 0 && strings.HasPrefix(key, stub.failKeyPrefix) {
+		return errors.New("PutState failed")
+	}
+	if stub.history == nil {
+		stub.history = map[string][]*queryresult.KeyModification{}
+	}
+	txTimestamp, _ := stub.GetTxTimestamp()
+	modification := &queryresult.KeyModification{TxId: stub.TxID, Value: value, Timestamp: txTimestamp}
+	stub.history[key] = append(stub.history[key], modification)
+
+	return stub.MockStub.PutState(key, value)
+}
+
+// GetHistoryForKey returns the recorded history of key,
+// the current value is the only entry of keys written before wrapping
+func (stub *testStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	history := append([]*queryresult.KeyModification{}, stub.history[key]...)
+	if value, ok := stub.State[key]; ok && len(history) == 0 {
+		history = append(history, &queryresult.KeyModification{Value: value})
+	}
+	return &testHistoryIterator{history: history}, nil
+}
+
+// testHistoryIterator is the history iterator of testStub
+type testHistoryIterator struct {
+	history []*queryresult.KeyModification
+}
+
+func (iterator *testHistoryIterator) HasNext() bool {
+	return len(iterator.history) > 0
+}
+
+func (iterator *testHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	next := iterator.history[0]
+	iterator.history = iterator.history[1:]
+	return next, nil
+}
+
+func (iterator *testHistoryIterator) Close() error {
+	return nil
+}
+
+func (stub *testStub) MockInvoke(uuid string, args [][]byte) sc.Response {
+	stub.args = args
+	stub.MockTransactionStart(uuid)
+	res := NewChaincode().Invoke(stub)
+	stub.MockTransactionEnd(uuid)
+	return res
+}
+
+func (stub *testStub) MockInit(uuid string, args [][]byte) sc.Response {
+	stub.args = args
+	stub.MockTransactionStart(uuid)
+	res := NewChaincode().Init(stub)
+	stub.MockTransactionEnd(uuid)
+	return res
+}
+
+func Test_Init_ownerBalanceWrite_failure(t *testing.T) {
+	// metadata is written first, then the owner balance write fails
+	stub := newTestStub(shim.NewMockStub("erc20", NewChaincode()))
+	stub.failKeyPrefix = "\x00" + repository.BalancePrefix + "\x00"
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount))})
+	if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "failed to save owner balance") {
+		t.FailNow()
+	}
+	if len(stub.ChaincodeEventsChannel) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_RecentTransfers_success(t *testing.T) {
+	stub := initERC20(t)
+	const recipient = "recipient"
+	for i := 1; i <= 3; i++ {
+		arguments := [][]byte{[]byte("transfer"), []byte(address), []byte(recipient), []byte(strconv.Itoa(i))}
+		res := stub.MockInvoke("txTransfer"+strconv.Itoa(i), arguments)
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+	}
+
+	// first page
+	pStub := newTestStub(stub)
+	res := pStub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte(recipient), []byte("2"), []byte("")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Logs) != 2 || page.Logs[0].TxID != "txTransfer1" || page.Bookmark == "" {
+		t.FailNow()
+	}
+
+	// last page
+	res = pStub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte(recipient), []byte("2"), []byte(page.Bookmark)})
+	page = model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Logs) != 1 || page.Logs[0].Event.Amount.Int64() != 3 || page.Bookmark != "" {
+		t.FailNow()
+	}
+}
+
+func Test_RecentTransfers_partial_success(t *testing.T) {
+	stub := initERC20(t)
+	for i := 1; i <= 3; i++ {
+		stub.MockInvoke("txTransfer"+strconv.Itoa(i), [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte(strconv.Itoa(i))})
+	}
+
+	// the iterator fails mid-scan, the gathered logs are returned
+	pStub := newTestStub(stub)
+	pStub.failIteratorAfter = 2
+	res := pStub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte("recipient"), []byte("10"), []byte("")})
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || len(page.Logs) != 2 || !page.Incomplete || len(page.Error) == 0 {
+		t.FailNow()
+	}
+
+	// the bookmark resumes after the last log read, the unread log is not skipped
+	pStub.failIteratorAfter = 0
+	res = pStub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte("recipient"), []byte("10"), []byte(page.Bookmark)})
+	page = model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Logs) != 1 || page.Logs[0].Event.Amount.Int64() != 3 || page.Incomplete {
+		t.FailNow()
+	}
+
+	// complete page is not marked
+	res = pStub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte("recipient"), []byte("10"), []byte("")})
+	page = model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Logs) != 3 || page.Incomplete || strings.Contains(string(res.GetPayload()), "incomplete") {
+		t.FailNow()
+	}
+}
+
+func Test_SetName_notOwner_failure(t *testing.T) {
+	stub := initERC20(t)
+	arguments := [][]byte{[]byte("setName"), []byte(tokenID), []byte("stranger"), []byte("newName")}
+	res := stub.MockInvoke("txSetName", arguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_SetName_success(t *testing.T) {
+	stub := initERC20(t)
+	arguments := [][]byte{[]byte("setName"), []byte(tokenID), []byte(address), []byte("newName")}
+	res := stub.MockInvoke("txSetName", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// metadata key is unchanged, display name is changed
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if *erc20.GetName() != "newName" || *erc20.GetSymbol() != "dt" {
+		t.FailNow()
+	}
+
+	// emit metadata updated event
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EventName(repository.MetadataUpdatedEventKey, tokenID) {
+		t.FailNow()
+	}
+}
+
+func Test_SaveMetadata_corrupt_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// metadata corrupted out of band
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	erc20.Decimals = 30
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveERC20Metadata(stub, tokenID, erc20)
+	stub.MockTransactionEnd("txCorrupt")
+
+	// mint & setName refuse to persist it again
+	res := stub.MockInvoke("txMint", [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte(address), []byte("100")})
+	if res.Status != model.StatusInternalError || !strings.Contains(res.Message, "decimals") {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txSetName", [][]byte{[]byte("setName"), []byte(tokenID), []byte(address), []byte("newName")})
+	if res.Status != model.StatusInternalError {
+		t.FailNow()
+	}
+	erc20, _ = repository.GetERC20Metadata(stub, tokenID)
+	if erc20.GetTotalSupply().Int64() != initAmount || *erc20.GetName() != tokenName {
+		t.FailNow()
+	}
+}
+
+func Test_Standard_success(t *testing.T) {
+	stub := initERC20(t)
+	standard := func() string {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+		erc20 := model.ERC20Metadata{}
+		json.Unmarshal(res.GetPayload(), &erc20)
+		return erc20.Standard
+	}
+	if standard() != model.StandardERC20 {
+		t.FailNow()
+	}
+
+	// minters make token mintable
+	stub.MockInvoke("txAddMinter", [][]byte{[]byte("addMinter"), []byte(tokenID), []byte(address), []byte("minter")})
+	if standard() != model.StandardMintable {
+		t.FailNow()
+	}
+	stub.MockInvoke("txRemoveMinter", [][]byte{[]byte("removeMinter"), []byte(tokenID), []byte(address), []byte("minter")})
+	if standard() != model.StandardERC20 {
+		t.FailNow()
+	}
+
+	// unknown standard is not persisted again
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	erc20.Standard = "ERC777"
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveERC20Metadata(stub, tokenID, erc20)
+	stub.MockTransactionEnd("txCorrupt")
+	res := stub.MockInvoke("txSetName", [][]byte{[]byte("setName"), []byte(tokenID), []byte(address), []byte("newName")})
+	if res.Status != model.StatusInternalError || !strings.Contains(res.Message, "standard") {
+		t.FailNow()
+	}
+}
+
+func Test_Setters_saveMetadata_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// a corrupt metadata cannot be saved back by any owner setter
+	tokenKey, _ := stub.CreateCompositeKey(repository.TokenPrefix, []string{tokenID})
+	fields := map[string]interface{}{}
+	json.Unmarshal(stub.State[tokenKey], &fields)
+	fields["symbol"] = ""
+	corrupt, _ := json.Marshal(fields)
+	stub.MockTransactionStart("txCorrupt")
+	stub.PutState(tokenKey, corrupt)
+	stub.MockTransactionEnd("txCorrupt")
+
+	for _, args := range [][]string{
+		{"setReserve", tokenID, address, "10"},
+		{"setEmitEvents", tokenID, address, "false"},
+		{"setStrictApprovals", tokenID, address, "true"},
+		{"setRateLimit", tokenID, address, "60", "10", "1000"},
+		{"setTransferCooldown", tokenID, address, "10"},
+		{"setVerifiedThreshold", tokenID, address, "1000"},
+		{"setMaintenance", tokenID, address, "true"},
+		{"setUsageMetering", tokenID, address, "true"},
+	} {
+		input := [][]byte{}
+		for _, arg := range args {
+			input = append(input, []byte(arg))
+		}
+		res := stub.MockInvoke("txSetter", input)
+		if res.Status != model.StatusInternalError || !strings.Contains(res.Message, "symbol cannot be empty") {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_Metadata_truncated_failure(t *testing.T) {
+	stub := initERC20(t)
+	tokenKey, _ := stub.CreateCompositeKey(repository.TokenPrefix, []string{tokenID})
+	erc20Bytes := stub.State[tokenKey]
+
+	for _, corrupt := range [][]byte{
+		erc20Bytes[:len(erc20Bytes)/2],
+		[]byte(`{"id":"` + tokenID + `","totalSupply":100000,"unknown":1}`),
+		[]byte(`{"id":"` + tokenID + `"}`),
+		append(append([]byte{}, erc20Bytes...), []byte("{}")...),
+	} {
+		stub.MockTransactionStart("txCorrupt")
+		stub.PutState(tokenKey, corrupt)
+		stub.MockTransactionEnd("txCorrupt")
+
+		// metadata readers fail loudly
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("totalSupply"), []byte(tokenID)})
+		if res.Status != model.StatusInternalError || !strings.Contains(res.Message, "UnMarshal") {
+			t.FailNow()
+		}
+		res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+		if res.Status != model.StatusInternalError {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_CategorizedTransfer_success(t *testing.T) {
+	stub := initERC20(t)
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res := stub.MockInvoke("txSalary", [][]byte{[]byte("categorizedTransfer"), []byte(address), []byte("recipient"), []byte("10"), []byte("salary")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	receipt := model.TransferReceipt{}
+	json.Unmarshal(res.GetPayload(), &receipt)
+	if receipt.ToBalance.Int64() != 10 {
+		t.FailNow()
+	}
+
+	// the event carries the category
+	data := <-stub.ChaincodeEventsChannel
+	event := model.TransferEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.TransferEventKey, tokenID) || event.Category != "salary" || event.Amount.Int64() != 10 {
+		t.FailNow()
+	}
+
+	// uncategorized transfers are not indexed under the category
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("5")})
+	res = newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("categoryTransfers"), []byte("salary"), []byte("10"), []byte("")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Logs) != 1 || page.Logs[0].TxID != "txSalary" || page.Logs[0].Event.Category != "salary" {
+		t.FailNow()
+	}
+}
+
+func Test_CategorizedTransfer_invalidCategory_failure(t *testing.T) {
+	stub := initERC20(t)
+	for _, category := range []string{"", "salary\x00", strings.Repeat("a", 33)} {
+		res := stub.MockInvoke("txTransfer", [][]byte{[]byte("categorizedTransfer"), []byte(address), []byte("recipient"), []byte("10"), []byte(category)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Int64() != initAmount {
+		t.FailNow()
+	}
+}
+
+func Test_ApproveCompareAndSet_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res := stub.MockInvoke("txCAS", [][]byte{[]byte("approveCompareAndSet"), []byte(address), []byte("spender"), []byte("100"), []byte("50")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, address, "spender", true)
+	if string(allowance) != "50" {
+		t.FailNow()
+	}
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EventName(repository.ApprovalEventKey, tokenID) {
+		t.FailNow()
+	}
+}
+
+func Test_ApproveCompareAndSet_mismatch_failure(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+
+	// spender front-ran the change
+	stub.MockInvoke("txSpend", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("spender"), []byte("100")})
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res := stub.MockInvoke("txCAS", [][]byte{[]byte("approveCompareAndSet"), []byte(address), []byte("spender"), []byte("100"), []byte("50")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, address, "spender", true)
+	if string(allowance) != "0" || len(stub.ChaincodeEventsChannel) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_SeedBalances_success(t *testing.T) {
+	stub := initERC20(t)
+	balances := `[{"address":"` + address + `","amount":"500"},{"address":"alice","amount":"300"},{"address":"bob","amount":"200"}]`
+	res := stub.MockInvoke("txSeed", [][]byte{[]byte("seedBalances"), []byte(tokenID), []byte(address), []byte(balances)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	seed := model.BalanceSeed{}
+	json.Unmarshal(res.GetPayload(), &seed)
+	if seed.Seeded != 3 || seed.TotalSupply.Int64() != 1000 {
+		t.FailNow()
+	}
+
+	// balances are replaced and the supply matches them
+	for owner, amount := range map[string]int64{address: 500, "alice": 300, "bob": 200} {
+		balance, _ := repository.GetBalance(stub, owner, true)
+		if balance.Int64() != amount {
+			t.FailNow()
+		}
+	}
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if erc20.GetTotalSupply().Int64() != 1000 {
+		t.FailNow()
+	}
+
+	// seeded holders have accounts
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("accountInfo"), []byte("alice")})
+	accountInfo := model.AccountInfo{}
+	json.Unmarshal(res.GetPayload(), &accountInfo)
+	if res.Status != shim.OK || accountInfo.Address != "alice" || accountInfo.FirstTxID != "txSeed" {
+		t.FailNow()
+	}
+
+	// seeding is one-time
+	res = stub.MockInvoke("txSeed2", [][]byte{[]byte("seedBalances"), []byte(tokenID), []byte(address), []byte(`[{"address":"carol","amount":"1"}]`)})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+}
+
+func Test_SeedBalances_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// duplicate addresses are rejected
+	balances := `[{"address":"alice","amount":"300"},{"address":"alice","amount":"200"}]`
+	res := stub.MockInvoke("txSeed", [][]byte{[]byte("seedBalances"), []byte(tokenID), []byte(address), []byte(balances)})
+	if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "duplicate") {
+		t.FailNow()
+	}
+
+	// only owner can seed
+	res = stub.MockInvoke("txSeed", [][]byte{[]byte("seedBalances"), []byte(tokenID), []byte("alice"), []byte(`[{"address":"alice","amount":"300"}]`)})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "alice", true)
+	if balance.Sign() != 0 {
+		t.FailNow()
+	}
+
+	// seeded addresses pass the recipient checks like transfer recipients
+	stub.MockInvoke("txWhitelist", [][]byte{[]byte("setWhitelistMode"), []byte(tokenID), []byte(address), []byte("true")})
+	res = stub.MockInvoke("txSeed", [][]byte{[]byte("seedBalances"), []byte(tokenID), []byte(address), []byte(`[{"address":"alice","amount":"300"}]`)})
+	if res.Status != model.StatusForbidden || !strings.Contains(res.Message, "invalid seed entry 0") {
+		t.FailNow()
+	}
+}
+
+func Test_MalformedParams_badRequest_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// malformed batch & seed JSON are the caller's error (400), not the chaincode's (500)
+	for _, args := range [][][]byte{
+		{[]byte("transferBatch"), []byte(address), []byte(`not json`)},
+		{[]byte("transferBatch"), []byte(address), []byte(`[]`)},
+		{[]byte("transferBatch"), []byte(address), []byte(`[{"recipient": "", "amount": "1"}]`)},
+		{[]byte("transferBatch"), []byte(address), []byte(`[{"recipient": "` + address + `", "amount": "1"}]`)},
+		{[]byte("transferSplit"), []byte(address), []byte("a"), []byte("0"), []byte("b"), []byte("1")},
+		{[]byte("seedBalances"), []byte(tokenID), []byte(address), []byte(`{"address":"alice"}`)},
+		{[]byte("seedBalances"), []byte(tokenID), []byte(address), []byte(`[{"address":"alice","amount":"1"},{"address":"alice","amount":"1"}]`)},
+	} {
+		res := stub.MockInvoke("txMalformed", args)
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+
+	// malformed options of Init
+	for _, options := range []string{`not json`, `{"unknown": 1}`} {
+		initStub := shim.NewMockStub("erc20", NewChaincode())
+		res := initStub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount)), []byte(options)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+
+	// spending more than the allowance is a conflict (409)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+	res := stub.MockInvoke("txTransferFrom", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte("101")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+}
+
+func Test_ClaimOwnership_success(t *testing.T) {
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount)), []byte(`{"backupOwner": "backup", "inactivityPeriod": 100}`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	createdAt := erc20.OwnerActiveAt
+	if createdAt == 0 || erc20.BackupOwner != "backup" {
+		t.FailNow()
+	}
+
+	// an owner action within the period resets the inactivity
+	tStub := newTestStub(stub)
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: createdAt + 50}
+	res = tStub.MockInvoke("txSetName", [][]byte{[]byte("setName"), []byte(tokenID), []byte(address), []byte("newName")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: createdAt + 120}
+	res = tStub.MockInvoke("txClaim", [][]byte{[]byte("claimOwnership"), []byte(tokenID), []byte("backup")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+
+	// only backup owner can claim
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: createdAt + 150}
+	res = tStub.MockInvoke("txClaim", [][]byte{[]byte("claimOwnership"), []byte(tokenID), []byte("other")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+	res = tStub.MockInvoke("txClaim", [][]byte{[]byte("claimOwnership"), []byte(tokenID), []byte("backup")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	erc20, _ = repository.GetERC20Metadata(stub, tokenID)
+	if *erc20.GetOwner() != "backup" || erc20.BackupOwner != "" {
+		t.FailNow()
+	}
+	data := <-stub.ChaincodeEventsChannel
+	event := model.OwnershipTransferredEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.OwnershipTransferredEventKey, tokenID) || event.PreviousOwner != address || event.NewOwner != "backup" {
+		t.FailNow()
+	}
+}
+
+func Test_Init_backupOwner_failure(t *testing.T) {
+	for _, options := range []string{`{"backupOwner": "backup"}`, `{"inactivityPeriod": 100}`, `{"backupOwner": "` + address + `", "inactivityPeriod": 100}`, `{"backupOwner": "backup", "inactivityPeriod": -1}`} {
+		stub := shim.NewMockStub("erc20", NewChaincode())
+		res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount)), []byte(options)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_SpenderCount_success(t *testing.T) {
+	stub := initERC20(t)
+	for _, spender := range []string{"spender1", "spender2", "spender3"} {
+		stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte(spender), []byte("10")})
+	}
+
+	// revoked & spent allowances don't count
+	stub.MockInvoke("txRevoke", [][]byte{[]byte("approve"), []byte(address), []byte("spender2"), []byte("0")})
+	stub.MockInvoke("txSpend", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender3"), []byte("spender3"), []byte("10")})
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("spenderCount"), []byte(address)})
+	if res.Status != shim.OK || string(res.GetPayload()) != "1" {
+		t.FailNow()
+	}
+
+	// owner without allowances
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("spenderCount"), []byte("nobody")})
+	if res.Status != shim.OK || string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+}
+
+func Test_GetState_tooLong_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// balance corrupted out of band
+	balanceKey, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{address})
+	stub.MockTransactionStart("txCorrupt")
+	stub.PutState(balanceKey, []byte(strings.Repeat("9", 129)))
+	stub.MockTransactionEnd("txCorrupt")
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if res.Status != model.StatusInternalError || !strings.Contains(res.Message, "exceeds the max") {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	if res.Status != model.StatusInternalError {
+		t.FailNow()
+	}
+
+	// metadata corrupted out of band
+	tokenKey, _ := stub.CreateCompositeKey(repository.TokenPrefix, []string{tokenID})
+	stub.MockTransactionStart("txCorrupt")
+	stub.PutState(tokenKey, []byte(`{"id":"`+tokenID+`","name":"`+strings.Repeat("a", 256*1024)+`"}`))
+	stub.MockTransactionEnd("txCorrupt")
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	if res.Status != model.StatusInternalError || !strings.Contains(res.Message, "exceeds the max") {
+		t.FailNow()
+	}
+}
+
+func Test_MintTo_success(t *testing.T) {
+	stub := initERC20(t)
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	res := stub.MockInvoke("txMintTo", [][]byte{[]byte("mintTo"), []byte(tokenID), []byte(address), []byte("recipient"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "recipient", true)
+	if balance.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// one event carries the credit & the new supply
+	data := <-stub.ChaincodeEventsChannel
+	event := model.MintEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.MintEventKey, tokenID) || event.Recipient != "recipient" || event.Amount.Int64() != 100 {
+		t.FailNow()
+	}
+	if event.Supply.OldTotalSupply.Int64() != initAmount || event.Supply.TotalSupply.Int64() != initAmount+100 {
+		t.FailNow()
+	}
+}
+
+func Test_MintTo_invalidRecipient_failure(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte("dead")})
+	for _, recipient := range []string{"", " ", "0", "0x0000000000000000000000000000000000000000", "dead"} {
+		res := stub.MockInvoke("txMintTo", [][]byte{[]byte("mintTo"), []byte(tokenID), []byte(address), []byte(recipient), []byte("100")})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if erc20.GetTotalSupply().Int64() != initAmount {
+		t.FailNow()
+	}
+}
+
+func Test_TotalAllowanceGranted_success(t *testing.T) {
+	stub := initERC20(t)
+	for spender, amount := range map[string]string{"spender1": "10", "spender2": "20", "spender3": strconv.Itoa(math.MaxInt64)} {
+		stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte(spender), []byte(amount)})
+	}
+	stub.MockInvoke("txRevoke", [][]byte{[]byte("approve"), []byte(address), []byte("spender2"), []byte("0")})
+
+	// the sum doesn't overflow int
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("totalAllowanceGranted"), []byte(address)})
+	if res.Status != shim.OK || string(res.GetPayload()) != "9223372036854775817" {
+		t.FailNow()
+	}
+}
+
+func Test_LockMetadata_immutable_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txLock", [][]byte{[]byte("lockMetadata"), []byte(tokenID), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the locked state is surfaced in metadata
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if !erc20.MetadataLocked {
+		t.FailNow()
+	}
+
+	// name & symbol never change, directly or via admin, and the lock cannot be repeated
+	for _, arguments := range [][][]byte{
+		{[]byte("setName"), []byte(tokenID), []byte(address), []byte("newName")},
+		{[]byte("setSymbol"), []byte(tokenID), []byte(address), []byte("NEW")},
+		{[]byte("admin"), []byte("setName"), []byte(tokenID), []byte(address), []byte("newName")},
+		{[]byte("lockMetadata"), []byte(tokenID), []byte(address)},
+	} {
+		res := stub.MockInvoke("txSet", arguments)
+		if res.Status != model.StatusConflict {
+			t.FailNow()
+		}
+	}
+
+	// an upgrade keeps name, symbol & decimals and the lock
+	res = stub.MockInit("txUpgrade", [][]byte{[]byte("init")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	erc20Metadata, _ := repository.GetERC20Metadata(stub, tokenID)
+	if *erc20Metadata.GetName() != tokenName || *erc20Metadata.GetSymbol() != "dt" || erc20Metadata.Decimals != 0 || !erc20Metadata.MetadataLocked {
+		t.FailNow()
+	}
+}
+
+func Test_SimulateTransfer_success(t *testing.T) {
+	stub := initERC20(t)
+	simulate := func(caller, amount string) model.TransferSimulation {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("simulateTransfer"), []byte(caller), []byte("recipient"), []byte(amount)})
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+		simulation := model.TransferSimulation{}
+		json.Unmarshal(res.GetPayload(), &simulation)
+		return simulation
+	}
+
+	simulation := simulate(address, "10")
+	if !simulation.Passes || simulation.NetAmount.Int64() != 10 || len(simulation.Reasons) != 0 {
+		t.FailNow()
+	}
+
+	// each failing check is reported
+	stub.MockInvoke("txWhitelist", [][]byte{[]byte("setWhitelistMode"), []byte(tokenID), []byte(address), []byte("true")})
+	simulation = simulate("poor", "10")
+	if simulation.Passes || simulation.Checks.Balance || simulation.Checks.Whitelist || !simulation.Checks.Cooldown || len(simulation.Reasons) != 2 {
+		t.FailNow()
+	}
+
+	// the simulation doesn't write state
+	balance, _ := repository.GetBalance(stub, "recipient", true)
+	if balance.Sign() != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_EstimateFee_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("estimateFee"), []byte("1500")})
+	estimate := model.FeeEstimate{}
+	json.Unmarshal(res.GetPayload(), &estimate)
+	if res.Status != shim.OK || estimate.Fee != "0" || estimate.Net != "1500" {
+		t.FailNow()
+	}
+
+	// amount must be a positive integer
+	for _, amount := range []string{"0", "-1", "abc"} {
+		res = stub.MockInvoke("txQuery", [][]byte{[]byte("estimateFee"), []byte(amount)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+}
+
+// balanceReaderChaincode reads a token balance through InvokeChaincode, as a DeFi chaincode would
+type balanceReaderChaincode struct {
+}
+
+func (cc *balanceReaderChaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (cc *balanceReaderChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	fcn, params := stub.GetFunctionAndParameters()
+	return stub.InvokeChaincode("erc20", [][]byte{[]byte(fcn), []byte(params[0])}, "")
+}
+
+func Test_ChaincodeBalanceOf_crossChaincode_success(t *testing.T) {
+	stub := initERC20(t)
+	reader := shim.NewMockStub("reader", &balanceReaderChaincode{})
+	reader.MockPeerChaincode("erc20", stub)
+
+	res := reader.MockInvoke("txRead", [][]byte{[]byte("chaincodeBalanceOf"), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance := model.ChaincodeBalance{}
+	err := json.Unmarshal(res.GetPayload(), &balance)
+	if err != nil || balance.TokenID != tokenID || balance.Address != address || balance.Balance != strconv.Itoa(initAmount) || balance.Decimals != 0 {
+		t.FailNow()
+	}
+
+	// balanceOf is the raw contract as well, missing address is 0
+	res = reader.MockInvoke("txRead", [][]byte{[]byte("balanceOf"), []byte("nobody")})
+	if res.Status != shim.OK || string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+	res = reader.MockInvoke("txRead", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if res.Status != shim.OK || string(res.GetPayload()) != strconv.Itoa(initAmount) {
+		t.FailNow()
+	}
+}
+
+// receiverChaincode is a target chaincode of approveAndCall
+type receiverChaincode struct {
+}
+
+func (cc *receiverChaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (cc *receiverChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	fcn, params := stub.GetFunctionAndParameters()
+	if fcn != "onApprove" {
+		return shim.Error("unknown function")
+	}
+	return shim.Success([]byte(params[0] + " approved " + params[2]))
+}
+
+func Test_ApproveAndCall_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockPeerChaincode("receiver", shim.NewMockStub("receiver", &receiverChaincode{}))
+
+	arguments := [][]byte{[]byte("approveAndCall"), []byte(address), []byte("spender"), []byte("100"), []byte("receiver"), []byte("onApprove")}
+	res := stub.MockInvoke("txApproveAndCall", arguments)
+	if res.Status != shim.OK || string(res.GetPayload()) != address+" approved 100" {
+		t.FailNow()
+	}
+
+	// emit approval event
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EventName(repository.ApprovalEventKey, tokenID) {
+		t.FailNow()
+	}
+}
+
+func Test_ApproveAndCall_callFailed_failure(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockPeerChaincode("receiver", shim.NewMockStub("receiver", &receiverChaincode{}))
+
+	arguments := [][]byte{[]byte("approveAndCall"), []byte(address), []byte("spender"), []byte("100"), []byte("receiver"), []byte("unknown")}
+	res := stub.MockInvoke("txApproveAndCall", arguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Invoke_unknownFunction_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txUnknown", [][]byte{[]byte("transfr")})
+	if res.Status != 404 || !strings.Contains(res.Message, "transfr") || !strings.Contains(res.Message, "transfer") {
+		t.FailNow()
+	}
+}
+
+func Test_Invoke_paramTooLarge_failure(t *testing.T) {
+	stub := initERC20(t)
+	hugeAddress := strings.Repeat("a", maxParamLength+1)
+	res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(hugeAddress), []byte("100")})
+	if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "parameter too large, param 1") {
+		t.FailNow()
+	}
+}
+
+func Test_Burn_insufficientBalance_failure(t *testing.T) {
+	stub := initERC20(t)
+	arguments := [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte(strconv.Itoa(initAmount + 1))}
+	res := stub.MockInvoke("txBurn", arguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Burn_success(t *testing.T) {
+	stub := initERC20(t)
+	const decreaseAmount = 10000
+	arguments := [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte(strconv.Itoa(decreaseAmount))}
+	res := stub.MockInvoke("txBurn", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// decrease TotalSupply
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	if totalSupply.Int64() != initAmount-decreaseAmount {
+		t.FailNow()
+	}
+
+	// decrease holder balance
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Int64() != initAmount-decreaseAmount {
+		t.FailNow()
+	}
+}
+
+func Test_Burn_belowReserve_failure(t *testing.T) {
+	stub := initERC20(t)
+	const reserve = initAmount - 100
+	res := stub.MockInvoke("txReserve", [][]byte{[]byte("setReserve"), []byte(tokenID), []byte(address), []byte(strconv.Itoa(reserve))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// burning below the reserve is rejected without changing state
+	res = stub.MockInvoke("txBurn", [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte("101")})
+	if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "reserve") {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// burning down to the reserve is allowed
+	res = stub.MockInvoke("txBurn", [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// reserve is surfaced in metadata
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if erc20.GetReserveSupply().Int64() != reserve {
+		t.FailNow()
+	}
+}
+
+func Test_SetEmitEvents_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txEmitEvents", [][]byte{[]byte("setEmitEvents"), []byte(tokenID), []byte(address), []byte("false")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+
+	// transfer, mint and burn don't emit event
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+	stub.MockInvoke("txMint", [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte(address), []byte("10")})
+	stub.MockInvoke("txBurn", [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte("10")})
+	if len(stub.ChaincodeEventsChannel) != 0 {
+		t.FailNow()
+	}
+
+	// the setting is surfaced in metadata
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if erc20.IsEmitEvents() {
+		t.FailNow()
+	}
+
+	// only owner can change the setting & the value must be true or false
+	res = stub.MockInvoke("txEmitEvents", [][]byte{[]byte("setEmitEvents"), []byte(tokenID), []byte("recipient"), []byte("true")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txEmitEvents", [][]byte{[]byte("setEmitEvents"), []byte(tokenID), []byte(address), []byte("yes")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	// transfer emits event again after enabling
+	stub.MockInvoke("txEmitEvents", [][]byte{[]byte("setEmitEvents"), []byte(tokenID), []byte(address), []byte("true")})
+	<-stub.ChaincodeEventsChannel
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+	if len(stub.ChaincodeEventsChannel) != 1 {
+		t.FailNow()
+	}
+}
+
+func Test_SetEmitEvents_batch_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txEmitEvents", [][]byte{[]byte("setEmitEvents"), []byte(tokenID), []byte(address), []byte("false")})
+	<-stub.ChaincodeEventsChannel
+
+	// batch transfers & mints don't emit the aggregated event
+	batch := []byte(`[{"recipient": "a", "amount": "10"}, {"recipient": "b", "amount": "10"}]`)
+	for _, args := range [][][]byte{
+		{[]byte("transferBatch"), []byte(address), batch},
+		{[]byte("mintBatch"), []byte(tokenID), []byte(address), batch},
+		{[]byte("treasuryDistribute"), []byte(tokenID), []byte(address), batch},
+		{[]byte("transferSplit"), []byte(address), []byte("a"), []byte("10"), []byte("b"), []byte("10")},
+	} {
+		res := stub.MockInvoke("txBatch", args)
+		if res.Status != shim.OK || len(stub.ChaincodeEventsChannel) != 0 {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_ListTokens_success(t *testing.T) {
+	stub := initERC20(t)
+	res := newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("listTokens"), []byte("10"), []byte("")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// balance key of owner is excluded
+	page := model.TokenPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Tokens) != 1 || *page.Tokens[0].GetID() != tokenID {
+		t.FailNow()
+	}
+}
+
+func Test_PageSize_outOfRange_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// page size above MaxInt32 would wrap in the int32 conversion
+	for _, pageSize := range []string{"0", "2147483648", "4294967297"} {
+		for _, args := range [][]string{
+			{"listTokens", pageSize, ""},
+			{"recentTransfers", address, pageSize, ""},
+			{"categoryTransfers", "payroll", pageSize, ""},
+			{"reconcileReport", pageSize, "", "0"},
+		} {
+			input := [][]byte{}
+			for _, arg := range args {
+				input = append(input, []byte(arg))
+			}
+			res := newTestStub(stub).MockInvoke("txQuery", input)
+			if res.Status != shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "pageSize") {
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func Test_Init_minEndorsements(t *testing.T) {
+	cc := NewChaincode()
+	stub := shim.NewMockStub("erc20", cc)
+	initArgs := [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(strconv.Itoa(initAmount))}
+
+	// minEndorsements must be positive
+	res := stub.MockInit("1", append(initArgs, []byte(`{"minEndorsements": 0}`)))
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	res = stub.MockInit("1", append(initArgs, []byte(`{"minEndorsements": 2}`)))
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// get metadata
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if res.Status != shim.OK || *erc20.GetMinEndorsements() != 2 {
+		t.FailNow()
+	}
+}
+
+func Test_Clawback_notOwner_failure(t *testing.T) {
+	stub := initERC20(t)
+	arguments := [][]byte{[]byte("clawback"), []byte(tokenID), []byte("stranger"), []byte(address), []byte("stranger"), []byte("100")}
+	res := stub.MockInvoke("txClawback", arguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Clawback_success(t *testing.T) {
+	stub := initERC20(t)
+	const fraudster = "fraudster"
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(fraudster), []byte("100")})
+	<-stub.ChaincodeEventsChannel
+
+	// zero amount is rejected
+	arguments := [][]byte{[]byte("clawback"), []byte(tokenID), []byte(address), []byte(fraudster), []byte(address), []byte("0")}
+	res := stub.MockInvoke("txClawback", arguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	arguments = [][]byte{[]byte("clawback"), []byte(tokenID), []byte(address), []byte(fraudster), []byte(address), []byte("100")}
+	res = stub.MockInvoke("txClawback", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	balance, _ := repository.GetBalance(stub, fraudster, true)
+	if balance.Sign() != 0 {
+		t.FailNow()
+	}
+
+	// emit clawback event
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EventName(repository.ClawbackEventKey, tokenID) {
+		t.FailNow()
+	}
+}
+
+func Test_SetRateLimit_exceeded_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txSetRateLimit", [][]byte{[]byte("setRateLimit"), []byte(tokenID), []byte(address), []byte("60"), []byte("2"), []byte("0")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// fix the timestamp in one window
+	tStub := newTestStub(stub)
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: 6000}
+	arguments := [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")}
+	for i := 1; i <= 2; i++ {
+		if res := tStub.MockInvoke("txTransfer", arguments); res.Status != shim.OK {
+			t.FailNow()
+		}
+	}
+	if res := tStub.MockInvoke("txTransfer", arguments); res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	// next window
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: 6060}
+	if res := tStub.MockInvoke("txTransfer", arguments); res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_SetCircuitBreaker_trip_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: 6000}
+	res := stub.MockInvoke("txSetCircuitBreaker", [][]byte{[]byte("setCircuitBreaker"), []byte(tokenID), []byte(address), []byte("60"), []byte("150")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	// transfer & transferFrom add up, the tripping transferFrom succeeds with the paused event
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+	res = stub.MockInvoke("txTransferFrom", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte("60")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	// MockStub queues every SetEvent, Fabric keeps the last one
+	data := <-stub.ChaincodeEventsChannel
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		data = <-stub.ChaincodeEventsChannel
+	}
+	event := model.PausedEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.PausedEventKey, tokenID) || event.Reason != "volume threshold exceeded" || event.Volume.Int64() != 160 || event.MaxVolume.Int64() != 150 {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "recipient", true)
+	if balance.Int64() != 160 {
+		t.FailNow()
+	}
+
+	// paused until owner unpauses, even in the next window
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: 6060}
+	arguments := [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")}
+	if res := stub.MockInvoke("txTransfer", arguments); res.Status != model.StatusUnavailable {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txUnpause", [][]byte{[]byte("unpause"), []byte(tokenID), []byte("recipient")}); res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txUnpause", [][]byte{[]byte("unpause"), []byte(tokenID), []byte(address)}); res.Status != shim.OK {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txTransfer", arguments); res.Status != shim.OK {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txUnpause", [][]byte{[]byte("unpause"), []byte(tokenID), []byte(address)}); res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+
+	// enabled needs a positive threshold, "0" windowSeconds disables
+	if res := stub.MockInvoke("txSetCircuitBreaker", [][]byte{[]byte("setCircuitBreaker"), []byte(tokenID), []byte(address), []byte("60"), []byte("0")}); res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txSetCircuitBreaker", [][]byte{[]byte("setCircuitBreaker"), []byte(tokenID), []byte(address), []byte("0"), []byte("0")}); res.Status != shim.OK {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1000")}); res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_SetCircuitBreaker_batchAndEscrow_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: 6000}
+	res := stub.MockInvoke("txSetCircuitBreaker", [][]byte{[]byte("setCircuitBreaker"), []byte(tokenID), []byte(address), []byte("60"), []byte("150")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the batch total & the escrow lock add up, the tripping escrow succeeds
+	batch := `[{"recipient":"card1","amount":"40"},{"recipient":"card2","amount":"60"}]`
+	if res := stub.MockInvoke("txBatch", [][]byte{[]byte("transferBatch"), []byte(address), []byte(batch)}); res.Status != shim.OK {
+		t.FailNow()
+	}
+	arguments := [][]byte{[]byte("conditionalTransfer"), []byte(address), []byte("recipient"), []byte("60"), []byte("7000")}
+	if res := stub.MockInvoke("txEscrow", arguments); res.Status != shim.OK {
+		t.FailNow()
+	}
+	state, _ := repository.GetCircuitBreakerState(stub)
+	if !state.Paused || state.Volume.Int64() != 160 {
+		t.FailNow()
+	}
+
+	// the simulation reports the pause
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("simulateTransfer"), []byte(address), []byte("recipient"), []byte("1")})
+	simulation := model.TransferSimulation{}
+	json.Unmarshal(res.GetPayload(), &simulation)
+	if res.Status != shim.OK || simulation.Passes || simulation.Checks.NotPaused || !simulation.Checks.Balance || len(simulation.Reasons) != 1 {
+		t.FailNow()
+	}
+}
+
+func Test_Sweep_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	for recipient, amount := range map[string]string{"dust1": "3", "dust2": "9", "holder": "10", "collector": "5"} {
+		stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(recipient), []byte(amount)})
+	}
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	// balances below 10 except the collector's are swept, one page covers all
+	arguments := [][]byte{[]byte("sweep"), []byte(tokenID), []byte(address), []byte("collector"), []byte("10"), []byte("10"), []byte("")}
+	res := stub.MockInvoke("txSweep", arguments)
+	result := model.SweepResult{}
+	json.Unmarshal(res.GetPayload(), &result)
+	if res.Status != shim.OK || result.Swept != 2 || result.Total.Int64() != 12 || result.Bookmark != "" {
+		t.FailNow()
+	}
+	collectorBalance, _ := repository.GetBalance(stub, "collector", true)
+	holderBalance, _ := repository.GetBalance(stub, "holder", true)
+	if collectorBalance.Int64() != 17 || holderBalance.Int64() != 10 {
+		t.FailNow()
+	}
+	dustKey, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{"dust1"})
+	if _, ok := stub.State[dustKey]; ok {
+		t.FailNow()
+	}
+
+	// each swept holder's txlog has its movement, the collector's has both
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte("dust2"), []byte("txSweep")})
+	transferLogs := []model.TransferLog{}
+	json.Unmarshal(res.GetPayload(), &transferLogs)
+	if res.Status != shim.OK || len(transferLogs) != 1 || transferLogs[0].Event.Recipient != "collector" || transferLogs[0].Event.Amount.Int64() != 9 {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte("collector"), []byte("txSweep")})
+	transferLogs = []model.TransferLog{}
+	json.Unmarshal(res.GetPayload(), &transferLogs)
+	if res.Status != shim.OK || len(transferLogs) != 2 {
+		t.FailNow()
+	}
+
+	// one event lists the swept accounts
+	data := <-stub.ChaincodeEventsChannel
+	event := model.SweepEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.SweepEventKey, tokenID) || len(event.Accounts) != 2 || event.Collector != "collector" || event.Total.Int64() != 12 {
+		t.FailNow()
+	}
+
+	// supply is unchanged
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("checkInvariant"), []byte(tokenID)})
+	report := model.InvariantReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if !report.Match {
+		t.FailNow()
+	}
+
+	// nothing is left to sweep
+	res = stub.MockInvoke("txSweep", arguments)
+	json.Unmarshal(res.GetPayload(), &result)
+	if res.Status != shim.OK || result.Swept != 0 || len(stub.ChaincodeEventsChannel) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Sweep_invalidParams_failure(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	for _, arguments := range [][]string{
+		{"collector", "0", "10"},
+		{"collector", "abc", "10"},
+		{"coll ector", "10", "10"},
+		{"collector", "10", "0"},
+	} {
+		res := stub.MockInvoke("txSweep", [][]byte{[]byte("sweep"), []byte(tokenID), []byte(address), []byte(arguments[0]), []byte(arguments[1]), []byte(arguments[2]), []byte("")})
+		if res.Status != model.StatusBadRequest {
+			t.Fatalf("%v: %d", arguments, res.Status)
+		}
+	}
+
+	// only owner can sweep
+	res := stub.MockInvoke("txSweep", [][]byte{[]byte("sweep"), []byte(tokenID), []byte("recipient"), []byte("collector"), []byte("10"), []byte("10"), []byte("")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// the collector passes the recipient checks, e.g. the whitelist
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("dust"), []byte("3")})
+	stub.MockInvoke("txWhitelist", [][]byte{[]byte("setWhitelistMode"), []byte(tokenID), []byte(address), []byte("true")})
+	res = stub.MockInvoke("txSweep", [][]byte{[]byte("sweep"), []byte(tokenID), []byte(address), []byte("collector"), []byte("10"), []byte("10"), []byte("")})
+	if res.Status != model.StatusForbidden || !strings.Contains(res.Message, "whitelist") {
+		t.FailNow()
+	}
+}
+
+func Test_Limits_nearUint64_failure(t *testing.T) {
+	const maxUint64 = "18446744073709551615"
+	const beyondUint64 = "18446744073709551616"
+	initLarge := func() *testStub {
+		stub := newTestStub(shim.NewMockStub("erc20", NewChaincode()))
+		stub.txTimestamp = &timestamp.Timestamp{Seconds: 6000}
+		res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("100000000000000000000")})
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+		return stub
+	}
+	admin := func(stub *testStub, command string, params ...string) {
+		arguments := [][]byte{[]byte(command), []byte(tokenID), []byte(address)}
+		for _, param := range params {
+			arguments = append(arguments, []byte(param))
+		}
+		if res := stub.MockInvoke("txAdmin", arguments); res.Status != shim.OK {
+			t.Fatalf("%s: %s", command, res.Message)
+		}
+	}
+	transferStatus := func(stub *testStub, recipient, amount string) int32 {
+		return stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(recipient), []byte(amount)}).Status
+	}
+
+	// the rate limit amount at the uint64 boundary
+	stub := initLarge()
+	admin(stub, "setRateLimit", "60", "0", maxUint64)
+	if transferStatus(stub, "recipient", maxUint64) != shim.OK || transferStatus(stub, "recipient", "1") != model.StatusConflict {
+		t.FailNow()
+	}
+
+	// the verified threshold at the uint64 boundary
+	stub = initLarge()
+	admin(stub, "setVerifiedThreshold", maxUint64)
+	if transferStatus(stub, "recipient", maxUint64) != shim.OK || transferStatus(stub, "recipient", beyondUint64) != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// the circuit breaker volume at the uint64 boundary
+	stub = initLarge()
+	admin(stub, "setCircuitBreaker", "60", maxUint64)
+	if transferStatus(stub, "recipient", maxUint64) != shim.OK || transferStatus(stub, "recipient", "1") != shim.OK || transferStatus(stub, "recipient", "1") != model.StatusUnavailable {
+		t.FailNow()
+	}
+
+	// the longest cooldown doesn't wrap around to the past
+	stub = initLarge()
+	admin(stub, "setTransferCooldown", "9223372036854775807")
+	if transferStatus(stub, "recipient", "1") != shim.OK || transferStatus(stub, "recipient", "1") != model.StatusConflict {
+		t.FailNow()
+	}
+}
+
+func Test_ReconcileReport_pages_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	for _, recipient := range []string{"account1", "account2", "account3"} {
+		stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(recipient), []byte("10")})
+	}
+
+	// drive the pages to completion with the returned bookmark & partialSum
+	bookmark, partialSum, pages, accounts := "", "0", 0, 0
+	page := model.ReconcilePage{}
+	for {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("reconcileReport"), []byte("2"), []byte(bookmark), []byte(partialSum)})
+		page = model.ReconcilePage{}
+		json.Unmarshal(res.GetPayload(), &page)
+		if res.Status != shim.OK || len(page.Balances) > 2 {
+			t.FailNow()
+		}
+		pages++
+		accounts += len(page.Balances)
+		bookmark, partialSum = page.Bookmark, page.PartialSum.String()
+		if page.Last {
+			break
+		}
+		if page.TotalSupply != nil || pages > 2 {
+			t.FailNow()
+		}
+	}
+	if pages != 2 || accounts != 4 || page.PartialSum.Int64() != initAmount || page.TotalSupply.Int64() != initAmount || page.Escrowed.Sign() != 0 || !page.Match {
+		t.FailNow()
+	}
+
+	// a wrong partialSum doesn't match
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("reconcileReport"), []byte("10"), []byte(""), []byte("1")})
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || !page.Last || page.Match {
+		t.FailNow()
+	}
+	for _, arguments := range [][]string{{"0", "0"}, {"10", "-1"}, {"10", "abc"}} {
+		res = stub.MockInvoke("txQuery", [][]byte{[]byte("reconcileReport"), []byte(arguments[0]), []byte(""), []byte(arguments[1])})
+		if res.Status != model.StatusBadRequest {
+			t.Fatalf("%v: %d", arguments, res.Status)
+		}
+	}
+}
+
+func Test_Namespace_balanceAndTokenKeys_disjoint(t *testing.T) {
+	stub := shim.NewMockStub("erc20", NewChaincode())
+
+	// a name used both as address and tokenID never shares a key, whatever the name
+	for _, name := range []string{tokenID, address, "token", "balance", "", "a/b", "balance\x01dappToken", strings.Repeat("x", util.MaxAddressLength)} {
+		balanceKey, err := stub.CreateCompositeKey(repository.BalancePrefix, []string{name})
+		if err != nil {
+			t.FailNow()
+		}
+		tokenKey, err := stub.CreateCompositeKey(repository.TokenPrefix, []string{name})
+		if err != nil || balanceKey == tokenKey {
+			t.Fatalf("%q collides", name)
+		}
+		balanceType, _, _ := stub.SplitCompositeKey(balanceKey)
+		tokenType, _, _ := stub.SplitCompositeKey(tokenKey)
+		if balanceType != repository.BalancePrefix || tokenType != repository.TokenPrefix {
+			t.Fatalf("%q leaves its namespace", name)
+		}
+	}
+
+	// attributes cannot smuggle the delimiter into another namespace
+	if _, err := stub.CreateCompositeKey(repository.BalancePrefix, []string{"x\x00token"}); err == nil {
+		t.FailNow()
+	}
+}
+
+func Test_VerifyNamespaceIntegrity_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("10")})
+	arguments := [][]byte{[]byte("verifyNamespaceIntegrity"), []byte(tokenID), []byte(address)}
+
+	// every key written by the chaincode matches the schema
+	res := stub.MockInvoke("txQuery", arguments)
+	report := model.NamespaceReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if res.Status != shim.OK || report.Scanned == 0 || len(report.UnexpectedKeys) != 0 {
+		t.FailNow()
+	}
+
+	// a bare key, a balance key of two attributes and an unknown config key are flagged
+	malformedBalance, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{address, "extra"})
+	unknownConfig, _ := stub.CreateCompositeKey(repository.ConfigPrefix, []string{"unknown"})
+	stub.MockTransactionStart("txCorrupt")
+	stub.PutState(address, []byte("100"))
+	stub.PutState(malformedBalance, []byte("100"))
+	stub.PutState(unknownConfig, []byte("1"))
+	stub.MockTransactionEnd("txCorrupt")
+
+	res = stub.MockInvoke("txQuery", arguments)
+	report = model.NamespaceReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if res.Status != shim.OK || len(report.UnexpectedKeys) != 3 {
+		t.FailNow()
+	}
+	flagged := map[string]bool{}
+	for _, unexpected := range report.UnexpectedKeys {
+		flagged[unexpected.Key] = len(unexpected.Reason) != 0
+	}
+	if !flagged[address] || !flagged[malformedBalance] || !flagged[unknownConfig] {
+		t.FailNow()
+	}
+
+	// only owner can scan
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("verifyNamespaceIntegrity"), []byte(tokenID), []byte("recipient")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+}
+
+func Test_SetRecurringAllowance_periods_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	allowanceAt := func(seconds int64) string {
+		stub.txTimestamp = &timestamp.Timestamp{Seconds: seconds}
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("allowance"), []byte(address), []byte("spender")})
+		return string(res.GetPayload())
+	}
+	spendAt := func(seconds int64, amount string) int32 {
+		stub.txTimestamp = &timestamp.Timestamp{Seconds: seconds}
+		return stub.MockInvoke("txTransferFrom", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte(amount)}).Status
+	}
+
+	// 50 every 100 seconds from 1000
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: 1000}
+	res := stub.MockInvoke("txRecurring", [][]byte{[]byte("setRecurringAllowance"), []byte(address), []byte("spender"), []byte("50"), []byte("100")})
+	if res.Status != shim.OK || allowanceAt(1000) != "50" {
+		t.FailNow()
+	}
+
+	// the last second of the first period has what is left
+	if spendAt(1050, "30") != shim.OK || allowanceAt(1099) != "20" || spendAt(1099, "21") != model.StatusConflict {
+		t.FailNow()
+	}
+
+	// the unused allowance is not carried over at the boundary
+	if allowanceAt(1100) != "50" || spendAt(1150, "10") != shim.OK || allowanceAt(1199) != "40" {
+		t.FailNow()
+	}
+
+	// skipped periods keep the alignment
+	if allowanceAt(1299) != "50" || spendAt(1299, "50") != shim.OK {
+		t.FailNow()
+	}
+	recurring, _ := repository.GetRecurringAllowance(stub, address, "spender")
+	if recurring.LastReset != 1200 || allowanceAt(1300) != "50" {
+		t.FailNow()
+	}
+
+	// approve replaces the recurring allowance
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("5")})
+	if allowanceAt(1400) != "5" {
+		t.FailNow()
+	}
+}
+
+func Test_SetKeyPolicy_success(t *testing.T) {
+	stub := initERC20(t)
+	metadataKey, _ := stub.CreateCompositeKey(repository.TokenPrefix, []string{tokenID})
+	balanceKey, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{"whale"})
+
+	// metadata & balance keys need the endorsement of every org
+	res := stub.MockInvoke("txSetKeyPolicy", [][]byte{[]byte("setKeyPolicy"), []byte(tokenID), []byte(address), []byte("metadata"), []byte(""), []byte(`["Org1MSP", "Org2MSP"]`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txSetKeyPolicy", [][]byte{[]byte("setKeyPolicy"), []byte(tokenID), []byte(address), []byte("balance"), []byte("whale"), []byte(`["Org1MSP"]`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	for key, orgs := range map[string]int{metadataKey: 2, balanceKey: 1} {
+		policy, _ := stub.GetStateValidationParameter(key)
+		endorsementPolicy, err := statebased.NewStateEP(policy)
+		if err != nil || len(endorsementPolicy.ListOrgs()) != orgs {
+			t.FailNow()
+		}
+	}
+
+	// the empty orgs remove the policy
+	res = stub.MockInvoke("txSetKeyPolicy", [][]byte{[]byte("setKeyPolicy"), []byte(tokenID), []byte(address), []byte("balance"), []byte("whale"), []byte(`[]`)})
+	if policy, _ := stub.GetStateValidationParameter(balanceKey); res.Status != shim.OK || policy != nil {
+		t.FailNow()
+	}
+}
+
+func Test_SetKeyPolicy_failure(t *testing.T) {
+	stub := initERC20(t)
+	invalids := [][]string{
+		{"supply", "", `["Org1MSP"]`},
+		{"metadata", "whale", `["Org1MSP"]`},
+		{"balance", "", `["Org1MSP"]`},
+		{"metadata", "", `["Org1MSP", "Org1MSP"]`},
+		{"metadata", "", `[""]`},
+		{"metadata", "", `"Org1MSP"`},
+	}
+	for _, invalid := range invalids {
+		res := stub.MockInvoke("txSetKeyPolicy", [][]byte{[]byte("setKeyPolicy"), []byte(tokenID), []byte(address), []byte(invalid[0]), []byte(invalid[1]), []byte(invalid[2])})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+
+	// only owner can set key policy
+	res := stub.MockInvoke("txSetKeyPolicy", [][]byte{[]byte("setKeyPolicy"), []byte(tokenID), []byte("stranger"), []byte("metadata"), []byte(""), []byte(`["Org1MSP"]`)})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+}
+
+func Test_BalanceOf_formatted_success(t *testing.T) {
+	cc := NewChaincode()
+	stub := shim.NewMockStub("erc20", cc)
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("12345"), []byte(`{"decimals": 2}`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// raw integer by default
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if string(res.GetPayload()) != "12345" {
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address), []byte("formatted")})
+	if string(res.GetPayload()) != "123.45 dt" {
+		t.FailNow()
+	}
+}
+
+func Test_BalanceOf_displayFormat_success(t *testing.T) {
+	cc := NewChaincode()
+	stub := shim.NewMockStub("erc20", cc)
+
+	// displayFormat must contain both placeholders
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("12345"), []byte(`{"decimals": 2, "displayFormat": "{amount}"}`)})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	// symbol before the amount
+	res = stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("12345"), []byte(`{"decimals": 2, "displayFormat": "{symbol} {amount}"}`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address), []byte("formatted")})
+	if string(res.GetPayload()) != "dt 123.45" {
+		t.FailNow()
+	}
+
+	// displayFormat is surfaced in metadata
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if erc20.GetDisplayFormat() != "{symbol} {amount}" {
+		t.FailNow()
+	}
+}
+
+func Test_TransferBatch_invalidBatch_failure(t *testing.T) {
+	stub := initERC20(t)
+	batches := []string{
+		`[]`,
+		`[{"recipient": "a", "amount": "1", "memo": "unknown field"}]`,
+		`[{"recipient": "a", "amount": "1"}, {"recipient": "a", "amount": "2"}]`,
+		`[{"recipient": "a", "amount": "1"}, {"recipient": "b", "amount": "0"}]`,
+	}
+	for _, batch := range batches {
+		res := stub.MockInvoke("txTransferBatch", [][]byte{[]byte("transferBatch"), []byte(address), []byte(batch)})
+		if res.Status < shim.ERRORTHRESHOLD {
+			t.FailNow()
+		}
+	}
+
+	// error contains the index of the first invalid entry
+	res := stub.MockInvoke("txTransferBatch", [][]byte{[]byte("transferBatch"), []byte(address), []byte(batches[3])})
+	if !strings.Contains(res.Message, "entry 1") {
+		t.FailNow()
+	}
+}
+
+func Test_TransferBatch_success(t *testing.T) {
+	stub := initERC20(t)
+	batch := `[{"recipient": "b", "amount": "200"}, {"recipient": "a", "amount": "100"}]`
+	res := stub.MockInvoke("txTransferBatch", [][]byte{[]byte("transferBatch"), []byte(address), []byte(batch)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	callerBalance, _ := repository.GetBalance(stub, address, true)
+	balanceA, _ := repository.GetBalance(stub, "a", true)
+	balanceB, _ := repository.GetBalance(stub, "b", true)
+	if callerBalance.Int64() != initAmount-300 || balanceA.Int64() != 100 || balanceB.Int64() != 200 {
+		t.FailNow()
+	}
+
+	// emit one aggregated event
+	data := <-stub.ChaincodeEventsChannel
+	event := model.BatchTransferEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.BatchTransferEventKey, tokenID) || len(event.Transfers) != 2 {
+		t.FailNow()
+	}
+
+	// movements are sorted by recipient
+	if event.Transfers[0].Recipient != "a" || event.Transfers[1].Recipient != "b" {
+		t.FailNow()
+	}
+}
+
+func Test_TreasuryDistribute_success(t *testing.T) {
+	stub := initERC20(t)
+	batch := `[{"recipient": "b", "amount": "200"}, {"recipient": "a", "amount": "100"}]`
+	res := stub.MockInvoke("txDistribute", [][]byte{[]byte("treasuryDistribute"), []byte(tokenID), []byte(address), []byte(batch)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	ownerBalance, _ := repository.GetBalance(stub, address, true)
+	balanceA, _ := repository.GetBalance(stub, "a", true)
+	balanceB, _ := repository.GetBalance(stub, "b", true)
+	if ownerBalance.Int64() != initAmount-300 || balanceA.Int64() != 100 || balanceB.Int64() != 200 {
+		t.FailNow()
+	}
+
+	// total supply doesn't change
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if erc20.GetTotalSupply().Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// emit one consolidated event
+	data := <-stub.ChaincodeEventsChannel
+	event := model.BatchTransferEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.BatchTransferEventKey, tokenID) || len(event.Transfers) != 2 || event.Transfers[0].Sender != address {
+		t.FailNow()
+	}
+}
+
+func Test_TreasuryDistribute_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// only owner can distribute
+	batch := `[{"recipient": "a", "amount": "100"}]`
+	res := stub.MockInvoke("txDistribute", [][]byte{[]byte("treasuryDistribute"), []byte(tokenID), []byte("stranger"), []byte(batch)})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// the total exceeding owner's balance credits no recipient
+	batch = `[{"recipient": "a", "amount": "100"}, {"recipient": "b", "amount": "` + strconv.Itoa(initAmount) + `"}]`
+	res = stub.MockInvoke("txDistribute", [][]byte{[]byte("treasuryDistribute"), []byte(tokenID), []byte(address), []byte(batch)})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	ownerBalance, _ := repository.GetBalance(stub, address, true)
+	balanceA, _ := repository.GetBalance(stub, "a", true)
+	if ownerBalance.Int64() != initAmount || balanceA.Int64() != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_burnAddress_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte("0xdead")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+
+	// the transfer to the burn address reduces the total supply instead of crediting it
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("0xdead"), []byte("100")})
+	receipt := model.TransferReceipt{}
+	json.Unmarshal(res.GetPayload(), &receipt)
+	if res.Status != shim.OK || receipt.FromBalance.Int64() != initAmount-100 || receipt.ToBalance.Sign() != 0 {
+		t.FailNow()
+	}
+	deadBalance, _ := repository.GetBalance(stub, "0xdead", true)
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if deadBalance.Sign() != 0 || erc20.GetTotalSupply().Int64() != initAmount-100 {
+		t.FailNow()
+	}
+
+	// emit burn event
+	data := <-stub.ChaincodeEventsChannel
+	event := model.BurnEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.BurnEventKey, tokenID) || event.Burner != address || event.Amount.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// owner cannot be the burn address
+	res = stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte(address)})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+
+	// the empty burn address credits transfers again
+	stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte("")})
+	stub.MockInvoke("txTransfer2", [][]byte{[]byte("transfer"), []byte(address), []byte("0xdead"), []byte("100")})
+	deadBalance, _ = repository.GetBalance(stub, "0xdead", true)
+	if deadBalance.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// an address holding a balance cannot become the burn address, nor can an invalid address
+	res = stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte("0xdead")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte("bad address")})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+}
+
+func Test_WhitelistMode_success(t *testing.T) {
+	stub := initERC20(t)
+	invoke := func(function string, args ...string) int32 {
+		arguments := [][]byte{[]byte(function)}
+		for _, arg := range args {
+			arguments = append(arguments, []byte(arg))
+		}
+		return stub.MockInvoke("tx"+function, arguments).Status
+	}
+
+	// disabled whitelist allows all
+	if invoke("transfer", address, "card1", "10") != shim.OK {
+		t.FailNow()
+	}
+
+	// whitelist mode is surfaced in metadata
+	if invoke("setWhitelistMode", tokenID, address, "true") != shim.OK || invoke("addAllowed", tokenID, address, "card2") != shim.OK {
+		t.FailNow()
+	}
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if !erc20.WhitelistMode {
+		t.FailNow()
+	}
+
+	// only allowed addresses & owner receive
+	if invoke("transfer", address, "card1", "10") != model.StatusForbidden || invoke("transfer", address, "card2", "10") != shim.OK {
+		t.FailNow()
+	}
+	if invoke("transfer", "card2", address, "5") != shim.OK {
+		t.FailNow()
+	}
+
+	// removed address cannot receive, removing twice conflicts
+	if invoke("removeAllowed", tokenID, address, "card2") != shim.OK || invoke("removeAllowed", tokenID, address, "card2") != model.StatusConflict {
+		t.FailNow()
+	}
+	if invoke("transfer", address, "card2", "10") != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// only owner manages the whitelist
+	if invoke("addAllowed", tokenID, "card1", "card1") != model.StatusForbidden {
+		t.FailNow()
+	}
+}
+
+func Test_WhitelistMode_everyCredit_failure(t *testing.T) {
+	stub := initERC20(t)
+	invoke := func(function string, args ...string) int32 {
+		arguments := [][]byte{[]byte(function)}
+		for _, arg := range args {
+			arguments = append(arguments, []byte(arg))
+		}
+		return stub.MockInvoke("tx"+function, arguments).Status
+	}
+	invoke("setWhitelistMode", tokenID, address, "true")
+	invoke("addAllowed", tokenID, address, "card2")
+
+	// batch, split, treasury, mint & escrow credits run the whitelist check of transfer
+	batch := `[{"recipient": "card1", "amount": "10"}, {"recipient": "card2", "amount": "10"}]`
+	if invoke("transferBatch", address, batch) != model.StatusForbidden ||
+		invoke("transferSplit", address, "card1", "10", "card2", "10") != model.StatusForbidden ||
+		invoke("treasuryDistribute", tokenID, address, batch) != model.StatusForbidden ||
+		invoke("mintBatch", tokenID, address, batch) != model.StatusForbidden ||
+		invoke("mint", tokenID, address, "card1", "10") != model.StatusForbidden {
+		t.FailNow()
+	}
+	deadline := strconv.FormatInt(time.Now().Unix()+3600, 10)
+	if invoke("conditionalTransfer", address, "card1", "10", deadline) != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// the recipient removed after the escrow cannot claim it
+	res := stub.MockInvoke("txEscrow", [][]byte{[]byte("conditionalTransfer"), []byte(address), []byte("card2"), []byte("10"), []byte(deadline)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	invoke("removeAllowed", tokenID, address, "card2")
+	if invoke("claim", string(res.GetPayload()), "card2") != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// nothing moved to card1 & the allowed batch is indexed like transfer
+	balance, _ := repository.GetBalance(stub, "card1", true)
+	if balance.Sign() != 0 {
+		t.FailNow()
+	}
+	invoke("addAllowed", tokenID, address, "card3")
+	if invoke("transferBatch", address, `[{"recipient": "card3", "amount": "10"}]`) != shim.OK {
+		t.FailNow()
+	}
+	res = newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte("card3"), []byte("10"), []byte("")})
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Logs) != 1 || page.Logs[0].TxID != "txtransferBatch" || page.Logs[0].Event.Sender != address {
+		t.FailNow()
+	}
+}
+
+func Test_BurnAddress_everyCredit_failure(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txSetBurnAddress", [][]byte{[]byte("setBurnAddress"), []byte(tokenID), []byte(address), []byte("dead")})
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+
+	// only transfer burns, the other credits cannot leave the amount in the burn address
+	for _, args := range [][]string{
+		{"transferBatch", address, `[{"recipient": "dead", "amount": "10"}]`},
+		{"transferFrom", address, "spender", "dead", "10"},
+		{"mint", tokenID, address, "dead", "10"},
+	} {
+		input := [][]byte{}
+		for _, arg := range args {
+			input = append(input, []byte(arg))
+		}
+		res := stub.MockInvoke("txCredit", input)
+		if res.Status != model.StatusBadRequest || !strings.Contains(res.Message, "burn address") {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_Init_upgrade_preservesBalances(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+
+	// upgrade with different tokenID is rejected
+	res := stub.MockInit("2", [][]byte{[]byte("init"), []byte("otherToken"), []byte(tokenName), []byte("dt"), []byte(address), []byte("1")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	res = stub.MockInit("2", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("1")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// balances & total supply are preserved
+	ownerBalance, _ := repository.GetBalance(stub, address, true)
+	recipientBalance, _ := repository.GetBalance(stub, "recipient", true)
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	if ownerBalance.Int64() != initAmount-100 || recipientBalance.Int64() != 100 || totalSupply.Int64() != initAmount {
+		t.FailNow()
+	}
+}
+
+func Test_CheckInvariant_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	stub.MockInvoke("txDummy", [][]byte{[]byte("putDummyData")})
+
+	res := newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("checkInvariant"), []byte(tokenID)})
+	report := model.InvariantReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if res.Status != shim.OK || !report.Match || report.Accounts != 2 || report.BalanceSum.Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// divergence is reported
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveBalance(stub, "recipient", big.NewInt(200))
+	stub.MockTransactionEnd("txCorrupt")
+	res = newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("checkInvariant"), []byte(tokenID)})
+	report = model.InvariantReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if report.Match || report.BalanceSum.Int64() != initAmount+100 {
+		t.FailNow()
+	}
+}
+
+func Test_ReconcileSupply_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+
+	// supply drifted from balances
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveBalance(stub, "recipient", big.NewInt(200))
+	stub.MockTransactionEnd("txCorrupt")
+
+	// only owner can reconcile
+	res := stub.MockInvoke("txReconcile", [][]byte{[]byte("reconcileSupply"), []byte(tokenID), []byte("recipient")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("txReconcile", [][]byte{[]byte("reconcileSupply"), []byte(tokenID), []byte(address)})
+	reconciled := model.SupplyReconciledEvent{}
+	json.Unmarshal(res.GetPayload(), &reconciled)
+	if res.Status != shim.OK || reconciled.OldSupply.Int64() != initAmount || reconciled.NewSupply.Int64() != initAmount+100 {
+		t.FailNow()
+	}
+
+	// invariant holds again
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("checkInvariant"), []byte(tokenID)})
+	report := model.InvariantReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if !report.Match || report.TotalSupply.Int64() != initAmount+100 {
+		t.FailNow()
+	}
+
+	// emit supply reconciled event for audit
+	var data *sc.ChaincodeEvent
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		data = <-stub.ChaincodeEventsChannel
+	}
+	if data.GetEventName() != repository.EventName(repository.SupplyReconciledEventKey, tokenID) {
+		t.FailNow()
+	}
+}
+
+func Test_AccountInfo_success(t *testing.T) {
+	stub := initERC20(t)
+
+	// unknown account has no info
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("accountInfo"), []byte("recipient")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+
+	// first transfer creates the marker, later transfers keep it
+	stub.MockInvoke("txFirst", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	stub.MockInvoke("txSecond", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("accountInfo"), []byte("recipient")})
+	accountInfo := model.AccountInfo{}
+	json.Unmarshal(res.GetPayload(), &accountInfo)
+	if res.Status != shim.OK || accountInfo.Address != "recipient" || accountInfo.FirstTxID != "txFirst" || accountInfo.CreatedAt == 0 {
+		t.FailNow()
+	}
+}
+
+func Test_AccountInfo_batch_success(t *testing.T) {
+	stub := initERC20(t)
+
+	// mintBatch & transferBatch create the markers of their recipients
+	stub.MockInvoke("txMintBatch", [][]byte{[]byte("mintBatch"), []byte(tokenID), []byte(address), []byte(`[{"recipient": "minted", "amount": "100"}]`)})
+	stub.MockInvoke("txTransferBatch", [][]byte{[]byte("transferBatch"), []byte(address), []byte(`[{"recipient": "transferred", "amount": "100"}]`)})
+	for account, txID := range map[string]string{"minted": "txMintBatch", "transferred": "txTransferBatch"} {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("accountInfo"), []byte(account)})
+		accountInfo := model.AccountInfo{}
+		json.Unmarshal(res.GetPayload(), &accountInfo)
+		if res.Status != shim.OK || accountInfo.Address != account || accountInfo.FirstTxID != txID {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_Permit_recurringAllowance_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKey := hex.EncodeToString(elliptic.Marshal(elliptic.P256(), privateKey.X, privateKey.Y))
+	owner := util.AddressFromPublicKey(&privateKey.PublicKey)
+
+	// 50 every 100 seconds from 1000
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: 1000}
+	res := stub.MockInvoke("txRecurring", [][]byte{[]byte("setRecurringAllowance"), []byte(owner), []byte("spender"), []byte("50"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// permit replaces the recurring allowance, the next period doesn't reset it
+	signature := signPermit(privateKey, owner, "spender", "5", "5000", 0)
+	res = stub.MockInvoke("txPermit", [][]byte{[]byte("permit"), []byte(owner), []byte("spender"), []byte("5"), []byte("5000"), []byte(publicKey), []byte(signature)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: 1100}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("allowance"), []byte(owner), []byte("spender")})
+	recurring, _ := repository.GetRecurringAllowance(stub, owner, "spender")
+	if string(res.GetPayload()) != "5" || recurring != nil {
+		t.FailNow()
+	}
+}
+
+// signPermit signs the permit message of owner's key like a wallet
+func signPermit(privateKey *ecdsa.PrivateKey, owner, spender, amount, deadline string, nonce int64) string {
+	digest := sha256.Sum256(util.PermitMessage(tokenID, owner, spender, amount, deadline, nonce))
+	r, s, _ := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+	signature, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	return hex.EncodeToString(signature)
+}
+
+func Test_Permit_success(t *testing.T) {
+	stub := initERC20(t)
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKey := hex.EncodeToString(elliptic.Marshal(elliptic.P256(), privateKey.X, privateKey.Y))
+	owner := util.AddressFromPublicKey(&privateKey.PublicKey)
+	deadline := strconv.FormatInt(time.Now().Unix()+3600, 10)
+
+	signature := signPermit(privateKey, owner, "spender", "100", deadline, 0)
+	arguments := [][]byte{[]byte("permit"), []byte(owner), []byte("spender"), []byte("100"), []byte(deadline), []byte(publicKey), []byte(signature)}
+	res := stub.MockInvoke("txPermit", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, owner, "spender", true)
+	if string(allowance) != "100" {
+		t.FailNow()
+	}
+
+	// the same permit cannot be replayed
+	res = stub.MockInvoke("txReplay", arguments)
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getNonce"), []byte(owner)})
+	if string(res.GetPayload()) != "1" {
+		t.FailNow()
+	}
+
+	// nonce is zero before the first signed operation
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getNonce"), []byte("spender")})
+	if string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+
+	// expired permit or the permit of other's key is rejected
+	expired := strconv.FormatInt(time.Now().Unix()-3600, 10)
+	signature = signPermit(privateKey, owner, "spender", "100", expired, 1)
+	res = stub.MockInvoke("txExpired", [][]byte{[]byte("permit"), []byte(owner), []byte("spender"), []byte("100"), []byte(expired), []byte(publicKey), []byte(signature)})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+	signature = signPermit(privateKey, address, "spender", "100", deadline, 0)
+	res = stub.MockInvoke("txOther", [][]byte{[]byte("permit"), []byte(address), []byte("spender"), []byte("100"), []byte(deadline), []byte(publicKey), []byte(signature)})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_HasActivity_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+
+	for account, expected := range map[string]string{address: "true", "recipient": "true", "newcomer": "false"} {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("hasActivity"), []byte(account)})
+		if res.Status != shim.OK || string(res.GetPayload()) != expected {
+			t.FailNow()
+		}
+	}
+
+	// the error of history query is returned
+	res := stub.MockStub.MockInvoke("txQuery", [][]byte{[]byte("hasActivity"), []byte(address)})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_TransferFrom_singleEvent_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+	<-stub.ChaincodeEventsChannel
+
+	// the whole allowance can be used
+	res := stub.MockInvoke("txTransferFrom", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, address, "spender", true)
+	if string(allowance) != "0" {
+		t.FailNow()
+	}
+
+	// only one event is emitted, Fabric keeps only the last event of a transaction
+	if len(stub.ChaincodeEventsChannel) != 1 {
+		t.FailNow()
+	}
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EventName(repository.TransferFromEventKey, tokenID) {
+		t.FailNow()
+	}
+
+	// the event has the transfer & the remaining allowance
+	event := model.TransferFromEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if event.Owner != address || event.Recipient != "recipient" || event.Spender != "spender" || event.Amount.Int64() != 100 || event.RemainingAllowance.Sign() != 0 {
+		t.FailNow()
+	}
+
+	// allowance cannot be exceeded
+	res = stub.MockInvoke("txTransferFrom", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte("1")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_TopHolders_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txTransfer1", [][]byte{[]byte("transfer"), []byte(address), []byte("a"), []byte("300")})
+	stub.MockInvoke("txTransfer2", [][]byte{[]byte("transfer"), []byte(address), []byte("b"), []byte("500")})
+	stub.MockInvoke("txTransfer3", [][]byte{[]byte("transfer"), []byte(address), []byte("c"), []byte("300")})
+	stub.MockInvoke("txDummy", [][]byte{[]byte("putDummyData")})
+
+	res := newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("topHolders"), []byte("3")})
+	holders := []model.AccountBalance{}
+	json.Unmarshal(res.GetPayload(), &holders)
+	if res.Status != shim.OK || len(holders) != 3 {
+		t.FailNow()
+	}
+	if holders[0].Address != address || holders[1].Address != "b" || holders[2].Address != "a" || holders[2].Balance.Int64() != 300 {
+		t.FailNow()
+	}
+
+	// n must be positive
+	res = newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("topHolders"), []byte("0")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_QueryBalances_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.MockInvoke("txTransfer1", [][]byte{[]byte("transfer"), []byte(address), []byte("a"), []byte("300")})
+	stub.MockInvoke("txTransfer2", [][]byte{[]byte("transfer"), []byte(address), []byte("b"), []byte("5")})
+	stub.MockInvoke("txTransfer3", [][]byte{[]byte("transfer"), []byte(address), []byte("c"), []byte("700")})
+
+	// walk [a, d) with minBalance 100 one key per page, dappcampus is out of range
+	addresses, bookmark, pages := []string{}, "", 0
+	for {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("queryBalances"), []byte("a"), []byte("d"), []byte("1"), []byte(bookmark), []byte("100")})
+		page := model.BalancePage{}
+		json.Unmarshal(res.GetPayload(), &page)
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+		for _, balance := range page.Balances {
+			addresses = append(addresses, balance.Address)
+		}
+		pages++
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+	if len(addresses) != 2 || addresses[0] != "a" || addresses[1] != "c" || pages != 4 {
+		t.FailNow()
+	}
+
+	// the open range without minBalance returns every balance
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("queryBalances"), []byte(""), []byte(""), []byte("10"), []byte("")})
+	page := model.BalancePage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || len(page.Balances) != 4 || page.Bookmark != "" {
+		t.FailNow()
+	}
+
+	// minBalance must be a non-negative integer
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("queryBalances"), []byte("a"), []byte("d"), []byte("1"), []byte(""), []byte("-1")})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+}
+
+func Test_QueryBalances_partial_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.MockInvoke("txTransfer1", [][]byte{[]byte("transfer"), []byte(address), []byte("a"), []byte("300")})
+	stub.MockInvoke("txTransfer2", [][]byte{[]byte("transfer"), []byte(address), []byte("b"), []byte("5")})
+
+	// the iterator fails mid-scan, the gathered balances are returned
+	stub.failIteratorAfter = 1
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("queryBalances"), []byte("a"), []byte("d"), []byte("10"), []byte("")})
+	page := model.BalancePage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || len(page.Balances) != 1 || page.Balances[0].Address != "a" || !page.Incomplete || len(page.Error) == 0 {
+		t.FailNow()
+	}
+
+	// the bookmark resumes after the last balance read
+	stub.failIteratorAfter = 0
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("queryBalances"), []byte("a"), []byte("d"), []byte("10"), []byte(page.Bookmark)})
+	page = model.BalancePage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if len(page.Balances) != 1 || page.Balances[0].Address != "b" || page.Incomplete {
+		t.FailNow()
+	}
+}
+
+func Test_IsNameAvailable_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	for name, expected := range map[string]string{tokenID: "false", tokenName: "false", "dt": "false", "otherToken": "true"} {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("isNameAvailable"), []byte(name)})
+		if res.Status != shim.OK || string(res.GetPayload()) != expected {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_ConditionalTransfer_claim_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	now := time.Now().Unix()
+	deadline := strconv.FormatInt(now+3600, 10)
+
+	res := stub.MockInvoke("txLock", [][]byte{[]byte("conditionalTransfer"), []byte(address), []byte("recipient"), []byte("100"), []byte(deadline)})
+	if res.Status != shim.OK || string(res.GetPayload()) != "txLock" {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Int64() != initAmount-100 {
+		t.FailNow()
+	}
+
+	// escrowed amount is counted in invariant
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("checkInvariant"), []byte(tokenID)})
+	report := model.InvariantReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if !report.Match || report.Escrowed.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// sender cannot refund until deadline, others cannot claim
+	res = stub.MockInvoke("txRefund", [][]byte{[]byte("refund"), []byte("txLock"), []byte(address)})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txClaim", [][]byte{[]byte("claim"), []byte("txLock"), []byte("other")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+
+	// recipient claims once
+	res = stub.MockInvoke("txClaim", [][]byte{[]byte("claim"), []byte("txLock"), []byte("recipient")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txClaim", [][]byte{[]byte("claim"), []byte("txLock"), []byte("recipient")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+	balance, _ = repository.GetBalance(stub, "recipient", true)
+	if balance.Int64() != 100 {
+		t.FailNow()
+	}
+}
+
+func Test_ConditionalTransfer_refund_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	now := time.Now().Unix()
+	deadline := strconv.FormatInt(now+3600, 10)
+	stub.MockInvoke("txLock", [][]byte{[]byte("conditionalTransfer"), []byte(address), []byte("recipient"), []byte("100"), []byte(deadline)})
+
+	// after deadline recipient cannot claim, sender refunds
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: now + 3601}
+	res := stub.MockInvoke("txClaim", [][]byte{[]byte("claim"), []byte("txLock"), []byte("recipient")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txRefund", [][]byte{[]byte("refund"), []byte("txLock"), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Int64() != initAmount {
+		t.FailNow()
+	}
+}
+
+func Test_Mint_minter_success(t *testing.T) {
+	stub := initERC20(t)
+	mintArguments := [][]byte{function, []byte(tokenID), []byte("minter"), []byte("recipient"), []byte("100")}
+
+	// only owner or minters can mint
+	res := stub.MockInvoke(txMint, mintArguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txMintBatch", [][]byte{[]byte("mintBatch"), []byte(tokenID), []byte("minter"), []byte(`[{"recipient": "a", "amount": "100"}]`)})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	// owner adds minter once
+	res = stub.MockInvoke("txAddMinter", [][]byte{[]byte("addMinter"), []byte(tokenID), []byte(address), []byte("minter")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txAddMinter", [][]byte{[]byte("addMinter"), []byte(tokenID), []byte(address), []byte("minter")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if len(erc20.GetMinters()) != 1 || erc20.GetMinters()[0] != "minter" {
+		t.FailNow()
+	}
+
+	// minter can mint
+	res = stub.MockInvoke(txMint, mintArguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "recipient", true)
+	if balance.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// removed minter cannot mint
+	res = stub.MockInvoke("txRemoveMinter", [][]byte{[]byte("removeMinter"), []byte(tokenID), []byte(address), []byte("minter")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke(txMint, mintArguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Mutators_zeroAndNegativeAmount_failure(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	deadline := strconv.FormatInt(time.Now().Unix()+3600, 10)
+	mutators := map[string]func(amount string) [][]byte{
+		"transfer": func(amount string) [][]byte {
+			return [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte(amount)}
+		},
+		"transferFrom": func(amount string) [][]byte {
+			return [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte(amount)}
+		},
+		"increaseAllowance": func(amount string) [][]byte {
+			return [][]byte{[]byte("increaseAllowance"), []byte(address), []byte("spender"), []byte(amount)}
+		},
+		"decreaseAllowance": func(amount string) [][]byte {
+			return [][]byte{[]byte("decreaseAllowance"), []byte(address), []byte("spender"), []byte(amount)}
+		},
+		"mint": func(amount string) [][]byte {
+			return [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte(address), []byte(amount)}
+		},
+		"burn": func(amount string) [][]byte {
+			return [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte(amount)}
+		},
+		"clawback": func(amount string) [][]byte {
+			return [][]byte{[]byte("clawback"), []byte(tokenID), []byte(address), []byte(address), []byte("recipient"), []byte(amount)}
+		},
+		"transferBatch": func(amount string) [][]byte {
+			return [][]byte{[]byte("transferBatch"), []byte(address), []byte(`[{"recipient": "a", "amount": "` + amount + `"}]`)}
+		},
+		"mintBatch": func(amount string) [][]byte {
+			return [][]byte{[]byte("mintBatch"), []byte(tokenID), []byte(address), []byte(`[{"recipient": "a", "amount": "` + amount + `"}]`)}
+		},
+		"conditionalTransfer": func(amount string) [][]byte {
+			return [][]byte{[]byte("conditionalTransfer"), []byte(address), []byte("recipient"), []byte(amount), []byte(deadline)}
+		},
+	}
+
+	for fcn, arguments := range mutators {
+		for _, amount := range []string{"0", "-1"} {
+			res := stub.MockInvoke("tx"+fcn, arguments(amount))
+			if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "must be positive") && !strings.Contains(res.Message, "cannot be negative") {
+				t.Fatalf("%s accepted amount %s", fcn, amount)
+			}
+		}
+	}
+}
+
+func Test_Approve_zeroAmount_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+
+	// zero revokes allowance, negative is rejected
+	res := stub.MockInvoke("txRevoke", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("0")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, address, "spender", true)
+	if string(allowance) != "0" {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txNegative", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("-1")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_AllowanceHistory_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	now := time.Now().Unix()
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: now}
+	stub.MockInvoke("txApprove1", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: now + 1}
+	stub.MockInvoke("txApprove2", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("50")})
+
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("allowanceHistory"), []byte(address), []byte("spender")})
+	changes := []model.AllowanceChange{}
+	json.Unmarshal(res.GetPayload(), &changes)
+	if res.Status != shim.OK || len(changes) != 2 {
+		t.FailNow()
+	}
+	if changes[0].TxID != "txApprove1" || changes[0].Allowance != "100" || changes[1].Allowance != "50" || changes[1].Timestamp != now+1 {
+		t.FailNow()
+	}
+}
+
+func Test_BalanceOfAtTime_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	now := time.Now().Unix()
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: now}
+	stub.MockInvoke("txTransfer1", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: now + 10}
+	stub.MockInvoke("txTransfer2", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("50")})
+
+	for at, balance := range map[int64]string{now - 1: "0", now: "100", now + 9: "100", now + 10: "150", now + 100: "150"} {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOfAtTime"), []byte("recipient"), []byte(strconv.FormatInt(at, 10))})
+		if res.Status != shim.OK || string(res.GetPayload()) != balance {
+			t.Fatalf("at %d: %s %s", at, res.GetPayload(), res.Message)
+		}
+	}
+
+	// an address which never existed is 0, the timestamp must be a non-negative integer
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOfAtTime"), []byte("nobody"), []byte(strconv.FormatInt(now, 10))})
+	if res.Status != shim.OK || string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+	for _, at := range []string{"-1", "abc", ""} {
+		res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOfAtTime"), []byte("recipient"), []byte(at)})
+		if res.Status != model.StatusBadRequest {
+			t.Fatalf("timestamp %q: %d", at, res.Status)
+		}
+	}
+}
+
+func Test_TransferSplit_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txSplit", [][]byte{[]byte("transferSplit"), []byte(address), []byte("primary"), []byte("90"), []byte("charity"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	callerBalance, _ := repository.GetBalance(stub, address, true)
+	primaryBalance, _ := repository.GetBalance(stub, "primary", true)
+	charityBalance, _ := repository.GetBalance(stub, "charity", true)
+	if callerBalance.Int64() != initAmount-100 || primaryBalance.Int64() != 90 || charityBalance.Int64() != 10 {
+		t.FailNow()
+	}
+
+	// both movements are in one event
+	data := <-stub.ChaincodeEventsChannel
+	event := model.BatchTransferEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if len(stub.ChaincodeEventsChannel) != 0 || len(event.Transfers) != 2 {
+		t.FailNow()
+	}
+
+	// insufficient balance fails entirely, empty recipient is rejected
+	res = stub.MockInvoke("txSplit", [][]byte{[]byte("transferSplit"), []byte(address), []byte("primary"), []byte(strconv.Itoa(initAmount)), []byte("charity"), []byte("10")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txSplit", [][]byte{[]byte("transferSplit"), []byte(address), []byte("primary"), []byte("1"), []byte(""), []byte("1")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_entireBalance_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte(strconv.Itoa(initAmount))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// sender reads back "0", recipient has the entire balance
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte("recipient")})
+	if string(res.GetPayload()) != strconv.Itoa(initAmount) {
+		t.FailNow()
+	}
+
+	// totalSupply is unchanged
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	if totalSupply.Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// nothing is left to transfer
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Allowance_beyondInt64_success(t *testing.T) {
+	// supply above int64, allowances are big integers like balances
+	supply := "100000000000000000000000"
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(supply)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the full balance can be approved in strict mode & spent
+	stub.MockInvoke("txStrict", [][]byte{[]byte("setStrictApprovals"), []byte(tokenID), []byte(address), []byte("true")})
+	res = stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte(supply)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txTransferFrom", [][]byte{[]byte("transferFrom"), []byte(address), []byte("spender"), []byte("recipient"), []byte(supply)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("allowance"), []byte(address), []byte("spender")})
+	if string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+
+	// increasing past MaxInt64 doesn't wrap
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte("recipient"), []byte("spender"), []byte("9223372036854775807")})
+	res = stub.MockInvoke("txIncrease", [][]byte{[]byte("increaseAllowance"), []byte("recipient"), []byte("spender"), []byte("1")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("totalAllowanceGranted"), []byte("recipient")})
+	if string(res.GetPayload()) != "9223372036854775808" {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_emptyParams_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// every permutation of empty params reports the first missing one
+	cases := []struct {
+		caller, recipient, amount, message string
+	}{
+		{"", "recipient", "10", "caller address is required"},
+		{address, " ", "10", "recipient address is required"},
+		{address, "recipient", "", "transfer amount is required"},
+		{"", "", "10", "caller address is required"},
+		{"", "recipient", "\t", "caller address is required"},
+		{address, "", " ", "recipient address is required"},
+		{" ", "", "", "caller address is required"},
+	}
+	for _, c := range cases {
+		res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(c.caller), []byte(c.recipient), []byte(c.amount)})
+		if res.Status != model.StatusBadRequest || res.Message != c.message {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_Transfer_zeroSupply_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txBurn", [][]byte{[]byte("burn"), []byte(tokenID), []byte(address), []byte(strconv.Itoa(initAmount))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// a corrupt balance left without supply cannot move
+	stub.MockTransactionStart("txCorrupt")
+	repository.SaveBalance(stub, address, big.NewInt(10))
+	stub.MockTransactionEnd("txCorrupt")
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+	if res.Status != model.StatusConflict || !strings.Contains(res.Message, "totalSupply") {
+		t.FailNow()
+	}
+
+	// reads still work
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("totalSupply"), []byte(tokenID)})
+	if res.Status != shim.OK || string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_receipt_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// receipt has the transaction & result balances
+	receipt := model.TransferReceipt{}
+	err := json.Unmarshal(res.GetPayload(), &receipt)
+	if err != nil || receipt.TxID != "txTransfer" || receipt.From != address || receipt.To != "recipient" || receipt.Timestamp == 0 {
+		t.FailNow()
+	}
+	if receipt.Amount.Int64() != 10 || receipt.FromBalance.Int64() != initAmount-10 || receipt.ToBalance.Int64() != 10 || !strings.Contains(receipt.Note, "MVCC") {
+		t.FailNow()
+	}
+}
+
+func Test_EventByTxId_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+
+	// both the sender & recipient find the event
+	for _, account := range []string{address, "recipient"} {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte(account), []byte("txTransfer")})
+		transferLogs := []model.TransferLog{}
+		json.Unmarshal(res.GetPayload(), &transferLogs)
+		if res.Status != shim.OK || len(transferLogs) != 1 || transferLogs[0].TxID != "txTransfer" || transferLogs[0].Event.Sender != address || transferLogs[0].Event.Amount.Int64() != 10 {
+			t.FailNow()
+		}
+	}
+
+	// other addresses & transactions are not found
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte("stranger"), []byte("txTransfer")})
+	if res.Status != model.StatusNotFound {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte(address), []byte("txUnknown")})
+	if res.Status != model.StatusNotFound {
+		t.FailNow()
+	}
+}
+
+func Test_TransferBatch_txlog_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	batch := `[{"recipient":"card1","amount":"10"},{"recipient":"card2","amount":"20"},{"recipient":"card3","amount":"30"}]`
+	if res := stub.MockInvoke("txBatch", [][]byte{[]byte("transferBatch"), []byte(address), []byte(batch)}); res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the sender's txlog keeps every movement of the batch
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte(address), []byte("10"), []byte("")})
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || len(page.Logs) != 3 {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte(address), []byte("txBatch")})
+	transferLogs := []model.TransferLog{}
+	json.Unmarshal(res.GetPayload(), &transferLogs)
+	if res.Status != shim.OK || len(transferLogs) != 3 {
+		t.FailNow()
+	}
+	for i, recipient := range []string{"card1", "card2", "card3"} {
+		if transferLogs[i].Index != i || transferLogs[i].Event.Recipient != recipient || transferLogs[i].Event.Amount.Int64() != int64(10*(i+1)) {
+			t.FailNow()
+		}
+	}
+
+	// each recipient finds its movement
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte("card3"), []byte("txBatch")})
+	transferLogs = []model.TransferLog{}
+	json.Unmarshal(res.GetPayload(), &transferLogs)
+	if res.Status != shim.OK || len(transferLogs) != 1 || transferLogs[0].Event.Amount.Int64() != 30 {
+		t.FailNow()
+	}
+}
+
+func Test_MintBatch_txlog_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	batch := `[{"recipient":"card1","amount":"10"},{"recipient":"card2","amount":"20"}]`
+	if res := stub.MockInvoke("txMintBatch", [][]byte{[]byte("mintBatch"), []byte(tokenID), []byte(address), []byte(batch)}); res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the admin's mint log keeps the mint of each recipient
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte("admin"), []byte("txMintBatch")})
+	transferLogs := []model.TransferLog{}
+	json.Unmarshal(res.GetPayload(), &transferLogs)
+	if res.Status != shim.OK || len(transferLogs) != 2 || transferLogs[0].Event.Recipient != "card1" || transferLogs[1].Event.Recipient != "card2" {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte("admin"), []byte("10"), []byte("")})
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || len(page.Logs) != 2 {
+		t.FailNow()
+	}
+}
+
+func Test_TreasuryDistribute_txlog_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	batch := `[{"recipient":"card1","amount":"10"},{"recipient":"card2","amount":"20"},{"recipient":"card3","amount":"30"}]`
+	if res := stub.MockInvoke("txDistribute", [][]byte{[]byte("treasuryDistribute"), []byte(tokenID), []byte(address), []byte(batch)}); res.Status != shim.OK {
+		t.FailNow()
+	}
+	if res := stub.MockInvoke("txSplit", [][]byte{[]byte("transferSplit"), []byte(address), []byte("card4"), []byte("1"), []byte("card5"), []byte("2")}); res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the treasury's history keeps every payout & both movements of the split
+	countLogs := func(txID string) int {
+		res := stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte(address), []byte(txID)})
+		transferLogs := []model.TransferLog{}
+		json.Unmarshal(res.GetPayload(), &transferLogs)
+		return len(transferLogs)
+	}
+	if countLogs("txDistribute") != 3 || countLogs("txSplit") != 2 {
+		t.FailNow()
+	}
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte(address), []byte("10"), []byte("")})
+	page := model.TransferLogPage{}
+	json.Unmarshal(res.GetPayload(), &page)
+	if res.Status != shim.OK || len(page.Logs) != 5 {
+		t.FailNow()
+	}
+}
+
+func Test_FormatProto_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("10")})
+
+	// balances, metadata & transfer logs decode as their pb messages
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address), []byte("format=proto")})
+	balance := &pb.Balance{}
+	if res.Status != shim.OK || proto.Unmarshal(res.GetPayload(), balance) != nil || balance.Balance != strconv.Itoa(initAmount-10) {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID), []byte("format=proto")})
+	metadata := &pb.TokenMetadata{}
+	if res.Status != shim.OK || proto.Unmarshal(res.GetPayload(), metadata) != nil || metadata.Id != tokenID || metadata.TotalSupply != strconv.Itoa(initAmount) || !metadata.EmitEvents {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("eventByTxId"), []byte("recipient"), []byte("txTransfer"), []byte("format=proto")})
+	transferLogs := &pb.TransferLogs{}
+	if res.Status != shim.OK || proto.Unmarshal(res.GetPayload(), transferLogs) != nil || len(transferLogs.Logs) != 1 || transferLogs.Logs[0].Event.Amount != "10" {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("recentTransfers"), []byte(address), []byte("10"), []byte(""), []byte("format=proto")})
+	page := &pb.TransferLogPage{}
+	if res.Status != shim.OK || proto.Unmarshal(res.GetPayload(), page) != nil || len(page.Logs) != 1 || page.Logs[0].TxId != "txTransfer" {
+		t.FailNow()
+	}
+
+	// JSON is the default & can be selected explicitly, other formats are rejected
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID), []byte("format=json")})
+	erc20 := model.ERC20Metadata{}
+	if res.Status != shim.OK || json.Unmarshal(res.GetPayload(), &erc20) != nil || erc20.ID != tokenID {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address), []byte("format=json")})
+	if res.Status != shim.OK || string(res.GetPayload()) != strconv.Itoa(initAmount-10) {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID), []byte("format=xml")})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address), []byte("format=xml")})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+}
+
+func Test_TokenAge_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	stub.txTimestamp = &timestamp.Timestamp{Seconds: erc20.CreatedAt + 3600}
+
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("tokenAge"), []byte(tokenID)})
+	tokenAge := model.TokenAge{}
+	json.Unmarshal(res.GetPayload(), &tokenAge)
+	if res.Status != shim.OK || tokenAge.CreatedTx != "1" || *tokenAge.CreatedAt != erc20.CreatedAt || *tokenAge.AgeSeconds != 3600 {
+		t.FailNow()
+	}
+
+	// tokens created before the creation was recorded are unknown
+	erc20.CreatedAt, erc20.CreatedTx = 0, ""
+	stub.MockTransactionStart("txLegacy")
+	repository.SaveERC20Metadata(stub, tokenID, erc20)
+	stub.MockTransactionEnd("txLegacy")
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("tokenAge"), []byte(tokenID)})
+	if res.Status != shim.OK || string(res.GetPayload()) != `{"createdTx":"unknown"}` {
+		t.FailNow()
+	}
+}
+
+func Test_Functions_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("functions")})
+	functions := []model.FunctionInfo{}
+	json.Unmarshal(res.GetPayload(), &functions)
+	if res.Status != shim.OK || len(functions) != len(NewChaincode().supportedFunctions()) {
+		t.FailNow()
+	}
+
+	// every function is described
+	for _, function := range functions {
+		if len(function.Description) == 0 || len(function.Params) == 0 {
+			t.Fatalf("%s is not described", function.Name)
+		}
+	}
+}
+
+func Test_AtomicSwap_success(t *testing.T) {
+	stub := initERC20(t)
+	stubB := shim.NewMockStub("tokenB", NewChaincode())
+	stubB.MockInit("1", [][]byte{[]byte("init"), []byte("tokenB"), []byte("token B"), []byte("tb"), []byte("party2"), []byte("1000")})
+	stub.MockPeerChaincode("tokenB", stubB)
+
+	// party2 didn't consent on tokenB
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("party2"), []byte("100")})
+	arguments := [][]byte{[]byte("atomicSwap"), []byte(address), []byte("party2"), []byte("100"), []byte("tokenB"), []byte("tokenB"), []byte("50")}
+	res := stub.MockInvoke("txSwap", arguments)
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	// both parties consent
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("party2"), []byte("100")})
+	stubB.MockInvoke("txApproveB", [][]byte{[]byte("approve"), []byte("party2"), []byte(address), []byte("50")})
+	// MockStub doesn't roll back the failed swap, so compare with the balances before the swap
+	balance, _ := repository.GetBalance(stub, address, true)
+	balance2, _ := repository.GetBalance(stub, "party2", true)
+	res = stub.MockInvoke("txSwap", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balanceA, _ := repository.GetBalance(stub, address, true)
+	balanceA2, _ := repository.GetBalance(stub, "party2", true)
+	balanceB, _ := repository.GetBalance(stubB, address, true)
+	balanceB2, _ := repository.GetBalance(stubB, "party2", true)
+	if balanceA.Int64() != balance.Int64()-100 || balanceA2.Int64() != balance2.Int64()+100 || balanceB.Int64() != 50 || balanceB2.Int64() != 950 {
+		t.FailNow()
+	}
+
+	// unknown tokenB is rejected
+	res = stub.MockInvoke("txSwap", [][]byte{[]byte("atomicSwap"), []byte(address), []byte("party2"), []byte("1"), []byte("tokenB"), []byte("unknown"), []byte("1")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_MigrateBalances_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("legacy1"), []byte("20")})
+
+	// balances of the key scheme before composite keys
+	stub.MockTransactionStart("txLegacy")
+	stub.PutState("legacy1", []byte("50"))
+	stub.PutState("legacy2", []byte("30"))
+	stub.PutState("notBalance", []byte("{}"))
+	stub.PutState("cursor/next", []byte("7"))
+	stub.PutState("stateTest1", []byte("5"))
+	stub.PutState("padded", []byte("007"))
+	stub.MockTransactionEnd("txLegacy")
+
+	// only owner can migrate
+	pStub := newTestStub(stub)
+	res := pStub.MockInvoke("txMigrate", [][]byte{[]byte("migrateBalances"), []byte(tokenID), []byte("legacy1"), []byte("1"), []byte("")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+
+	// migrate page by page
+	bookmark := ""
+	migrated := 0
+	for i := 0; i < 10; i++ {
+		res = pStub.MockInvoke("txMigrate", [][]byte{[]byte("migrateBalances"), []byte(tokenID), []byte(address), []byte("1"), []byte(bookmark)})
+		migration := model.BalanceMigration{}
+		json.Unmarshal(res.GetPayload(), &migration)
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+		migrated += migration.Migrated
+		bookmark = migration.Bookmark
+		if migration.Completed {
+			break
+		}
+	}
+	if migrated != 2 || bookmark != "" {
+		t.FailNow()
+	}
+
+	// legacy balances are added to the composite keys & deleted
+	balance1, _ := repository.GetBalance(stub, "legacy1", true)
+	balance2, _ := repository.GetBalance(stub, "legacy2", true)
+	if balance1.Int64() != 70 || balance2.Int64() != 30 || stub.State["legacy1"] != nil || stub.State["notBalance"] == nil {
+		t.FailNow()
+	}
+
+	// numeric keys of other shapes are not balances
+	for _, key := range []string{"cursor/next", "stateTest1", "padded"} {
+		balance, _ := repository.GetBalance(stub, key, true)
+		if balance.Sign() != 0 || stub.State[key] == nil {
+			t.Fatalf("%s is migrated", key)
+		}
+	}
+
+	// re-running migrates nothing
+	res = pStub.MockInvoke("txMigrate", [][]byte{[]byte("migrateBalances"), []byte(tokenID), []byte(address), []byte("1"), []byte("")})
+	migration := model.BalanceMigration{}
+	json.Unmarshal(res.GetPayload(), &migration)
+	if res.Status != shim.OK || migration.Migrated != 0 || !migration.Completed {
+		t.FailNow()
+	}
+	balance1, _ = repository.GetBalance(stub, "legacy1", true)
+	if balance1.Int64() != 70 {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_metadataKeyAsAddress_success(t *testing.T) {
+	stub := initERC20(t)
+
+	// balances & metadata are namespaced by composite keys, so the tokenID as address is just an address
+	res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(tokenID), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte(tokenName), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// metadata is not corrupted
+	erc20, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil || *erc20.GetName() != tokenName || erc20.GetTotalSupply().Int64() != initAmount {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, tokenID, true)
+	if balance.Int64() != 10 {
+		t.FailNow()
+	}
+}
+
+func Test_SpendableBalanceOf_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("spendableBalanceOf"), []byte(address)})
+	if string(res.GetPayload()) != strconv.Itoa(initAmount) {
+		t.FailNow()
+	}
+
+	// escrowed amount is not spendable
+	stub.MockInvoke("txEscrow", [][]byte{[]byte("conditionalTransfer"), []byte(address), []byte("recipient"), []byte("100"), []byte("9999999999")})
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("spendableBalanceOf"), []byte(address)})
+	if string(res.GetPayload()) != strconv.Itoa(initAmount-100) {
+		t.FailNow()
+	}
+
+	// capped by the amount left in the rate limit window
+	stub.MockInvoke("txSetRateLimit", [][]byte{[]byte("setRateLimit"), []byte(tokenID), []byte(address), []byte("60"), []byte("0"), []byte("50")})
+	tStub := newTestStub(stub)
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: 6000}
+	tStub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("20")})
+	res = tStub.MockInvoke("txQuery", [][]byte{[]byte("spendableBalanceOf"), []byte(address)})
+	if string(res.GetPayload()) != "30" {
+		t.FailNow()
+	}
+}
+
+func Test_Init_invalidTokenName_failure(t *testing.T) {
+	cases := map[string][][]byte{
+		"'\\x00'":        {[]byte("init"), []byte(tokenID), []byte("dapp\x00token"), []byte("dt"), []byte(address), []byte("100")},
+		"longer than 64": {[]byte("init"), []byte(tokenID), []byte(strings.Repeat("a", 65)), []byte("dt"), []byte(address), []byte("100")},
+		"'\\x00' at 1":   {[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("d\x00"), []byte(address), []byte("100")},
+		"longer than 16": {[]byte("init"), []byte(tokenID), []byte(tokenName), []byte(strings.Repeat("d", 17)), []byte(address), []byte("100")},
+	}
+	for message, arguments := range cases {
+		stub := shim.NewMockStub("erc20", NewChaincode())
+		res := stub.MockInit("1", arguments)
+		if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, message) {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_BalanceAndAllowance_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("balanceAndAllowance"), []byte(address), []byte("spender")})
+	result := model.BalanceAndAllowance{}
+	json.Unmarshal(res.GetPayload(), &result)
+	if res.Status != shim.OK || result.Balance.Int64() != initAmount || result.Allowance.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// missing values are 0
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceAndAllowance"), []byte("nobody"), []byte("spender")})
+	result = model.BalanceAndAllowance{}
+	json.Unmarshal(res.GetPayload(), &result)
+	if res.Status != shim.OK || result.Balance.Int64() != 0 || result.Allowance.Sign() != 0 {
+		t.FailNow()
+	}
+
+	// owner & spender cannot be empty
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceAndAllowance"), []byte(address), []byte("")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_Approve_self_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte(address), []byte("100")})
+	if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "cannot approve itself") {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txIncrease", [][]byte{[]byte("increaseAllowance"), []byte(address), []byte(address), []byte("100")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, address, address, true)
+	if string(allowance) != "0" {
+		t.FailNow()
+	}
+}
+
+func Test_SetTransferCooldown_active_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txCooldown", [][]byte{[]byte("setTransferCooldown"), []byte(tokenID), []byte(address), []byte("60")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the second transfer in the cooldown is rejected
+	tStub := newTestStub(stub)
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: 6000}
+	arguments := [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")}
+	if res := tStub.MockInvoke("txTransfer", arguments); res.Status != shim.OK {
+		t.FailNow()
+	}
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: 6059}
+	res = tStub.MockInvoke("txTransfer", arguments)
+	if res.Status < shim.ERRORTHRESHOLD || !strings.Contains(res.Message, "cooldown active, retry after 6060") {
+		t.FailNow()
+	}
+	res = tStub.MockInvoke("txQuery", [][]byte{[]byte("spendableBalanceOf"), []byte(address)})
+	if string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+
+	// the recipient is not limited & the sender can send after the cooldown
+	if res := tStub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte("recipient"), []byte(address), []byte("1")}); res.Status != shim.OK {
+		t.FailNow()
+	}
+	tStub.txTimestamp = &timestamp.Timestamp{Seconds: 6060}
+	if res := tStub.MockInvoke("txTransfer", arguments); res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the setting is surfaced in metadata
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if erc20.TransferCooldownSeconds != 60 {
+		t.FailNow()
+	}
+}
+
+func Test_RevokeAllAllowances_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove1", [][]byte{[]byte("approve"), []byte(address), []byte("spender1"), []byte("100")})
+	stub.MockInvoke("txApprove2", [][]byte{[]byte("approve"), []byte(address), []byte("spender2"), []byte("200")})
+	stub.MockInvoke("txApprove3", [][]byte{[]byte("approve"), []byte(address), []byte("spender3"), []byte("0")})
+	stub.MockInvoke("txApprove4", [][]byte{[]byte("approve"), []byte("other"), []byte("spender1"), []byte("100")})
+	for len(stub.ChaincodeEventsChannel) > 0 {
+		<-stub.ChaincodeEventsChannel
+	}
+
+	// only non-zero allowances of owner are revoked
+	res := stub.MockInvoke("txRevoke", [][]byte{[]byte("revokeAllAllowances"), []byte(address)})
+	if res.Status != shim.OK || string(res.GetPayload()) != "2" {
+		t.FailNow()
+	}
+	for _, spender := range []string{"spender1", "spender2"} {
+		allowance, _ := repository.GetAllowanceBytes(stub, address, spender, true)
+		if string(allowance) != "0" {
+			t.FailNow()
+		}
+	}
+	allowance, _ := repository.GetAllowanceBytes(stub, "other", "spender1", true)
+	if string(allowance) != "100" {
+		t.FailNow()
+	}
+
+	// one event lists the revoked spenders
+	if len(stub.ChaincodeEventsChannel) != 1 {
+		t.FailNow()
+	}
+	data := <-stub.ChaincodeEventsChannel
+	event := model.AllowancesRevokedEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.AllowancesRevokedEventKey, tokenID) || len(event.Spenders) != 2 {
+		t.FailNow()
+	}
+}
+
+func Test_BalanceOf_maxUint64_success(t *testing.T) {
+	const maxUint64 = "18446744073709551615"
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte(maxUint64)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// stored & returned in the same format
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if string(res.GetPayload()) != maxUint64 {
+		t.FailNow()
+	}
+	balanceKey, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{address})
+	if string(stub.State[balanceKey]) != maxUint64 {
+		t.FailNow()
+	}
+
+	// the format is kept after a transfer
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if string(res.GetPayload()) != "18446744073709551614" {
+		t.FailNow()
+	}
+}
+
+func Test_ImplementationStatus_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("implementationStatus")})
+	statuses := map[string]model.ImplementationStatus{}
+	json.Unmarshal(res.GetPayload(), &statuses)
+	if res.Status != shim.OK || len(statuses) != 5 {
+		t.FailNow()
+	}
+
+	// every registered function is dispatched
+	functions := NewChaincode().functions
+	for name, status := range statuses {
+		if _, ok := functions[name]; !ok || status.Status != model.StatusImplemented {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_ErrorStatus_success(t *testing.T) {
+	stub := initERC20(t)
+
+	// bad request
+	res := stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address)})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+
+	// forbidden
+	res = stub.MockInvoke("txSetName", [][]byte{[]byte("setName"), []byte(tokenID), []byte("stranger"), []byte("newName")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// not found
+	res = stub.MockInvoke("txUnknown", [][]byte{[]byte("unknownFunction")})
+	if res.Status != model.StatusNotFound {
+		t.FailNow()
+	}
+
+	// conflict
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1000000")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+}
+
+func Test_RenounceOwnership_mint_failure(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txAddMinter", [][]byte{[]byte("addMinter"), []byte(tokenID), []byte(address), []byte("minter")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+
+	res = stub.MockInvoke("txRenounce", [][]byte{[]byte("renounceOwnership"), []byte(tokenID), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// emit ownership renounced event
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EventName(repository.OwnershipRenouncedEventKey, tokenID) {
+		t.FailNow()
+	}
+
+	// neither the previous owner nor the minter can mint
+	res = stub.MockInvoke("txMint", [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte(address), []byte("10")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txMint", [][]byte{[]byte("mint"), []byte(tokenID), []byte("minter"), []byte("minter"), []byte("10")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// the renounced owner cannot be matched by an empty caller
+	res = stub.MockInvoke("txSetName", [][]byte{[]byte("setName"), []byte(tokenID), []byte(""), []byte("newName")})
+	if res.Status < shim.ERRORTHRESHOLD {
+		t.FailNow()
+	}
+}
+
+func Test_SetLabel_verifiedThreshold_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txLabel", [][]byte{[]byte("setLabel"), []byte(tokenID), []byte(address), []byte("recipient"), []byte("gold")})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txLabel", [][]byte{[]byte("setLabel"), []byte(tokenID), []byte("stranger"), []byte("recipient"), []byte(model.LabelVerified)})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txThreshold", [][]byte{[]byte("setVerifiedThreshold"), []byte(tokenID), []byte(address), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+
+	// unlabeled recipient can receive up to the threshold only
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("101")})
+	if res.Status != model.StatusForbidden {
+		t.FailNow()
+	}
+
+	// verified recipient can receive above the threshold
+	res = stub.MockInvoke("txLabel", [][]byte{[]byte("setLabel"), []byte(tokenID), []byte(address), []byte("recipient"), []byte(model.LabelVerified)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("101")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// label is surfaced by getLabel & accountInfo
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getLabel"), []byte("recipient")})
+	if string(res.GetPayload()) != model.LabelVerified {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("accountInfo"), []byte("recipient")})
+	accountInfo := model.AccountInfo{}
+	json.Unmarshal(res.GetPayload(), &accountInfo)
+	if accountInfo.Label != model.LabelVerified {
+		t.FailNow()
+	}
+}
+
+func Test_Invoke_panic_failure(t *testing.T) {
+	cc := NewChaincode()
+	cc.functions["panicking"] = functionEntry{handler: func(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+		return shim.Success([]byte(params[0]))
+	}}
+	stub := shim.NewMockStub("erc20", cc)
+
+	// handler indexing missing params returns error instead of crashing
+	res := stub.MockInvoke("txPanic", [][]byte{[]byte("panicking")})
+	if res.Status != shim.ERROR || !strings.Contains(res.Message, "panicking") {
+		t.FailNow()
+	}
+}
+
+func Test_RemainingMintable_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("remainingMintable"), []byte(tokenID)})
+	if res.Status != shim.OK || string(res.GetPayload()) != model.UnlimitedMintable {
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("remainingMintable"), []byte("unknownToken")})
+	if res.Status == shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_CirculatingSupply_success(t *testing.T) {
+	stub := newTestStub(initERC20(t))
+	deadline := strconv.FormatInt(time.Now().Unix()+3600, 10)
+	stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")})
+	stub.MockInvoke("txLock", [][]byte{[]byte("conditionalTransfer"), []byte("recipient"), []byte("other"), []byte("10"), []byte(deadline)})
+
+	// only the owner treasury is excluded
+	res := stub.MockInvoke("txExclude", [][]byte{[]byte("addExcludedAddress"), []byte(tokenID), []byte(address), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txExclude", [][]byte{[]byte("addExcludedAddress"), []byte(tokenID), []byte(address), []byte(address)})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("circulatingSupply"), []byte(tokenID)})
+	if res.Status != shim.OK || string(res.GetPayload()) != "90" {
+		t.FailNow()
+	}
+
+	// excluded address must be a valid, non-zero address
+	for _, invalid := range []string{"", "0x000", "bad address"} {
+		res = stub.MockInvoke("txExclude", [][]byte{[]byte("addExcludedAddress"), []byte(tokenID), []byte(address), []byte(invalid)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+		res = stub.MockInvoke("txInclude", [][]byte{[]byte("removeExcludedAddress"), []byte(tokenID), []byte(address), []byte(invalid)})
+		if res.Status != model.StatusBadRequest {
+			t.FailNow()
+		}
+	}
+
+	// removed address is counted again, unknown address cannot be removed
+	res = stub.MockInvoke("txInclude", [][]byte{[]byte("removeExcludedAddress"), []byte(tokenID), []byte(address), []byte("recipient")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txInclude", [][]byte{[]byte("removeExcludedAddress"), []byte(tokenID), []byte(address), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("circulatingSupply"), []byte(tokenID)})
+	if string(res.GetPayload()) != strconv.Itoa(initAmount-10) {
+		t.FailNow()
+	}
+}
+
+func Test_SetStrictApprovals_success(t *testing.T) {
+	stub := initERC20(t)
+	overBalance := strconv.Itoa(initAmount + 1)
+
+	// lenient mode allows approvals exceeding the balance
+	res := stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte(overBalance)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("txStrict", [][]byte{[]byte("setStrictApprovals"), []byte(tokenID), []byte(address), []byte("true")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if !erc20.StrictApprovals {
+		t.FailNow()
+	}
+
+	// strict mode rejects raising the allowance above the balance, lowering is still allowed
+	res = stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("other"), []byte(overBalance)})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txDecrease", [][]byte{[]byte("decreaseAllowance"), []byte(address), []byte("spender"), []byte("1")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txIncrease", [][]byte{[]byte("increaseAllowance"), []byte(address), []byte("spender"), []byte("2")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("other"), []byte(strconv.Itoa(initAmount))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_Admin_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txAdmin", [][]byte{[]byte("admin"), []byte("setName"), []byte(tokenID), []byte(address), []byte("newName")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	erc20, _ := repository.GetERC20Metadata(stub, tokenID)
+	if *erc20.GetName() != "newName" {
+		t.FailNow()
+	}
+
+	res = stub.MockInvoke("txAdmin", [][]byte{[]byte("admin"), []byte("mint"), []byte(tokenID), []byte(address), []byte("recipient"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "recipient", true)
+	if balance.Int64() != 10 {
+		t.FailNow()
+	}
+}
+
+func Test_Admin_failure(t *testing.T) {
+	stub := initERC20(t)
+
+	// non-owner gets 403 for every sub-command
+	for _, command := range []string{"setName", "mint", "addMinter"} {
+		res := stub.MockInvoke("txAdmin", [][]byte{[]byte("admin"), []byte(command), []byte(tokenID), []byte("stranger"), []byte("stranger"), []byte("10")})
+		if res.Status != model.StatusForbidden {
+			t.FailNow()
+		}
+	}
+
+	// unknown sub-command & non-admin function are not found
+	for _, command := range []string{"unknown", "transfer"} {
+		res := stub.MockInvoke("txAdmin", [][]byte{[]byte("admin"), []byte(command), []byte(tokenID), []byte(address)})
+		if res.Status != model.StatusNotFound {
+			t.FailNow()
+		}
+	}
+}
+
+// The approve/allowance tutorial functions existing deployments depend on,
+// characterized before they are changed
+
+func Test_Approve_compositeKey_characterization(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+
+	// allowance is stored as decimal string under approval/{owner}/{spender}
+	approvalKey, _ := stub.CreateCompositeKey(repository.AllowancePrefix, []string{address, "spender"})
+	if string(stub.State[approvalKey]) != "100" {
+		t.FailNow()
+	}
+	objectType, attributes, _ := stub.SplitCompositeKey(approvalKey)
+	if objectType != "approval" || len(attributes) != 2 || attributes[0] != address || attributes[1] != "spender" {
+		t.FailNow()
+	}
+
+	// allowance query returns the bare decimal string, unknown spender is "0"
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("allowance"), []byte(address), []byte("spender")})
+	if res.Status != shim.OK || string(res.GetPayload()) != "100" {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("allowance"), []byte(address), []byte("unknown")})
+	if res.Status != shim.OK || string(res.GetPayload()) != "0" {
+		t.FailNow()
+	}
+}
+
+func Test_ApprovalList_partialKey_characterization(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove1", [][]byte{[]byte("approve"), []byte(address), []byte("spenderB"), []byte("200")})
+	stub.MockInvoke("txApprove2", [][]byte{[]byte("approve"), []byte(address), []byte("spenderA"), []byte("100")})
+	stub.MockInvoke("txApprove3", [][]byte{[]byte("approve"), []byte(address), []byte("spenderC"), []byte("0")})
+
+	// owner whose address is a prefix of another owner doesn't match the partial key
+	stub.MockInvoke("txApprove4", [][]byte{[]byte("approve"), []byte(address + "2"), []byte("spenderA"), []byte("300")})
+
+	// the list is ordered by spender, keeps zero allowances and omits eventType & timestamp
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("approvalList"), []byte(address)})
+	expected := `[{"owner":"` + address + `","spender":"spenderA","allowance":100},` +
+		`{"owner":"` + address + `","spender":"spenderB","allowance":200},` +
+		`{"owner":"` + address + `","spender":"spenderC","allowance":0}]`
+	if res.Status != shim.OK || string(res.GetPayload()) != expected {
+		t.FailNow()
+	}
+
+	// owner without approvals gets an empty list
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("approvalList"), []byte("nobody")})
+	if res.Status != shim.OK || string(res.GetPayload()) != "[]" {
+		t.FailNow()
+	}
+}
+
+func Test_BurnFrom_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+	<-stub.ChaincodeEventsChannel
+
+	res := stub.MockInvoke("txBurnFrom", [][]byte{[]byte("burnFrom"), []byte(tokenID), []byte(address), []byte("spender"), []byte("60")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	balance, _ := repository.GetBalance(stub, address, true)
+	if totalSupply.Int64() != initAmount-60 || balance.Int64() != initAmount-60 {
+		t.FailNow()
+	}
+
+	// the delegated burn is distinguished from a direct transfer
+	data := <-stub.ChaincodeEventsChannel
+	event := model.TransferFromEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if data.GetEventName() != repository.EventName(repository.TransferFromEventKey, tokenID) ||
+		event.Owner != address || event.Spender != "spender" || event.Amount.Int64() != 60 || event.RemainingAllowance.Int64() != 40 {
+		t.FailNow()
+	}
+
+	// allowance cannot be exceeded
+	res = stub.MockInvoke("txBurnFrom", [][]byte{[]byte("burnFrom"), []byte(tokenID), []byte(address), []byte("spender"), []byte("41")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+}
+
+func Test_Mint_requestID_success(t *testing.T) {
+	stub := initERC20(t)
+	arguments := [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte("recipient"), []byte("10"), []byte("request-1")}
+	res := stub.MockInvoke("txMint", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	<-stub.ChaincodeEventsChannel
+
+	// retry returns the prior outcome without minting again
+	res = stub.MockInvoke("txRetry", arguments)
+	mintRequest := model.MintRequest{}
+	json.Unmarshal(res.GetPayload(), &mintRequest)
+	if res.Status != shim.OK || mintRequest.TxID != "txMint" || mintRequest.Amount.Int64() != 10 {
+		t.FailNow()
+	}
+	if len(stub.ChaincodeEventsChannel) != 0 {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "recipient", true)
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenID)
+	if balance.Int64() != 10 || totalSupply.Int64() != initAmount+10 {
+		t.FailNow()
+	}
+
+	// the request id cannot be reused for another mint
+	res = stub.MockInvoke("txReuse", [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte("recipient"), []byte("20"), []byte("request-1")})
+	if res.Status != model.StatusConflict {
+		t.FailNow()
+	}
+}
+
+func Test_SaveMintRequest_evict_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockTransactionStart("txSave")
+	for _, requestID := range []string{"request-1", "request-2", "request-3"} {
+		repository.SaveMintRequest(stub, model.NewMintRequest(requestID, "txSave", address, address, big.NewInt(1), 0), 2)
+	}
+	stub.MockTransactionEnd("txSave")
+
+	// only the latest 2 requests are stored
+	evicted, _ := repository.GetMintRequest(stub, "request-1")
+	stored, _ := repository.GetMintRequest(stub, "request-3")
+	if evicted != nil || stored == nil || stored.RequestID != "request-3" {
+		t.FailNow()
+	}
+}
+
+func Test_StateSchema_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("stateSchema")})
+	schema := []model.StateKeySchema{}
+	json.Unmarshal(res.GetPayload(), &schema)
+	if res.Status != shim.OK || len(schema) != len(repository.StateSchema) || schema[0].Prefix != repository.BalancePrefix {
+		t.FailNow()
+	}
+}
+
+func Test_TransferSplit_duplicateRecipient_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txSplit", [][]byte{[]byte("transferSplit"), []byte(address), []byte("primary"), []byte("90"), []byte("primary"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the full sum is credited
+	callerBalance, _ := repository.GetBalance(stub, address, true)
+	primaryBalance, _ := repository.GetBalance(stub, "primary", true)
+	if callerBalance.Int64() != initAmount-100 || primaryBalance.Int64() != 100 {
+		t.FailNow()
+	}
+
+	// the event has one movement of the sum
+	data := <-stub.ChaincodeEventsChannel
+	event := model.BatchTransferEvent{}
+	json.Unmarshal(data.GetPayload(), &event)
+	if len(event.Transfers) != 1 || event.Transfers[0].Amount.Int64() != 100 {
+		t.FailNow()
+	}
+}
+
+func Test_UsageStats_success(t *testing.T) {
+	stub := initERC20(t)
+
+	// nothing is recorded until owner enables metering
+	stub.MockInvoke("txTransfer0", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	res := stub.MockInvoke("txMetering", [][]byte{[]byte("setUsageMetering"), []byte(tokenID), []byte(address), []byte("true")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	stub.MockInvoke("txTransfer1", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	stub.MockInvoke("txTransfer2", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	stub.MockInvoke("txBalance", [][]byte{[]byte("balanceOf"), []byte(address)})
+
+	res = stub.MockInvoke("txStats", [][]byte{[]byte("usageStats")})
+	stats := map[string]int{}
+	json.Unmarshal(res.GetPayload(), &stats)
+	if res.Status != shim.OK || stats["transfer"] != 2 || stats["balanceOf"] != 1 || stats["setUsageMetering"] != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_ApprovalList_malformedKey_success(t *testing.T) {
+	stub := initERC20(t)
+	stub.MockInvoke("txApprove", [][]byte{[]byte("approve"), []byte(address), []byte("spender"), []byte("100")})
+
+	// keys under the prefixes with unexpected attribute counts
+	stub.MockTransactionStart("txCorrupt")
+	shortKey, _ := stub.CreateCompositeKey(repository.AllowancePrefix, []string{address})
+	stub.PutState(shortKey, []byte("5"))
+	longKey, _ := stub.CreateCompositeKey(repository.AllowancePrefix, []string{address, "spender2", "extra"})
+	stub.PutState(longKey, []byte("5"))
+	balanceKey, _ := stub.CreateCompositeKey(repository.BalancePrefix, []string{"holder", "extra"})
+	stub.PutState(balanceKey, []byte("5"))
+	stub.MockTransactionEnd("txCorrupt")
+
+	// malformed keys are skipped instead of crashing the list queries
+	res := stub.MockInvoke("txQuery", [][]byte{[]byte("approvalList"), []byte(address)})
+	approvals := []model.Approval{}
+	json.Unmarshal(res.GetPayload(), &approvals)
+	if res.Status != shim.OK || len(approvals) != 1 || approvals[0].Spender != "spender" {
+		t.FailNow()
+	}
+	res = newTestStub(stub).MockInvoke("txQuery", [][]byte{[]byte("checkInvariant"), []byte(tokenID)})
+	report := model.InvariantReport{}
+	json.Unmarshal(res.GetPayload(), &report)
+	if res.Status != shim.OK || report.Accounts != 1 {
+		t.FailNow()
+	}
+}
+
+func Test_TransferEvent_decimals_success(t *testing.T) {
+	cc := NewChaincode()
+	stub := shim.NewMockStub("erc20", cc)
+	stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("12345"), []byte(`{"decimals": 2}`)})
+	<-stub.ChaincodeEventsChannel
+
+	// transfer & mint events carry the decimals with the raw amount
+	for _, arguments := range [][][]byte{
+		{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("150")},
+		{[]byte("mint"), []byte(tokenID), []byte(address), []byte("recipient"), []byte("150")},
+	} {
+		res := stub.MockInvoke("txTransfer", arguments)
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+		data := <-stub.ChaincodeEventsChannel
+		event := model.TransferEvent{}
+		json.Unmarshal(data.GetPayload(), &event)
+		if event.Decimals == nil || *event.Decimals != 2 || event.Amount.Int64() != 150 {
+			t.FailNow()
+		}
+	}
+
+	// txlog entries don't carry decimals
+	logPage, _ := repository.GetTransferLogs(newTestStub(stub), "recipient", 10, "")
+	if len(logPage.Logs) != 1 || logPage.Logs[0].Event.Decimals != nil {
+		t.FailNow()
+	}
+}
+
+func Test_SetMaintenance_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txMaintenance", [][]byte{[]byte("setMaintenance"), []byte(tokenID), []byte(address), []byte("true")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// transfers & queries are unavailable
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	if res.Status != model.StatusUnavailable || !strings.Contains(res.Message, "under maintenance") {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("balanceOf"), []byte(address)})
+	if res.Status != model.StatusUnavailable {
+		t.FailNow()
+	}
+
+	// owner can still configure the token, the mode is surfaced in metadata
+	res = stub.MockInvoke("txSetName", [][]byte{[]byte("setName"), []byte(tokenID), []byte(address), []byte("newName")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txAdmin", [][]byte{[]byte("admin"), []byte("setSymbol"), []byte(tokenID), []byte(address), []byte("NEW")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// the supply is frozen, directly or through admin
+	res = stub.MockInvoke("txMint", [][]byte{[]byte("mint"), []byte(tokenID), []byte(address), []byte(address), []byte("1")})
+	if res.Status != model.StatusUnavailable {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txAdmin", [][]byte{[]byte("admin"), []byte("mint"), []byte(tokenID), []byte(address), []byte(address), []byte("1")})
+	if res.Status != model.StatusUnavailable {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txMintBatch", [][]byte{[]byte("mintBatch"), []byte(tokenID), []byte(address), []byte(`[{"recipient": "a", "amount": "1"}]`)})
+	if res.Status != model.StatusUnavailable {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("getMetadata"), []byte(tokenID)})
+	erc20 := model.ERC20Metadata{}
+	json.Unmarshal(res.GetPayload(), &erc20)
+	if res.Status != shim.OK || !erc20.Maintenance || erc20.GetTotalSupply().Int64() != initAmount {
+		t.FailNow()
+	}
+
+	// ending maintenance makes every function available again
+	stub.MockInvoke("txMaintenance", [][]byte{[]byte("setMaintenance"), []byte(tokenID), []byte(address), []byte("false")})
+	res = stub.MockInvoke("txTransfer", [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("1")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_Init_decimalsTooLarge_failure(t *testing.T) {
+	cc := NewChaincode()
+	stub := shim.NewMockStub("erc20", cc)
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("100"), []byte(`{"decimals": 255}`)})
+	if res.Status != model.StatusBadRequest {
+		t.FailNow()
+	}
+	if _, err := repository.GetERC20Metadata(stub, tokenID); err == nil {
+		t.FailNow()
+	}
+
+	// 18 is accepted
+	res = stub.MockInit("2", [][]byte{[]byte("init"), []byte(tokenID), []byte(tokenName), []byte("dt"), []byte(address), []byte("100"), []byte(`{"decimals": 18}`)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// adminCommand is the handler of admin sub-command, params begin with tokenID & caller's address
+type adminCommand func(stub shim.ChaincodeStubInterface, params []string) sc.Response
+
+// adminCommands returns the owner-only functions dispatched by Admin
+func (cc *Controller) adminCommands() map[string]adminCommand {
+	return map[string]adminCommand{
+		"setName":               cc.SetName,
+		"setSymbol":             cc.SetSymbol,
+		"lockMetadata":          cc.LockMetadata,
+		"setEmitEvents":         cc.SetEmitEvents,
+		"setReserve":            cc.SetReserve,
+		"setRateLimit":          cc.SetRateLimit,
+		"setTransferCooldown":   cc.SetTransferCooldown,
+		"setCircuitBreaker":     cc.SetCircuitBreaker,
+		"unpause":               cc.Unpause,
+		"setStrictApprovals":    cc.SetStrictApprovals,
+		"setLabel":              cc.SetLabel,
+		"setVerifiedThreshold":  cc.SetVerifiedThreshold,
+		"addMinter":             cc.AddMinter,
+		"removeMinter":          cc.RemoveMinter,
+		"addExcludedAddress":    cc.AddExcludedAddress,
+		"removeExcludedAddress": cc.RemoveExcludedAddress,
+		"setBurnAddress":        cc.SetBurnAddress,
+		"setKeyPolicy":          cc.SetKeyPolicy,
+		"setWhitelistMode":      cc.SetWhitelistMode,
+		"addAllowed":            cc.AddAllowed,
+		"removeAllowed":         cc.RemoveAllowed,
+		"reconcileSupply":       cc.ReconcileSupply,
+		"mint":                  cc.Mint,
+		"mintTo":                cc.MintTo,
+		"mintBatch":             cc.MintBatch,
+		"clawback":              cc.Clawback,
+		"sweep":                 cc.Sweep,
+		"migrateBalances":       cc.MigrateBalances,
+		"seedBalances":          cc.SeedBalances,
+		"renounceOwnership":     cc.RenounceOwnership,
+	}
+}
+
+// Admin is invoke function that dispatches an owner-only sub-command after a single owner check
+// Non-owners get 403 before any sub-command runs, the sub-commands still check the owner themselves,
+// so calling them directly stays safe
+// sub-commands - setName, setSymbol, lockMetadata, setEmitEvents, setReserve, setRateLimit,
+// setTransferCooldown, setCircuitBreaker, unpause, setStrictApprovals, setLabel, setVerifiedThreshold,
+// addMinter, removeMinter, addExcludedAddress, removeExcludedAddress, setBurnAddress, setKeyPolicy,
+// setWhitelistMode, addAllowed, removeAllowed, mint, mintTo, mintBatch, clawback, sweep, migrateBalances,
+// seedBalances, reconcileSupply, renounceOwnership
+// params - sub-command, tokenID, caller's address, [params of sub-command...]
+func (cc *Controller) Admin(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is at least 3
+	if len(params) < 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	commandName, tokenID, callerAddress := params[0], params[1], params[2]
+
+	// sub-command must be one of the admin commands
+	commands := cc.adminCommands()
+	command, ok := commands[commandName]
+	if !ok {
+		names := []string{}
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return util.NotFound("admin command " + commandName + " is not supported, supported commands: " + strings.Join(names, ", "))
+	}
+
+	// only owner can run admin commands, maintenance freezes the sub-commands it freezes when called directly
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = cc.CheckMaintenance(erc20Metadata, commandName)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return command(stub, params[1:])
+}
@@ -0,0 +1,348 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// TransferBatch is invoke function that moves amount tokens
+// from the caller's address to each recipient, the caller is debited once for the total
+// params - caller's address, batch(JSON array of {"recipient", "amount"})
+func (cc *Controller) TransferBatch(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	callerAddress, batch := params[0], params[1]
+
+	// parse batch
+	entries, amounts, err := parseBatch(batch)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// debit the caller once & credit each recipient
+	transfers, err := transferBatch(stub, callerAddress, entries, amounts)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit one aggregated event, txlog indexes each movement of the batch
+	err = emitBatchTransferEvent(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// count the volume of circuit breaker, last since tripping replaces the event
+	err = recordBatchVolume(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("transferBatch success"))
+}
+
+// MintBatch is invoke function that creates amount tokens for each recipient, increasing the total supply
+// Only owner or minters can mint, the admin's txlog indexes the mint of each recipient
+// params - tokenID, caller's address, batch(JSON array of {"recipient", "amount"})
+func (cc *Controller) MintBatch(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, batch := params[0], params[1], params[2]
+
+	// parse batch
+	entries, amounts, err := parseBatch(batch)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	total := big.NewInt(0)
+	for _, amount := range amounts {
+		total = util.AddBigBalance(total, amount)
+	}
+
+	// only owner or minters can mint
+	erc20Metadata, err := assertMinter(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// increase TotalSupply
+	erc20Metadata.TotalSupply = util.AddBigBalance(erc20Metadata.GetTotalSupply(), total)
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// credit each recipient
+	transfers, err := creditBatch(stub, "admin", entries, amounts)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit one aggregated event (skipped when events are disabled)
+	err = emitBatchTransferEvent(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("mintBatch success"))
+}
+
+// TreasuryDistribute is invoke function that moves amount tokens from owner's balance (treasury)
+// to each recipient, unlike mintBatch the total supply doesn't change
+// Only owner can distribute, owner is debited once for the total before any recipient is credited,
+// the treasury's txlog indexes each payout
+// params - tokenID, caller's address, batch(JSON array of {"recipient", "amount"})
+func (cc *Controller) TreasuryDistribute(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, batch := params[0], params[1], params[2]
+
+	// parse batch
+	entries, amounts, err := parseBatch(batch)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can distribute the treasury
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// debit owner once & credit each recipient
+	transfers, err := transferBatch(stub, erc20Metadata.Owner, entries, amounts)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit one consolidated event (skipped when events are disabled)
+	err = emitBatchTransferEvent(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// count the volume of circuit breaker, last since tripping replaces the event
+	err = recordBatchVolume(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("treasuryDistribute success"))
+}
+
+// TransferSplit is invoke function that moves amount1 token to recipient1 and amount2 token to recipient2
+// from the caller's address, the caller is debited once for the sum
+// When recipient1 and recipient2 are the same, the recipient is credited once with the sum,
+// otherwise the caller's txlog indexes both movements
+// params - caller's address, recipient1's address, amount1, recipient2's address, amount2
+func (cc *Controller) TransferSplit(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	callerAddress := params[0]
+	entries := []model.BatchEntry{
+		{Recipient: params[1], Amount: params[2]},
+		{Recipient: params[3], Amount: params[4]},
+	}
+
+	// merge the same recipient into one entry, crediting one key twice in a transaction
+	// reads the pre-transfer balance both times and the first credit is lost
+	if entries[0].Recipient == entries[1].Recipient {
+		mergedEntry, err := mergeSplitEntries(entries[0], entries[1])
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		entries = []model.BatchEntry{mergedEntry}
+	}
+
+	// validate recipients & amounts like batch
+	amounts, err := validateBatch(entries)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// debit the caller once & credit both recipients
+	transfers, err := transferBatch(stub, callerAddress, entries, amounts)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit one consolidated event with both movements (skipped when events are disabled)
+	err = emitBatchTransferEvent(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// count the volume of circuit breaker, last since tripping replaces the event
+	err = recordBatchVolume(stub, transfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("transferSplit success"))
+}
+
+// mergeSplitEntries returns one entry of the same recipient with the sum of both amounts
+func mergeSplitEntries(entry1, entry2 model.BatchEntry) (model.BatchEntry, error) {
+	amount1, err := util.ConvertToBigPositive("amount1", entry1.Amount)
+	if err != nil {
+		return model.BatchEntry{}, err
+	}
+	amount2, err := util.ConvertToBigPositive("amount2", entry2.Amount)
+	if err != nil {
+		return model.BatchEntry{}, err
+	}
+
+	return model.BatchEntry{Recipient: entry1.Recipient, Amount: util.AddBigBalance(amount1, amount2).String()}, nil
+}
+
+// transferBatch debits the caller once for the total of batch and credits each recipient
+// Returns the movements for the aggregated event
+func transferBatch(stub shim.ChaincodeStubInterface, callerAddress string, entries []model.BatchEntry, amounts []*big.Int) ([]model.TransferEvent, error) {
+	total := big.NewInt(0)
+	for i, entry := range entries {
+		if entry.Recipient == callerAddress {
+			return nil, model.NewStatusError(model.StatusBadRequest, fmt.Sprintf("invalid batch entry %d: recipient cannot be the caller", i))
+		}
+		total = util.AddBigBalance(total, amounts[i])
+	}
+
+	// check the caller's cooldown & rate limit
+	err := checkSenderLimits(stub, callerAddress, total)
+	if err != nil {
+		return nil, err
+	}
+
+	// debit the caller once for the total (caller's result amount cannot be negative)
+	callerAmount, err := repository.GetBalance(stub, callerAddress, false)
+	if err != nil {
+		return nil, err
+	}
+	callerResultAmount, err := util.SubBigBalance("caller's balance", callerAmount, total)
+	if err != nil {
+		return nil, err
+	}
+	err = repository.SaveBalance(stub, callerAddress, callerResultAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	// credit each recipient
+	return creditBatch(stub, callerAddress, entries, amounts)
+}
+
+// emitBatchTransferEvent emits the aggregated event of transfers unless owner disabled events of the token
+func emitBatchTransferEvent(stub shim.ChaincodeStubInterface, transfers []model.TransferEvent) error {
+	decimals, emitEvents, err := eventDecimals(stub)
+	if err != nil || !emitEvents {
+		return err
+	}
+
+	return repository.EmitBatchTransferEvent(stub, transfers, decimals)
+}
+
+// recordBatchVolume counts the total of transfers in the circuit breaker
+func recordBatchVolume(stub shim.ChaincodeStubInterface, transfers []model.TransferEvent) error {
+	total := big.NewInt(0)
+	for _, transfer := range transfers {
+		total = util.AddBigBalance(total, transfer.Amount)
+	}
+
+	return recordTransferVolume(stub, total)
+}
+
+// parseBatch strictly decodes batch params
+// Unknown fields, empty batch, empty recipients, non-positive amounts and duplicate recipients are rejected
+// Returns the entries and the parsed amounts
+func parseBatch(batch string) ([]model.BatchEntry, []*big.Int, error) {
+	entries := []model.BatchEntry{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(batch)))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&entries)
+	if err != nil {
+		return nil, nil, model.NewCustomError(model.ConvertErrorType, "batch", "must be JSON array of {\"recipient\", \"amount\"}, "+err.Error())
+	}
+	if decoder.More() {
+		return nil, nil, model.NewCustomError(model.ConvertErrorType, "batch", "unexpected data after batch array")
+	}
+
+	amounts, err := validateBatch(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, amounts, nil
+}
+
+// validateBatch checks batch entries and returns the parsed amounts
+func validateBatch(entries []model.BatchEntry) ([]*big.Int, error) {
+	// batch cannot be empty
+	if len(entries) == 0 {
+		return nil, model.NewStatusError(model.StatusBadRequest, "batch cannot be empty")
+	}
+
+	amounts := []*big.Int{}
+	recipients := map[string]bool{}
+	for i, entry := range entries {
+		if len(entry.Recipient) == 0 {
+			return nil, model.NewStatusError(model.StatusBadRequest, fmt.Sprintf("invalid batch entry %d: recipient cannot be empty", i))
+		}
+		if recipients[entry.Recipient] {
+			return nil, model.NewStatusError(model.StatusBadRequest, fmt.Sprintf("invalid batch entry %d: duplicate recipient %s", i, entry.Recipient))
+		}
+		recipients[entry.Recipient] = true
+
+		amount, err := util.ConvertToBigPositive("amount", entry.Amount)
+		if err != nil {
+			return nil, model.NewStatusError(model.StatusBadRequest, fmt.Sprintf("invalid batch entry %d: %s", i, err.Error()))
+		}
+		amounts = append(amounts, amount)
+	}
+
+	return amounts, nil
+}
+
+// creditBatch credits each recipient of batch through creditRecipient, the entry i is the movement i of txlog
+// Returns the movements for the aggregated event sorted by recipient, so every endorsing peer
+// produces the identical event regardless of the order of entries
+func creditBatch(stub shim.ChaincodeStubInterface, senderAddress string, entries []model.BatchEntry, amounts []*big.Int) ([]model.TransferEvent, error) {
+	transfers := []model.TransferEvent{}
+	for i, entry := range entries {
+		_, err := creditRecipient(stub, senderAddress, entry.Recipient, amounts[i], nil, i)
+		if err != nil {
+			return nil, model.NewStatusError(util.ErrorStatus(err), fmt.Sprintf("invalid batch entry %d: %s", i, err.Error()))
+		}
+
+		transfers = append(transfers, *model.NewTransferEvent(senderAddress, entry.Recipient, amounts[i]))
+	}
+
+	// recipients are unique in batch, so the order is total
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].Recipient < transfers[j].Recipient
+	})
+
+	return transfers, nil
+}
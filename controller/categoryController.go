@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// maxCategoryLength is the max length of transfer category in bytes
+const maxCategoryLength = 32
+
+// CategorizedTransfer is invoke function that moves amount token like transfer
+// and tags the movement with an accounting category, e.g. "salary" or "refund"
+// The transfer event carries the category and the movement is indexed under category/{category}/{txID}
+// params - caller's address, recipient's address, amount of token, category
+func (cc *Controller) CategorizedTransfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	callerAddress, recipientAddress, transferAmount, category := params[0], params[1], params[2], params[3]
+
+	// category is free-form but limited to the allowed charset & length, it is a key attribute
+	err := validateCategory(category)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return transferResponse(stub, callerAddress, recipientAddress, transferAmount, category)
+}
+
+// CategoryTransfers is query function
+// params - category, page size, bookmark
+// Returns one page of the categorizedTransfer movements of category
+func (cc *Controller) CategoryTransfers(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	category, pageSize, bookmark := params[0], params[1], params[2]
+
+	err := validateCategory(category)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check page size is integer in [1, MaxInt32]
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// get category logs
+	page, err := repository.GetCategoryLogs(stub, category, int32(pageSizeInt), bookmark)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert page to bytes for return
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal transferLogPage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// validateCategory checks category is not empty and is a valid text of at most maxCategoryLength bytes
+func validateCategory(category string) error {
+	if len(category) == 0 {
+		return model.NewStatusError(model.StatusBadRequest, "category cannot be empty")
+	}
+
+	return util.ValidateText("category", category, maxCategoryLength)
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SetCircuitBreaker is invoke function that configures the token-wide transfer volume threshold (owner only)
+// When the transfers (transfer, transferFrom, batches, escrows & swaps) move more than maxVolume in total in a window of windowSeconds,
+// the token pauses until owner unpauses, "0" windowSeconds disables the circuit breaker (default)
+// Every counted transfer writes config/circuitBreaker, so while enabled the transfers of one block conflict (MVCC)
+// params - tokenID, caller's address, windowSeconds, maxVolume
+func (cc *Controller) SetCircuitBreaker(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, windowSeconds, maxVolume := params[0], params[1], params[2], params[3]
+
+	// check windowSeconds is non-negative integer & maxVolume is positive when enabled
+	windowSecondsInt, err := util.ParseDecimalInt("windowSeconds", windowSeconds, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	maxVolumeBig, err := util.ParseBigBalance("maxVolume", maxVolume)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if windowSecondsInt > 0 && maxVolumeBig.Sign() == 0 {
+		return util.BadRequest("maxVolume must be positive")
+	}
+
+	// only owner can configure
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save circuit breaker
+	erc20Metadata.CircuitBreaker = nil
+	if windowSecondsInt > 0 {
+		erc20Metadata.CircuitBreaker = model.NewCircuitBreaker(windowSecondsInt, maxVolumeBig)
+	}
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setCircuitBreaker success"))
+}
+
+// Unpause is invoke function that lifts the pause tripped by the circuit breaker (owner only)
+// The volume of the current window restarts from zero, so the next transfer doesn't trip again at once
+// params - tokenID, caller's address
+func (cc *Controller) Unpause(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress := params[0], params[1]
+
+	// only owner can unpause
+	_, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	state, err := repository.GetCircuitBreakerState(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if !state.Paused {
+		return util.Conflict("token " + tokenID + " is not paused")
+	}
+
+	// save state without the pause
+	state.Paused = false
+	state.PausedAt = 0
+	state.Volume = big.NewInt(0)
+	err = repository.SaveCircuitBreakerState(stub, state)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "paused", "true", "false")
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("unpause success"))
+}
+
+// checkPaused returns 503 error while the token is paused by the circuit breaker,
+// it guards every movement out of a balance, burns too while only the transfers are counted
+func checkPaused(stub shim.ChaincodeStubInterface) error {
+	state, err := repository.GetCircuitBreakerState(stub)
+	if err != nil {
+		return err
+	}
+	if state.Paused {
+		return model.NewStatusError(model.StatusUnavailable, "transfers are paused, "+model.PausedReasonVolumeExceeded+", owner must unpause")
+	}
+
+	return nil
+}
+
+// recordTransferVolume counts amount in the circuit breaker window of transaction,
+// the windows are fixed like the rate limit's. When the volume exceeds the threshold, the token pauses
+// and the paused event replaces the transfer event, Fabric keeps one event per transaction
+// The tripping transfer itself succeeds: failing it would roll back the pause with it
+func recordTransferVolume(stub shim.ChaincodeStubInterface, amount *big.Int) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	circuitBreaker := erc20Metadata.CircuitBreaker
+	if circuitBreaker == nil {
+		return nil
+	}
+
+	// get the window of transaction, the volume of an older window is dropped
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return err
+	}
+	windowStart := txSeconds / circuitBreaker.WindowSeconds * circuitBreaker.WindowSeconds
+	state, err := repository.GetCircuitBreakerState(stub)
+	if err != nil {
+		return err
+	}
+	if state.WindowStart != windowStart {
+		state.WindowStart = windowStart
+		state.Volume = big.NewInt(0)
+	}
+
+	// count the transfer & trip above the threshold
+	state.Volume = util.AddBigBalance(state.Volume, amount)
+	tripped := util.ExceedsLimit(state.Volume, circuitBreaker.MaxVolume)
+	if tripped {
+		state.Paused = true
+		state.PausedAt = txSeconds
+	}
+	err = repository.SaveCircuitBreakerState(stub, state)
+	if err != nil {
+		return err
+	}
+
+	if !tripped {
+		return nil
+	}
+	return repository.EmitPausedEvent(stub, model.NewPausedEvent(erc20Metadata.ID, model.PausedReasonVolumeExceeded, state.Volume, circuitBreaker))
+}
@@ -1,13 +1,20 @@
 package controller
 
 import (
-	"strconv"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
 
+	"github.com/erc20/model"
 	"github.com/erc20/repository"
+	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
+// Controller is the definition of ERC20 function handlers
 type Controller struct {
 }
 
@@ -16,37 +23,558 @@ func NewController() *Controller {
 }
 
 // Init is called when the chaincode is instantiated by the blockchain network.
-// params - tokenName, symbol, owner(address), amount
+// params - tokenID, tokenName, symbol, owner(address), amount, [options(JSON)]
+// tokenID is the stable state key of metadata, tokenName is the display name
+// options - {"minEndorsements": positive integer, default 1, "decimals": integer up to 18, default 0,
+// "displayFormat": template containing {amount} & {symbol}, default "{amount} {symbol}",
+// "eventPrefix": letters, digits & "-_" prepended to event names, default none (see repository.EventName),
+// "backupOwner": address claiming the ownership of inactive owner (see ClaimOwnership), default none,
+// "inactivityPeriod": positive seconds of owner inactivity, required with backupOwner}
+// Init is called again on chaincode upgrade, then the existing token is migrated (see upgrade)
+// State is written in the order of metadata, tokenID and then owner balance,
+// a failed write aborts the whole transaction, so the token is never created without owner balance
 func (cc *Controller) Init(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	if len(params) != 4 {
-		return shim.Error("incorrect number of parameter")
+
+	// the token exists on upgrade
+	existingTokenID, err := repository.GetTokenID(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if len(existingTokenID) != 0 {
+		return cc.upgrade(stub, existingTokenID, params)
+	}
+
+	if len(params) != 5 && len(params) != 6 {
+		return util.BadRequest("incorrect number of parameter")
+	}
+
+	tokenID, tokenName, symbol, owner, amount := params[0], params[1], params[2], params[3], params[4]
+
+	// parse options
+	options := "{}"
+	if len(params) == 6 {
+		options = params[5]
+	}
+	initOptions, err := parseInitOptions(options)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check amount is unsigned integer, big integer is not limited to 64 bits
+	if len(amount) == 0 {
+		return util.BadRequest("amount cannot be empty")
+	}
+	if strings.HasPrefix(amount, "-") {
+		return util.BadRequest("amount cannot be negative")
+	}
+	amountBig, err := util.ParseBigBalance("amount", amount)
+	if err != nil {
+		return util.BadRequest("amount must be a number")
 	}
 
-	tokenName, symbol, owner, amount := params[0], params[1], params[2], params[3]
+	// tokenID & tokenName & symbol & owner cannot be empty
+	if len(tokenID) == 0 || len(tokenName) == 0 || len(symbol) == 0 || len(owner) == 0 {
+		return util.BadRequest("tokenID or tokenName or symbol or owner cannot be emtpy")
+	}
 
-	// check amount is unsigned int
-	amountUint, err := strconv.ParseUint(string(amount), 10, 64)
+	// tokenID & tokenName & symbol are limited to the allowed charset & length
+	err = validateTokenTexts(tokenID, tokenName, symbol)
 	if err != nil {
-		return shim.Error("amount must be a number or amount cannot be negative")
+		return util.ErrorResponse(err)
 	}
 
-	// tokenName & symbol & owner cannot be empty
-	if len(tokenName) == 0 || len(symbol) == 0 || len(owner) == 0 {
-		return shim.Error("tokenName or symbol or owner cannot be emtpy")
+	// owner becomes the balance key of the initial supply, so it must be a well-formed address
+	err = util.ValidateAddress("owner", owner)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// backupOwner must be another address with the inactivity period
+	err = validateBackupOwner(owner, initOptions)
+	if err != nil {
+		return util.ErrorResponse(err)
 	}
 
 	// save token meta data
-	err = repository.SaveERC20Metadata(stub, tokenName, symbol, owner, amountUint)
+	erc20 := model.NewERC20MetaData(tokenID, tokenName, symbol, owner, amountBig)
+	erc20.MinEndorsements = *initOptions.MinEndorsements
+	erc20.Decimals = initOptions.Decimals
+	erc20.DisplayFormat = *initOptions.DisplayFormat
+	erc20.EventPrefix = initOptions.EventPrefix
+	erc20.CreatedAt, err = getTxSeconds(stub)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
+	}
+	erc20.CreatedTx = stub.GetTxID()
+	erc20.BackupOwner = initOptions.BackupOwner
+	erc20.InactivityPeriod = initOptions.InactivityPeriod
+	if len(erc20.BackupOwner) != 0 {
+		erc20.OwnerActiveAt = erc20.CreatedAt
+	}
+	erc20.Standard = erc20.DeriveStandard()
+	err = saveMetadata(stub, tokenID, erc20)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.SaveTokenID(stub, tokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
 	}
 
-	// save owner balance
-	err = repository.SaveBalance(stub, owner, amount)
+	// save owner balance, metadata is already written in this transaction
+	// The parsed amount is saved, so the owner's balance has the format of every other balance, not the raw param (e.g. "00100")
+	err = repository.SaveBalance(stub, owner, amountBig)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.StatusResponse(util.ErrorStatus(err), "failed to save owner balance, token is not created, error: "+err.Error())
+	}
+
+	// emit token created event after metadata & balance are saved
+	err = repository.EmitTokenCreatedEvent(stub, erc20)
+	if err != nil {
+		return util.ErrorResponse(err)
 	}
 
 	// response
 	return shim.Success(nil)
 }
+
+// upgrade migrates the existing token when Init is called on chaincode upgrade
+// Balances, total supply, owner, name, symbol and decimals are preserved,
+// only safe metadata fields are updated or filled with the default values
+// params - [tokenID, tokenName, symbol, owner(address), amount, [options(JSON)]]
+// tokenID must be the existing tokenID, only minEndorsements of options is applied
+func (cc *Controller) upgrade(stub shim.ChaincodeStubInterface, existingTokenID string, params []string) sc.Response {
+	if len(params) != 0 && len(params) != 5 && len(params) != 6 {
+		return util.BadRequest("incorrect number of parameter")
+	}
+
+	// tokenID cannot be changed
+	if len(params) != 0 && params[0] != existingTokenID {
+		return util.BadRequest("tokenID cannot be changed on upgrade, existing tokenID is " + existingTokenID)
+	}
+
+	erc20, err := repository.GetERC20Metadata(stub, existingTokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// fill the default values of fields added after the token was created
+	if erc20.MinEndorsements == 0 {
+		erc20.MinEndorsements = 1
+	}
+	if erc20.ReserveSupply == nil {
+		erc20.ReserveSupply = big.NewInt(0)
+	}
+	if erc20.Minters == nil {
+		erc20.Minters = []string{}
+	}
+	if len(erc20.DisplayFormat) == 0 {
+		erc20.DisplayFormat = model.DefaultDisplayFormat
+	}
+	if erc20.EmitEvents == nil {
+		emitEvents := true
+		erc20.EmitEvents = &emitEvents
+	}
+	if len(erc20.Standard) == 0 {
+		erc20.Standard = erc20.DeriveStandard()
+	}
+
+	// apply options
+	if len(params) == 6 {
+		initOptions, err := parseInitOptions(params[5])
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		erc20.MinEndorsements = *initOptions.MinEndorsements
+	}
+
+	err = saveMetadata(stub, existingTokenID, erc20)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("upgrade success"))
+}
+
+// max lengths of token texts in bytes
+const (
+	maxTokenIDLength   = 64
+	maxTokenNameLength = 64
+	maxSymbolLength    = 16
+)
+
+// validateTokenTexts checks tokenID & tokenName & symbol against the allowed charset & max lengths
+func validateTokenTexts(tokenID, tokenName, symbol string) error {
+	err := util.ValidateText("tokenID", tokenID, maxTokenIDLength)
+	if err != nil {
+		return err
+	}
+	err = util.ValidateText("tokenName", tokenName, maxTokenNameLength)
+	if err != nil {
+		return err
+	}
+
+	return util.ValidateText("symbol", symbol, maxSymbolLength)
+}
+
+// parseInitOptions parses options of Init and fills the default values
+func parseInitOptions(options string) (*model.InitOptions, error) {
+	initOptions := model.InitOptions{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(options)))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&initOptions)
+	if err != nil {
+		return nil, model.NewCustomError(model.ConvertErrorType, "options", "must be JSON object, "+err.Error())
+	}
+
+	// minEndorsements must be positive
+	if initOptions.MinEndorsements == nil {
+		minEndorsements := 1
+		initOptions.MinEndorsements = &minEndorsements
+	}
+	if *initOptions.MinEndorsements <= 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, "minEndorsements", " must be positive")
+	}
+
+	// decimals cannot be larger than model.MaxDecimals
+	if initOptions.Decimals > model.MaxDecimals {
+		return nil, model.NewCustomError(model.ConvertErrorType, "decimals", fmt.Sprintf(" cannot be larger than %d", model.MaxDecimals))
+	}
+
+	// displayFormat must contain both placeholders
+	if initOptions.DisplayFormat == nil {
+		displayFormat := model.DefaultDisplayFormat
+		initOptions.DisplayFormat = &displayFormat
+	}
+	if !strings.Contains(*initOptions.DisplayFormat, model.AmountPlaceholder) || !strings.Contains(*initOptions.DisplayFormat, model.SymbolPlaceholder) {
+		return nil, model.NewCustomError(model.ConvertErrorType, "displayFormat", " must contain "+model.AmountPlaceholder+" and "+model.SymbolPlaceholder)
+	}
+
+	// eventPrefix becomes the head of event names, which listeners match by regex
+	err = validateEventPrefix(initOptions.EventPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &initOptions, nil
+}
+
+// maxEventPrefixLength is the max length of eventPrefix in bytes
+const maxEventPrefixLength = 32
+
+// validateEventPrefix checks eventPrefix only has ASCII letters, digits and "-_" and is at most maxEventPrefixLength bytes
+// "." separates the parts of event names and the other characters would need escaping in listener regexes
+func validateEventPrefix(eventPrefix string) error {
+	if len(eventPrefix) > maxEventPrefixLength {
+		return model.NewCustomError(model.ConvertErrorType, "eventPrefix", fmt.Sprintf(" cannot be longer than %d bytes", maxEventPrefixLength))
+	}
+	for i, c := range eventPrefix {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_':
+		default:
+			return model.NewCustomError(model.ConvertErrorType, "eventPrefix", fmt.Sprintf(" contains invalid character %q at %d", c, i))
+		}
+	}
+
+	return nil
+}
+
+// assertOwner checks caller is the owner of token stored under tokenID
+// Returns the token metadata for the caller to reuse
+func assertOwner(stub shim.ChaincodeStubInterface, tokenID, caller string) (*model.ERC20Metadata, error) {
+	erc20, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	if erc20.IsOwnershipRenounced() {
+		return nil, model.NewCustomError(model.AuthorizeErrorType, caller, "ownership of "+tokenID+" is renounced")
+	}
+	if *erc20.GetOwner() != caller {
+		return nil, model.NewCustomError(model.AuthorizeErrorType, caller, "caller is not the owner of "+tokenID)
+	}
+
+	err = recordOwnerActivity(stub, erc20)
+	if err != nil {
+		return nil, err
+	}
+
+	return erc20, nil
+}
+
+// assertMinter checks caller is the owner or a minter of token stored under tokenID
+// Returns the token metadata for the caller to reuse
+func assertMinter(stub shim.ChaincodeStubInterface, tokenID, caller string) (*model.ERC20Metadata, error) {
+	erc20, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	if erc20.IsOwnershipRenounced() {
+		return nil, model.NewCustomError(model.AuthorizeErrorType, caller, "ownership of "+tokenID+" is renounced")
+	}
+	if *erc20.GetOwner() != caller && !erc20.IsMinter(caller) {
+		return nil, model.NewCustomError(model.AuthorizeErrorType, caller, "caller is not a minter of "+tokenID)
+	}
+
+	if *erc20.GetOwner() == caller {
+		err = recordOwnerActivity(stub, erc20)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return erc20, nil
+}
+
+// saveMetadata checks the invariants of erc20 and saves it under tokenID
+// A handler bug cannot persist a corrupt metadata, the transaction fails with 500 instead
+// Owner may be empty only because of renounceOwnership (RenouncedOwner), which leaves no minters
+func saveMetadata(stub shim.ChaincodeStubInterface, tokenID string, erc20 *model.ERC20Metadata) error {
+	invalid := func(message string) error {
+		return model.NewCustomError(model.ValidateErrorType, "erc20Metadata", message)
+	}
+
+	if *erc20.GetID() != tokenID {
+		return invalid(fmt.Sprintf("id %s does not match tokenID %s", *erc20.GetID(), tokenID))
+	}
+	if len(*erc20.GetName()) == 0 {
+		return invalid("name cannot be empty")
+	}
+	if len(*erc20.GetSymbol()) == 0 {
+		return invalid("symbol cannot be empty")
+	}
+	if erc20.IsOwnershipRenounced() && len(erc20.GetMinters()) != 0 {
+		return invalid("renounced token cannot have minters")
+	}
+	if erc20.GetTotalSupply() == nil || erc20.GetTotalSupply().Sign() < 0 {
+		return invalid("totalSupply must be a non-negative integer")
+	}
+	if erc20.GetReserveSupply().Sign() < 0 {
+		return invalid("reserveSupply cannot be negative")
+	}
+	if *erc20.GetDecimals() > model.MaxDecimals {
+		return invalid(fmt.Sprintf("decimals cannot be larger than %d", model.MaxDecimals))
+	}
+	if len(erc20.BackupOwner) != 0 && erc20.BackupOwner == *erc20.GetOwner() {
+		return invalid("backupOwner cannot be the owner")
+	}
+	if !model.IsKnownStandard(erc20.GetStandard()) {
+		return invalid("unknown standard " + erc20.GetStandard())
+	}
+
+	return repository.SaveERC20Metadata(stub, tokenID, erc20)
+}
+
+// getTxSeconds returns the unix seconds of transaction timestamp
+func getTxSeconds(stub shim.ChaincodeStubInterface) (int64, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, "txTimestamp", err.Error())
+	}
+
+	return txTimestamp.GetSeconds(), nil
+}
+
+// markAccountCreated records the account-created marker of address
+// only when the address doesn't have one yet
+func markAccountCreated(stub shim.ChaincodeStubInterface, address string) error {
+	accountInfo, err := repository.GetAccountInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	if accountInfo != nil {
+		return nil
+	}
+
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return err
+	}
+
+	return repository.SaveAccountInfo(stub, model.NewAccountInfo(address, txSeconds, stub.GetTxID()))
+}
+
+// transfer moves amount from sender to recipient checking the sender's cooldown & rate limit,
+// the recipient is credited through creditRecipient like every other credit (without event)
+// Returns the sender's & recipient's result balance
+//
+// Fabric validates the read set at commit (MVCC), a transaction is invalidated with MVCC_READ_CONFLICT
+// when a key it read is written by an earlier transaction of the same block
+// read set - config/tokenID, config/circuitBreaker, token/{tokenID}, balance/{sender}, balance/{recipient}, account/{recipient},
+// ratelimit/{sender}/{window}, cooldown/{sender}, label/{recipient} & allowed/{recipient} (only when enabled)
+// write set - balance/{sender}, balance/{recipient}, txlog/{sender}/{txID}, txlog/{recipient}/{txID},
+// account/{recipient} (only when new), ratelimit/{sender}/{window} & cooldown/{sender} (only when enabled)
+// So concurrent transfers of one sender (or to one recipient) conflict, and so do they with metadata updates (e.g. mint)
+func transfer(stub shim.ChaincodeStubInterface, senderAddress, recipientAddress string, amount *big.Int) (*big.Int, *big.Int, error) {
+	// check the sender's cooldown & rate limit
+	err := checkSenderLimits(stub, senderAddress, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// debit the sender (sender's result amount cannot be negative)
+	senderAmount, err := repository.GetBalance(stub, senderAddress, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	senderBalance, err := util.SubBigBalance("sender's balance", senderAmount, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = repository.SaveBalance(stub, senderAddress, senderBalance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// credit the recipient, moving to oneself credits the debited balance
+	var writtenBalance *big.Int
+	if senderAddress == recipientAddress {
+		writtenBalance = senderBalance
+	}
+	recipientBalance, err := creditRecipient(stub, senderAddress, recipientAddress, amount, writtenBalance, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if senderAddress == recipientAddress {
+		senderBalance = recipientBalance
+	}
+
+	return senderBalance, recipientBalance, nil
+}
+
+// checkRecipient checks recipient can be credited amount: it cannot be the burn address
+// (crediting it would keep the amount in supply), it must be verified for amounts above the threshold and in the whitelist
+func checkRecipient(stub shim.ChaincodeStubInterface, recipientAddress string, amount *big.Int) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	if erc20Metadata.IsBurnAddress(recipientAddress) {
+		return model.NewStatusError(model.StatusBadRequest, "recipient cannot be the burn address")
+	}
+
+	// check the recipient is verified for amounts above the threshold
+	err = checkRecipientLabel(stub, recipientAddress, amount)
+	if err != nil {
+		return err
+	}
+
+	// check the recipient is in the whitelist
+	return checkRecipientAllowed(stub, recipientAddress)
+}
+
+// creditRecipient increases the balance of recipient by amount moved from sender, every credit of
+// transfer, transferFrom, batch, mint & escrow claim goes through it, so none skips checkRecipient.
+// It marks the recipient account created and indexes the movement in txlog (without event),
+// index is the movement of the transaction (see repository.SaveTransferLog), 0 for a single movement
+// writtenBalance is the balance of recipient already written in the transaction (the debited sender moving to oneself),
+// as GetState doesn't read the writes of the same transaction, nil reads the balance
+// Returns the recipient's result balance
+func creditRecipient(stub shim.ChaincodeStubInterface, senderAddress, recipientAddress string, amount, writtenBalance *big.Int, index int) (*big.Int, error) {
+	err := checkRecipient(stub, recipientAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// credit recipient
+	recipientAmount := writtenBalance
+	if recipientAmount == nil {
+		recipientAmount, err = repository.GetBalance(stub, recipientAddress, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	recipientBalance := util.AddBigBalance(recipientAmount, amount)
+	err = repository.SaveBalance(stub, recipientAddress, recipientBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	// mark the recipient account created if it is new
+	err = markAccountCreated(stub, recipientAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// index the movement for the sender & recipient
+	err = repository.SaveTransferLog(stub, senderAddress, recipientAddress, amount, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return recipientBalance, nil
+}
+
+// eventDecimals returns the decimals of token carried by the transfer events
+// and whether owner enabled the events of transfers, the metadata is read once
+func eventDecimals(stub shim.ChaincodeStubInterface) (uint8, bool, error) {
+	erc20, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return erc20.Decimals, erc20.IsEmitEvents(), nil
+}
+
+// emitTransferEvent emits the transfer event unless owner disabled events of the token
+func emitTransferEvent(stub shim.ChaincodeStubInterface, senderAddress, recipientAddress string, amount *big.Int) error {
+	decimals, emitEvents, err := eventDecimals(stub)
+	if err != nil || !emitEvents {
+		return err
+	}
+
+	return repository.EmitTransferEvent(stub, senderAddress, recipientAddress, amount, decimals)
+}
+
+// emitCategorizedTransferEvent emits the transfer event with category unless owner disabled events of the token
+func emitCategorizedTransferEvent(stub shim.ChaincodeStubInterface, senderAddress, recipientAddress string, amount *big.Int, category string) error {
+	decimals, emitEvents, err := eventDecimals(stub)
+	if err != nil || !emitEvents {
+		return err
+	}
+
+	return repository.EmitCategorizedTransferEvent(stub, senderAddress, recipientAddress, amount, decimals, category)
+}
+
+// moveBalance moves amount from sender's balance to recipient's balance without the checks of creditRecipient
+// (clawback is the owner's override of them)
+// Returns the sender's & recipient's result balance
+func moveBalance(stub shim.ChaincodeStubInterface, senderAddress, recipientAddress string, amount *big.Int) (*big.Int, *big.Int, error) {
+	// get sender amount
+	senderAmount, err := repository.GetBalance(stub, senderAddress, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// calculate amount (sender's result amount cannot be negative)
+	senderResultAmount, err := util.SubBigBalance("sender's balance", senderAmount, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// moving to oneself doesn't change the balance
+	// (GetState doesn't read the writes of the same transaction, so saving twice would mint)
+	if senderAddress == recipientAddress {
+		return senderAmount, senderAmount, nil
+	}
+
+	// get recipient amount
+	recipientAmount, err := repository.GetBalance(stub, recipientAddress, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	recipientResultAmount := util.AddBigBalance(recipientAmount, amount)
+
+	// save the sender's & recipient's amount
+	err = repository.SaveBalance(stub, senderAddress, senderResultAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = repository.SaveBalance(stub, recipientAddress, recipientResultAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return senderResultAmount, recipientResultAmount, nil
+}
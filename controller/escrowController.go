@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// ConditionalTransfer is invoke function that debits amount from the caller into escrow
+// The recipient can claim the escrow until deadline, the caller can refund it after deadline
+// params - caller's address, recipient's address, amount of token, deadline(unix seconds)
+// Returns the escrowID (txID)
+func (cc *Controller) ConditionalTransfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	callerAddress, recipientAddress, transferAmount, deadline := params[0], params[1], params[2], params[3]
+
+	// check amount is integer & positive
+	transferAmountBig, err := util.ConvertToBigPositive("transferAmount", transferAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// recipient cannot be empty or the caller
+	if len(recipientAddress) == 0 || recipientAddress == callerAddress {
+		return util.BadRequest("recipient cannot be empty or the caller")
+	}
+
+	// deadline must be in the future
+	deadlineInt, err := util.ParseDecimalInt("deadline", deadline, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if deadlineInt <= txSeconds {
+		return util.BadRequest("deadline must be in the future")
+	}
+
+	// check the caller's cooldown & rate limit
+	err = checkSenderLimits(stub, callerAddress, transferAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// fail early when the recipient couldn't claim, the claim checks the recipient again
+	err = checkRecipient(stub, recipientAddress, transferAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// debit the caller into escrow (caller's result amount cannot be negative)
+	callerAmount, err := repository.GetBalance(stub, callerAddress, false)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	callerResultAmount, err := util.SubBigBalance("caller's balance", callerAmount, transferAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.SaveBalance(stub, callerAddress, callerResultAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save escrow under txID
+	escrow := model.NewEscrow(stub.GetTxID(), callerAddress, recipientAddress, transferAmountBig, deadlineInt)
+	err = repository.SaveEscrow(stub, escrow)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit escrow event
+	err = repository.EmitEscrowEvent(stub, escrow)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// count the volume of circuit breaker when locked, claim & refund only release it
+	err = recordTransferVolume(stub, transferAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(escrow.ID))
+}
+
+// Claim is invoke function that credits the locked escrow to the recipient until deadline
+// params - escrowID, caller's address(recipient)
+func (cc *Controller) Claim(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	escrowID, callerAddress := params[0], params[1]
+
+	// only recipient can claim until deadline
+	escrow, err := getLockedEscrow(stub, escrowID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if escrow.Recipient != callerAddress {
+		return util.ErrorResponse(model.NewCustomError(model.AuthorizeErrorType, callerAddress, "caller is not the recipient of escrow "+escrowID))
+	}
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if txSeconds > escrow.Deadline {
+		return util.Conflict("escrow " + escrowID + " cannot be claimed after deadline")
+	}
+
+	// the claim is the transfer from sender, so the recipient is credited like transfer
+	_, err = creditRecipient(stub, escrow.Sender, escrow.Recipient, escrow.Amount, nil, 0)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return closeEscrow(stub, escrow, model.EscrowClaimed)
+}
+
+// Refund is invoke function that credits the locked escrow back to the sender after deadline
+// params - escrowID, caller's address(sender)
+func (cc *Controller) Refund(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	escrowID, callerAddress := params[0], params[1]
+
+	// only sender can refund after deadline
+	escrow, err := getLockedEscrow(stub, escrowID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if escrow.Sender != callerAddress {
+		return util.ErrorResponse(model.NewCustomError(model.AuthorizeErrorType, callerAddress, "caller is not the sender of escrow "+escrowID))
+	}
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if txSeconds <= escrow.Deadline {
+		return util.Conflict("escrow " + escrowID + " cannot be refunded until deadline")
+	}
+
+	// the refund returns the sender's own amount, it isn't a transfer and skips the recipient checks
+	curBalance, err := repository.GetBalance(stub, escrow.Sender, true)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.SaveBalance(stub, escrow.Sender, util.AddBigBalance(curBalance, escrow.Amount))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return closeEscrow(stub, escrow, model.EscrowRefunded)
+}
+
+// getLockedEscrow returns the escrow which is neither claimed nor refunded
+func getLockedEscrow(stub shim.ChaincodeStubInterface, escrowID string) (*model.Escrow, error) {
+	escrow, err := repository.GetEscrow(stub, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow == nil {
+		return nil, model.NewStatusError(model.StatusNotFound, "escrow "+escrowID+" is not found")
+	}
+	if escrow.Status != model.EscrowLocked {
+		return nil, model.NewStatusError(model.StatusConflict, "escrow "+escrowID+" is already "+escrow.Status)
+	}
+
+	return escrow, nil
+}
+
+// closeEscrow closes the released escrow with status
+func closeEscrow(stub shim.ChaincodeStubInterface, escrow *model.Escrow, status string) sc.Response {
+	// close escrow (kept for audit)
+	escrow.Status = status
+	err := repository.SaveEscrow(stub, escrow)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit escrow event
+	err = repository.EmitEscrowEvent(stub, escrow)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(status + " success"))
+}
+
+// sumLockedEscrows returns the sum of escrows not yet claimed or refunded
+// The amounts are debited from the senders, so they are in no balance
+func sumLockedEscrows(stub shim.ChaincodeStubInterface) (*big.Int, error) {
+	escrowed := big.NewInt(0)
+	bookmark := ""
+	for {
+		escrows, nextBookmark, err := repository.GetEscrowPage(stub, balanceScanPageSize, bookmark)
+		if err != nil {
+			return nil, err
+		}
+		for _, escrow := range escrows {
+			if escrow.Status == model.EscrowLocked {
+				escrowed = util.AddBigBalance(escrowed, escrow.Amount)
+			}
+		}
+
+		if len(nextBookmark) == 0 {
+			return escrowed, nil
+		}
+		bookmark = nextBookmark
+	}
+}
@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
+	"github.com/erc20/model"
 	"github.com/erc20/repository"
 	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -12,57 +16,114 @@ import (
 
 // Transfer is invoke function that moves amount token
 // from the caller's address to recipient
+// Returns the receipt (see model.TransferReceipt) as payload
+// The transfer to the burn address (see SetBurnAddress) burns the amount and emits the burn event,
+// toBalance of the receipt is 0
 // params - caller's address, recipient's address, amount of token
 func (cc *Controller) Transfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of params is 3
 	if len(params) != 3 {
-		return shim.Error("incorrect number of parameters")
+		return util.BadRequest("incorrect number of parameters")
 	}
 
 	callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2]
 
+	return transferResponse(stub, callerAddress, recipientAddress, transferAmount, "")
+}
+
+// transferResponse moves amount from caller to recipient and returns the receipt,
+// the transfer of categorizedTransfer (category is not empty) is indexed & emitted with category
+func transferResponse(stub shim.ChaincodeStubInterface, callerAddress, recipientAddress, transferAmount, category string) sc.Response {
+
+	// caller, recipient & amount are required, whitespace only counts as empty
+	if len(strings.TrimSpace(callerAddress)) == 0 {
+		return util.BadRequest("caller address is required")
+	}
+	if len(strings.TrimSpace(recipientAddress)) == 0 {
+		return util.BadRequest("recipient address is required")
+	}
+	if len(strings.TrimSpace(transferAmount)) == 0 {
+		return util.BadRequest("transfer amount is required")
+	}
+
 	// check amount is integer & positive
-	transferAmountInt, err := util.ConvertToPositive("transferAmount", transferAmount)
+	transferAmountBig, err := util.ConvertToBigPositive("transferAmount", transferAmount)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
-	// get caller amount
-	callerAmountInt, err := repository.GetBalance(stub, callerAddress, false)
+	// the transfer to the burn address burns the caller's amount instead of crediting it
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
-	// get recipient amount
-	recipientAmountInt, err := repository.GetBalance(stub, recipientAddress, true)
-	if err != nil {
-		return shim.Error(err.Error())
+	// no balance can be positive without supply, so a transfer means corrupt state
+	if erc20Metadata.GetTotalSupply().Sign() == 0 {
+		return util.Conflict("totalSupply of " + erc20Metadata.ID + " is zero, transfer is rejected")
 	}
 
-	// calculate amount
-	callerResultAmount := *callerAmountInt - *transferAmountInt
-	recipientResultAmount := *recipientAmountInt + *transferAmountInt
+	var callerBalance, recipientBalance *big.Int
+	if erc20Metadata.IsBurnAddress(recipientAddress) {
+		callerBalance, err = burnToAddress(stub, erc20Metadata, callerAddress, transferAmountBig)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		recipientBalance = big.NewInt(0)
+	} else {
+		// move the caller's amount to recipient
+		callerBalance, recipientBalance, err = transfer(stub, callerAddress, recipientAddress, transferAmountBig)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+
+		// emit transfer event (skipped when events are disabled)
+		if len(category) == 0 {
+			err = emitTransferEvent(stub, callerAddress, recipientAddress, transferAmountBig)
+		} else {
+			err = emitCategorizedTransferEvent(stub, callerAddress, recipientAddress, transferAmountBig, category)
+		}
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
 
-	// check callerReuslt Amount is positive
-	if callerResultAmount < 0 {
-		return shim.Error("caller's balance is not sufficient")
+	// index the movement under its category
+	if len(category) != 0 {
+		err = repository.SaveCategoryLog(stub, category, callerAddress, recipientAddress, transferAmountBig)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
 	}
 
-	// save the caller's & recipient's amount
-	err = repository.SaveBalance(stub, callerAddress, strconv.Itoa(callerResultAmount))
+	// count the volume of circuit breaker, last since tripping replaces the event
+	err = recordTransferVolume(stub, transferAmountBig)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
-	err = repository.SaveBalance(stub, recipientAddress, strconv.Itoa(recipientResultAmount))
+
+	// return the receipt of transfer
+	txSeconds, err := getTxSeconds(stub)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
+	}
+	receipt := model.TransferReceipt{
+		Note:        model.TransferReceiptNote,
+		TxID:        stub.GetTxID(),
+		From:        callerAddress,
+		To:          recipientAddress,
+		Amount:      transferAmountBig,
+		FromBalance: callerBalance,
+		ToBalance:   recipientBalance,
+		Timestamp:   txSeconds,
+	}
+	receiptBytes, err := json.Marshal(receipt)
+	if err != nil {
+		return shim.Error("failed to Marshal receipt, error: " + err.Error())
 	}
 
-	// emit transfer event
-	err = repository.EmitTransferEvent(stub, callerAddress, recipientAddress, *transferAmountInt)
-
-	return shim.Success([]byte("transfer Success"))
+	return shim.Success(receiptBytes)
 }
 
 // Approve is invoke function that Sets amount as the allowance
@@ -72,27 +133,43 @@ func (cc *Controller) Approve(stub shim.ChaincodeStubInterface, params []string)
 
 	// check the number of params is 3
 	if len(params) != 3 {
-		return shim.Error("incorrect number of parameters")
+		return util.BadRequest("incorrect number of parameters")
 	}
 
 	ownerAddress, spenderAddress, allowanceAmount := params[0], params[1], params[2]
 
-	// check amount is integer & positive
-	allowanceAmountInt, err := util.ConvertToPositive("AllowanceAmount", allowanceAmount)
+	// owner cannot approve itself
+	err := validateAllowanceParties(ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check amount is integer & not negative (zero revokes allowance)
+	allowanceAmountBig, err := util.ParseBigBalance("AllowanceAmount", allowanceAmount)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
-	// save allowance amount
-	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, allowanceAmount)
+	// in strict mode, allowance cannot exceed the owner's balance
+	err = validateAllowanceCap(stub, ownerAddress, spenderAddress, allowanceAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save allowance amount, which replaces the recurring allowance
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, util.FormatBigBalance(allowanceAmountBig))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.DeleteRecurringAllowance(stub, ownerAddress, spenderAddress)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	// emit approval event
-	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, *allowanceAmountInt)
+	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, allowanceAmountBig)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	return shim.Success([]byte("approve success"))
@@ -100,51 +177,192 @@ func (cc *Controller) Approve(stub shim.ChaincodeStubInterface, params []string)
 
 // TransferFrom is invoke function that Moves amount of tokens from sender(owner) to recipient
 // using allowance of spender
+// Fabric keeps only the last event of a transaction, so one transferFrom event carrying
+// the transfer and the remaining allowance replaces the separate transfer & approval events
 // parmas - owner's address, spender's address, recipient's address, amount of token
 func (cc *Controller) TransferFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of parmas is 4
 	if len(params) != 4 {
-		return shim.Error("incorrect number of params")
+		return util.BadRequest("incorrect number of params")
 	}
 
 	ownerAddress, spenderAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
 
 	// check amount is integer & positive
-	transferAmountInt, err := util.ConvertToPositive("TransferAmount", transferAmount)
+	transferAmountBig, err := util.ConvertToBigPositive("transferAmount", transferAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// decrease allowance amount without emitting approval event
+	remainingAllowance, err := cc.spendAllowance(stub, ownerAddress, spenderAddress, transferAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// transfer from owner to recipient
+	_, _, err = transfer(stub, ownerAddress, recipientAddress, transferAmountBig)
+	if err != nil {
+		return util.StatusResponse(util.ErrorStatus(err), "failed to transfer, error: "+err.Error())
+	}
+
+	// emit transferFrom event (skipped when events are disabled)
+	decimals, emitEvents, err := eventDecimals(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if emitEvents {
+		err = repository.EmitTransferFromEvent(stub, ownerAddress, spenderAddress, recipientAddress, transferAmountBig, remainingAllowance, decimals)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// count the volume of circuit breaker, last since tripping replaces the event
+	err = recordTransferVolume(stub, transferAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("transferFrom success"))
+}
+
+// RevokeAllAllowances is invoke function that sets every allowance over the owner tokens to zero
+// (e.g. when the owner's wallet is compromised), one allowancesRevoked event lists the revoked spenders
+// params - owner's address
+// Returns the number of revoked allowances
+func (cc *Controller) RevokeAllAllowances(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	ownerAddress := params[0]
+
+	// owner cannot be empty
+	if len(ownerAddress) == 0 {
+		return util.BadRequest("owner cannot be empty")
+	}
+
+	// get all allowances of owner
+	approvals, err := repository.GetApprovalList(stub, ownerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// set non-zero allowances to zero & stop recurring allowances
+	spenders := []string{}
+	for _, approval := range approvals {
+		err = repository.DeleteRecurringAllowance(stub, ownerAddress, approval.Spender)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		if approval.Allowance.Sign() == 0 {
+			continue
+		}
+		err = repository.SaveAllowance(stub, ownerAddress, approval.Spender, "0")
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		spenders = append(spenders, approval.Spender)
+	}
+
+	// emit one event for all revoked allowances
+	if len(spenders) > 0 {
+		err = repository.EmitAllowancesRevokedEvent(stub, ownerAddress, spenders)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	return shim.Success([]byte(strconv.Itoa(len(spenders))))
+}
+
+// validateAllowanceParties checks owner & spender of allowance are distinct,
+// approving itself is meaningless and usually a client bug
+func validateAllowanceParties(ownerAddress, spenderAddress string) error {
+	if ownerAddress == spenderAddress {
+		return model.NewStatusError(model.StatusBadRequest, "owner cannot approve itself as spender, "+ownerAddress)
+	}
+	return nil
+}
+
+// validateAllowanceCap checks allowance doesn't exceed the owner's current balance when owner enabled strict approvals
+// Such approvals are usually mistakes or phishing, but ERC20 allows them, so the default lenient mode does too
+// Lowering the current allowance is always allowed, so decreaseAllowance never fails the check
+func validateAllowanceCap(stub shim.ChaincodeStubInterface, ownerAddress, spenderAddress string, allowance *big.Int) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	if !erc20Metadata.StrictApprovals {
+		return nil
+	}
+
+	currentBytes, err := repository.GetAllowanceBytes(stub, ownerAddress, spenderAddress, true)
+	if err != nil {
+		return err
+	}
+	current, err := util.ParseBigBalance("allowance", string(currentBytes))
+	if err != nil {
+		return err
+	}
+	if allowance.Cmp(current) <= 0 {
+		return nil
+	}
+
+	balance, err := repository.GetBalance(stub, ownerAddress, true)
 	if err != nil {
-		return shim.Error(err.Error())
+		return err
+	}
+	if allowance.Cmp(balance) > 0 {
+		return model.NewStatusError(model.StatusConflict, "allowance "+allowance.String()+" exceeds the balance "+balance.String()+" of "+ownerAddress)
 	}
 
+	return nil
+}
+
+// spendAllowance decreases the allowance of spender over the owner tokens by amount
+// The allowance cannot be exceeded, returns the remaining allowance
+func (cc *Controller) spendAllowance(stub shim.ChaincodeStubInterface, ownerAddress, spenderAddress string, amount *big.Int) (*big.Int, error) {
 	// get allowance
 	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
 	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error: " + allowanceResponse.GetMessage())
+		return nil, model.NewStatusError(allowanceResponse.GetStatus(), "failed to get allowance, error: "+allowanceResponse.GetMessage())
 	}
 
 	// convert allowance response paylaod to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
+	allowance, err := util.ParseBigBalance("allowance", string(allowanceResponse.GetPayload()))
 	if err != nil {
-		return shim.Error("allowance must be positive")
+		return nil, err
 	}
 
-	// transfer from owner to recipient
-	transferResponse := cc.Transfer(stub, []string{ownerAddress, recipientAddress, transferAmount})
-	if transferResponse.GetStatus() >= 400 {
-		return shim.Error("failed to transfer, error: " + transferResponse.GetMessage())
+	// the allowance above is reset in a new period of recurring allowance, save the period
+	recurring, err := dueRecurringAllowance(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return nil, err
+	}
+	if recurring != nil {
+		err = repository.SaveRecurringAllowance(stub, ownerAddress, spenderAddress, recurring)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// decrease allowance amount
-	approveAmountInt := allowanceInt - *transferAmountInt
-	approveAmount := strconv.Itoa(approveAmountInt)
+	// decrease allowance amount (allowance cannot be negative)
+	remainingAllowance, err := util.SubBigBalance("allowance", allowance, amount)
+	if err != nil {
+		return nil, err
+	}
 
-	// approve amount of tokens transfered
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, approveAmount})
-	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to approve, error: " + approveResponse.GetMessage())
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, util.FormatBigBalance(remainingAllowance))
+	if err != nil {
+		return nil, err
 	}
 
-	return shim.Success([]byte("transferFrom success"))
+	return remainingAllowance, nil
 }
 
 // TransferOtherToken is invoke function that Moves amount other chaincode tokens
@@ -154,7 +372,7 @@ func (cc *Controller) TransferOtherToken(stub shim.ChaincodeStubInterface, param
 
 	// check the number of parmas is 4
 	if len(params) != 4 {
-		return shim.Error("incorrect number of params")
+		return util.BadRequest("incorrect number of params")
 	}
 
 	chaincodeName, callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
@@ -168,7 +386,7 @@ func (cc *Controller) TransferOtherToken(stub shim.ChaincodeStubInterface, param
 	// transfer other chaincode token
 	transferResponse := stub.InvokeChaincode(chaincodeName, args, channel)
 	if transferResponse.GetStatus() >= 400 {
-		return shim.Error(fmt.Sprintf("failed to transfer %s, error: %s", chaincodeName, transferResponse.GetMessage()))
+		return util.StatusResponse(transferResponse.GetStatus(), fmt.Sprintf("failed to transfer %s, error: %s", chaincodeName, transferResponse.GetMessage()))
 	}
 
 	return shim.Success([]byte("transfer other token success"))
@@ -180,37 +398,42 @@ func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params
 
 	// check the number of parmas is 3
 	if len(params) != 3 {
-		return shim.Error("incorrect number of params")
+		return util.BadRequest("incorrect number of params")
 	}
 
 	ownerAddress, spenderAddress, increaseAmount := params[0], params[1], params[2]
 
+	// owner cannot approve itself
+	err := validateAllowanceParties(ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
 	// check amount is integer & positive
-	increaseAmountInt, err := util.ConvertToPositive("IncreaseAmount", increaseAmount)
+	increaseAmountBig, err := util.ConvertToBigPositive("IncreaseAmount", increaseAmount)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	// get allowance
 	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
 	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error: " + allowanceResponse.GetMessage())
+		return util.StatusResponse(allowanceResponse.GetStatus(), "failed to get allowance, error: "+allowanceResponse.GetMessage())
 	}
 
 	// convert allowance response paylaod to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
+	allowance, err := util.ParseBigBalance("allowance", string(allowanceResponse.GetPayload()))
 	if err != nil {
-		return shim.Error("allowance must be positive")
+		return util.ErrorResponse(err)
 	}
 
-	// increase allowance
-	resultAmountInt := allowanceInt + *increaseAmountInt
-	resultAmount := strconv.Itoa(resultAmountInt)
+	// increase allowance (big integer, the sum cannot overflow)
+	resultAmount := util.FormatBigBalance(util.AddBigBalance(allowance, increaseAmountBig))
 
 	// call approve
 	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, resultAmount})
 	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to approve allowance, error: " + approveResponse.GetMessage())
+		return util.StatusResponse(approveResponse.GetStatus(), "failed to approve allowance, error: "+approveResponse.GetMessage())
 	}
 
 	return shim.Success([]byte("increaseAllowance success"))
@@ -222,93 +445,717 @@ func (cc *Controller) DecreaseAllowance(stub shim.ChaincodeStubInterface, params
 
 	// check the number of params is 3
 	if len(params) != 3 {
-		return shim.Error("incorrect number of params")
+		return util.BadRequest("incorrect number of params")
 	}
 
 	ownerAddress, spenderAddress, decreaseAmount := params[0], params[1], params[2]
 
 	// check amount is integer & positive
-	decreaseAmountInt, err := util.ConvertToPositive("DecreaseAmount", decreaseAmount)
+	decreaseAmountBig, err := util.ConvertToBigPositive("DecreaseAmount", decreaseAmount)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	// get allowance
 	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
 	if allowanceResponse.Status >= 400 {
-		return shim.Error("failed to get allowance, error: " + allowanceResponse.GetMessage())
+		return util.StatusResponse(allowanceResponse.GetStatus(), "failed to get allowance, error: "+allowanceResponse.GetMessage())
 	}
 
 	// convert allowance response payload to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
+	allowance, err := util.ParseBigBalance("allowance", string(allowanceResponse.GetPayload()))
 	if err != nil {
-		return shim.Error("allowance must be positive")
+		return util.ErrorResponse(err)
 	}
 
-	// calculate allowance (allowance cannot be negative!!)
-	resultAmountInt := allowanceInt - *decreaseAmountInt
-	if resultAmountInt < 0 {
-		resultAmountInt = 0
+	// calculate allowance (decreasing by more than the allowance revokes it)
+	resultAmountBig, err := util.SubBigBalance("allowance", allowance, decreaseAmountBig)
+	if err != nil {
+		resultAmountBig = big.NewInt(0)
 	}
-	resultAmount := strconv.Itoa(resultAmountInt)
+	resultAmount := util.FormatBigBalance(resultAmountBig)
 
 	// call approve
 	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, resultAmount})
 	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to approve allowance, error: " + approveResponse.GetMessage())
+		return util.StatusResponse(approveResponse.GetStatus(), "failed to approve allowance, error: "+approveResponse.GetMessage())
 	}
 
 	return shim.Success([]byte("decreaseAllowance success"))
 }
 
+// ApproveCompareAndSet is invoke function that sets spender's allowance to new amount
+// only if the current allowance equals expected amount, so a spender who spent the allowance
+// in between (the approve front-running) makes it fail instead of getting both amounts
+// The approval event is emitted only on success
+// params - owner's address, spender's address, expected current amount, new amount
+func (cc *Controller) ApproveCompareAndSet(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	ownerAddress, spenderAddress, expectedAmount, newAmount := params[0], params[1], params[2], params[3]
+
+	// check expected amount is integer & not negative
+	expectedAmountBig, err := util.ParseBigBalance("ExpectedAmount", expectedAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// get allowance
+	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
+	if allowanceResponse.GetStatus() >= 400 {
+		return util.StatusResponse(allowanceResponse.GetStatus(), "failed to get allowance, error: "+allowanceResponse.GetMessage())
+	}
+
+	// convert allowance response payload to allowance data
+	allowance, err := util.ParseBigBalance("allowance", string(allowanceResponse.GetPayload()))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// the current allowance must be the expected one
+	if allowance.Cmp(expectedAmountBig) != 0 {
+		return util.Conflict("current allowance " + allowance.String() + " does not match expected " + expectedAmountBig.String())
+	}
+
+	// call approve
+	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, newAmount})
+	if approveResponse.GetStatus() >= 400 {
+		return util.StatusResponse(approveResponse.GetStatus(), "failed to approve allowance, error: "+approveResponse.GetMessage())
+	}
+
+	return shim.Success([]byte("approveCompareAndSet success"))
+}
+
+// maxMintRequests is the number of the latest mint requests stored for retries,
+// a request older than that many newer requests is evicted and would be minted again
+const maxMintRequests = 1000
+
+// maxMintRequestIDLength is the max length of mintRequestId
+const maxMintRequestIDLength = 64
+
 // Mint is invoke function That Creates amount tokens and assign them to address, increasing the total supply
-// params - tokenName, recipient's addresss, amount
+// Only owner or minters can mint
+// With mintRequestId, a retry of the processed request returns the prior outcome (see model.MintRequest)
+// without minting again, only the latest maxMintRequests requests are stored
+// read & write set - token/{tokenID}, balance/{recipient}, account/{recipient} (written only when new), config/tokenID (read only),
+// txlog/admin/{txID} & txlog/{recipient}/{txID} (written only), label/{recipient} & allowed/{recipient} (read only, when enabled),
+// mintrequest/{requestID}, mintslot/{slot} & config/mintRequestCursor (only with mintRequestId),
+// concurrent mints conflict on token/{tokenID} (see transfer for MVCC)
+// params - tokenID, caller's address, recipient's addresss, amount, [mintRequestId]
 func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
+	// check the number of params is 4 or 5
+	if len(params) != 4 && len(params) != 5 {
+		return util.BadRequest("incoreect number of parmas")
+	}
+
+	tokenID, callerAddress, address, mintAmount := params[0], params[1], params[2], params[3]
+	requestID := ""
+	if len(params) == 5 {
+		requestID = params[4]
+		err := util.ValidateText("mintRequestId", requestID, maxMintRequestIDLength)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// amount must be positive
+	mintAmountBig, err := util.ConvertToBigPositive("mintAmount", mintAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner or minters can mint
+	erc20Metadata, err := assertMinter(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// a processed request returns the prior outcome
+	if len(requestID) != 0 {
+		mintRequest, err := repository.GetMintRequest(stub, requestID)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		if mintRequest != nil {
+			if mintRequest.Caller != callerAddress || mintRequest.Recipient != address || mintRequest.Amount.Cmp(mintAmountBig) != 0 {
+				return util.Conflict("mint request " + requestID + " was processed with different params in " + mintRequest.TxID)
+			}
+			return mintRequestResponse(mintRequest)
+		}
+	}
+
+	// increase TotalSupply & the recipient balance
+	err = mint(stub, tokenID, erc20Metadata, address, mintAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit transfer event (skipped when events are disabled)
+	err = emitTransferEvent(stub, "admin", address, mintAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	if len(requestID) == 0 {
+		return shim.Success([]byte("mint success"))
+	}
+
+	// store the outcome of request for retries
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	mintRequest := model.NewMintRequest(requestID, stub.GetTxID(), callerAddress, address, mintAmountBig, txSeconds)
+	err = repository.SaveMintRequest(stub, mintRequest, maxMintRequests)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return mintRequestResponse(mintRequest)
+}
+
+// mint increases TotalSupply of erc20 and credits amount to address through creditRecipient
+func mint(stub shim.ChaincodeStubInterface, tokenID string, erc20Metadata *model.ERC20Metadata, address string, amount *big.Int) error {
+	// increase TotalSupply
+	erc20Metadata.TotalSupply = util.AddBigBalance(erc20Metadata.GetTotalSupply(), amount)
+	err := saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return err
+	}
+
+	// increase recipient balance
+	_, err = creditRecipient(stub, "admin", address, amount, nil, 0)
+	return err
+}
+
+// mintRequestResponse returns the outcome of mint request as payload
+func mintRequestResponse(mintRequest *model.MintRequest) sc.Response {
+	response, err := json.Marshal(mintRequest)
+	if err != nil {
+		return shim.Error("failed to Marshal mintRequest, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// Burn is invoke function that destroys amount tokens from address, decreasing the total supply
+// read & write set - token/{tokenID}, balance/{holder}, config/tokenID (read only), concurrent burns conflict on token/{tokenID} (see transfer for MVCC)
+// params - tokenID, holder's address, amount
+func (cc *Controller) Burn(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
 	// check the number of params is 3
 	if len(params) != 3 {
-		return shim.Error("incoreect number of parmas")
+		return util.BadRequest("incorrect number of params")
 	}
 
-	tokenName, address, mintAmount := params[0], params[1], params[2]
+	tokenID, address, burnAmount := params[0], params[1], params[2]
 
 	// amount must be positive
-	mintAmountInt, err := util.ConvertToPositive("mintAmount", mintAmount)
+	burnAmountBig, err := util.ConvertToBigPositive("burnAmount", burnAmount)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
-	// increase TotalSupply
-	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	// destroy holder's amount
+	_, err = burn(stub, tokenID, address, burnAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit transfer event (skipped when events are disabled)
+	err = emitTransferEvent(stub, address, "admin", burnAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("burn success"))
+}
+
+// BurnFrom is invoke function that destroys amount tokens from owner using allowance of spender
+// The remaining allowance is emitted in one transferFrom event as transferFrom does
+// params - tokenID, owner's address, spender's address, amount
+func (cc *Controller) BurnFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, ownerAddress, spenderAddress, burnAmount := params[0], params[1], params[2], params[3]
+
+	// amount must be positive
+	burnAmountBig, err := util.ConvertToBigPositive("burnAmount", burnAmount)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
-	resultTotalSupply := *erc20Metadata.GetTotalSupply() + uint64(*mintAmountInt)
-	err = repository.SaveERC20Metadata(stub, *erc20Metadata.GetName(), *erc20Metadata.GetSymbol(), *erc20Metadata.GetOwner(), resultTotalSupply)
+
+	// decrease allowance amount without emitting approval event
+	remainingAllowance, err := cc.spendAllowance(stub, ownerAddress, spenderAddress, burnAmountBig)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
-	// increase owner balance
+	// destroy owner's amount
+	_, err = burn(stub, tokenID, ownerAddress, burnAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit transferFrom event (skipped when events are disabled)
+	decimals, emitEvents, err := eventDecimals(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if emitEvents {
+		err = repository.EmitTransferFromEvent(stub, ownerAddress, spenderAddress, "admin", burnAmountBig, remainingAllowance, decimals)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	return shim.Success([]byte("burnFrom success"))
+}
+
+// burn decreases holder's balance & TotalSupply by amount (without event)
+// TotalSupply cannot be below the reserve and balance cannot be negative
+// Returns the holder's result balance
+func burn(stub shim.ChaincodeStubInterface, tokenID, address string, amount *big.Int) (*big.Int, error) {
+	// decrease TotalSupply (TotalSupply cannot be below the reserve)
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	resultSupply, err := util.SubBigBalance("totalSupply", erc20Metadata.GetTotalSupply(), amount)
+	if err != nil {
+		return nil, err
+	}
+	reserveSupply := erc20Metadata.GetReserveSupply()
+	if reserveSupply.Sign() > 0 && resultSupply.Cmp(reserveSupply) < 0 {
+		return nil, model.NewStatusError(model.StatusConflict, "burn would reduce totalSupply "+resultSupply.String()+" below the reserve "+reserveSupply.String())
+	}
+
+	// decrease holder balance (balance cannot be negative)
 	curBalance, err := repository.GetBalance(stub, address, true)
 	if err != nil {
-		return shim.Error(err.Error())
+		return nil, err
+	}
+	resultBalance, err := util.SubBigBalance("holder's balance", curBalance, amount)
+	if err != nil {
+		return nil, err
 	}
-	resultBalance := *curBalance + *mintAmountInt
-	err = repository.SaveBalance(stub, address, strconv.Itoa(resultBalance))
+	err = repository.SaveBalance(stub, address, resultBalance)
 	if err != nil {
-		return shim.Error(err.Error())
+		return nil, err
 	}
 
-	// emit transfer event
-	err = repository.EmitTransferEvent(stub, "admin", address, *mintAmountInt)
+	// save metadata with the decreased TotalSupply
+	erc20Metadata.TotalSupply = resultSupply
+	err = saveMetadata(stub, tokenID, erc20Metadata)
 	if err != nil {
-		return shim.Error(err.Error())
+		return nil, err
 	}
 
-	return shim.Success([]byte("mint success"))
+	return resultBalance, nil
 }
 
-func (cc *Controller) Burn(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	return shim.Success(nil)
+// burnToAddress burns the sender's amount transferred to the burn address of erc20Metadata
+// The sender's cooldown & rate limit apply like transfer, the burn address is never credited
+// Returns the sender's result balance
+func burnToAddress(stub shim.ChaincodeStubInterface, erc20Metadata *model.ERC20Metadata, senderAddress string, amount *big.Int) (*big.Int, error) {
+	// check the sender's cooldown & rate limit
+	err := checkSenderLimits(stub, senderAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// destroy the sender's amount
+	senderBalance, err := burn(stub, erc20Metadata.ID, senderAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// emit burn event (skipped when events are disabled)
+	if erc20Metadata.IsEmitEvents() {
+		err = repository.EmitBurnEvent(stub, senderAddress, erc20Metadata.BurnAddress, amount, erc20Metadata.Decimals)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return senderBalance, nil
+}
+
+// SetName is invoke function that changes the display name of token by owner
+// The state key of metadata stays tokenID set at Init
+// params - tokenID, caller's address, new name
+func (cc *Controller) SetName(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, name := params[0], params[1], params[2]
+
+	// name cannot be empty
+	if len(name) == 0 {
+		return util.BadRequest("name cannot be empty")
+	}
+	err := util.ValidateText("name", name, maxTokenNameLength)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can change name
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// locked metadata cannot change
+	if erc20Metadata.MetadataLocked {
+		return util.Conflict("metadata of " + tokenID + " is locked, name cannot change")
+	}
+
+	// save metadata with new name
+	oldName := *erc20Metadata.GetName()
+	erc20Metadata.Name = name
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "name", oldName, name)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setName success"))
+}
+
+// SetSymbol is invoke function that changes the symbol of token by owner
+// params - tokenID, caller's address, new symbol
+func (cc *Controller) SetSymbol(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, symbol := params[0], params[1], params[2]
+
+	// symbol cannot be empty
+	if len(symbol) == 0 {
+		return util.BadRequest("symbol cannot be empty")
+	}
+	err := util.ValidateText("symbol", symbol, maxSymbolLength)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can change symbol
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// locked metadata cannot change
+	if erc20Metadata.MetadataLocked {
+		return util.Conflict("metadata of " + tokenID + " is locked, symbol cannot change")
+	}
+
+	// save metadata with new symbol
+	oldSymbol := *erc20Metadata.GetSymbol()
+	erc20Metadata.Symbol = symbol
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "symbol", oldSymbol, symbol)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setSymbol success"))
+}
+
+// LockMetadata is invoke function that locks name & symbol of token by owner, signaling finalized token parameters
+// setName & setSymbol reject afterwards and the lock cannot be undone, decimals never change after Init anyway
+// params - tokenID, caller's address
+func (cc *Controller) LockMetadata(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress := params[0], params[1]
+
+	// only owner can lock metadata
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// metadata can be locked only once
+	if erc20Metadata.MetadataLocked {
+		return util.Conflict("metadata of " + tokenID + " is already locked")
+	}
+
+	// save metadata with the lock
+	erc20Metadata.MetadataLocked = true
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "metadataLocked", "false", "true")
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("lockMetadata success"))
+}
+
+// SetReserve is invoke function that sets the reserve supply of token by owner
+// burn cannot reduce TotalSupply below the reserve, "0" reserve disables the check
+// params - tokenID, caller's address, reserve
+func (cc *Controller) SetReserve(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, reserve := params[0], params[1], params[2]
+
+	// reserve must be non-negative integer
+	reserveBig, err := util.ParseBigBalance("reserve", reserve)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can change reserve
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new reserve
+	oldReserve := erc20Metadata.GetReserveSupply().String()
+	erc20Metadata.ReserveSupply = reserveBig
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "reserveSupply", oldReserve, reserveBig.String())
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setReserve success"))
+}
+
+// SetEmitEvents is invoke function that enables or disables the transfer events of transfer, mint and burn (single or batch) by owner
+// Disabling saves the cost of listeners on high-throughput tokens, but they lose the real-time notification
+// params - tokenID, caller's address, emitEvents("true" or "false")
+func (cc *Controller) SetEmitEvents(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, emitEvents := params[0], params[1], params[2]
+
+	// emitEvents must be true or false
+	if emitEvents != "true" && emitEvents != "false" {
+		return util.BadRequest("emitEvents must be true or false")
+	}
+	emitEventsBool := emitEvents == "true"
+
+	// only owner can change emitEvents
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new emitEvents
+	oldEmitEvents := strconv.FormatBool(erc20Metadata.IsEmitEvents())
+	erc20Metadata.EmitEvents = &emitEventsBool
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event, the setting change itself is always notified
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "emitEvents", oldEmitEvents, emitEvents)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setEmitEvents success"))
+}
+
+// RenounceOwnership is invoke function that gives up the ownership of token by owner
+// Every owner-gated function rejects afterwards and minters are removed, so the supply is fixed
+// params - tokenID, caller's address
+func (cc *Controller) RenounceOwnership(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress := params[0], params[1]
+
+	// only owner can renounce ownership
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata without owner & minters
+	erc20Metadata.Owner = model.RenouncedOwner
+	erc20Metadata.Minters = []string{}
+	erc20Metadata.Standard = erc20Metadata.DeriveStandard()
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit ownership renounced event
+	err = repository.EmitOwnershipRenouncedEvent(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("renounceOwnership success"))
+}
+
+// SetStrictApprovals is invoke function that enables or disables strict approvals by owner
+// In strict mode approve, increaseAllowance and permit reject allowances exceeding the owner's balance,
+// in lenient mode (default) they are allowed as ERC20 does
+// params - tokenID, caller's address, strictApprovals("true" or "false")
+func (cc *Controller) SetStrictApprovals(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, strictApprovals := params[0], params[1], params[2]
+
+	// strictApprovals must be true or false
+	if strictApprovals != "true" && strictApprovals != "false" {
+		return util.BadRequest("strictApprovals must be true or false")
+	}
+
+	// only owner can change strictApprovals
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new strictApprovals
+	oldStrictApprovals := strconv.FormatBool(erc20Metadata.StrictApprovals)
+	erc20Metadata.StrictApprovals = strictApprovals == "true"
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "strictApprovals", oldStrictApprovals, strictApprovals)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setStrictApprovals success"))
+}
+
+// ApproveAndCall is invoke function that sets amount as the allowance of spender
+// and then calls the function of target chaincode in one transaction
+// The target chaincode receives owner's address, spender's address, amount of token
+// params - owner's address, spender's address, amount of token, chaincode name, function name
+func (cc *Controller) ApproveAndCall(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	ownerAddress, spenderAddress, allowanceAmount, chaincodeName, functionName := params[0], params[1], params[2], params[3], params[4]
+
+	// chaincode name & function name cannot be empty
+	if len(chaincodeName) == 0 || len(functionName) == 0 {
+		return util.BadRequest("chaincode name or function name cannot be empty")
+	}
+
+	// approve allowance (emits approval event before the external call)
+	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, allowanceAmount})
+	if approveResponse.GetStatus() >= 400 {
+		return util.StatusResponse(approveResponse.GetStatus(), "failed to approve allowance, error: "+approveResponse.GetMessage())
+	}
+
+	// make arguments
+	args := [][]byte{[]byte(functionName), []byte(ownerAddress), []byte(spenderAddress), []byte(allowanceAmount)}
+
+	// call target chaincode, the failure aborts the whole transaction including the approval
+	callResponse := stub.InvokeChaincode(chaincodeName, args, stub.GetChannelID())
+	if callResponse.GetStatus() >= 400 {
+		return util.StatusResponse(callResponse.GetStatus(), fmt.Sprintf("failed to call %s, error: %s", chaincodeName, callResponse.GetMessage()))
+	}
+
+	return shim.Success(callResponse.GetPayload())
+}
+
+// Clawback is invoke function that forcibly moves amount tokens from one address to another
+// regardless of approval, for court-ordered or fraud recovery (owner only)
+// params - tokenID, caller's address, from address, to address, amount
+func (cc *Controller) Clawback(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, fromAddress, toAddress, clawbackAmount := params[0], params[1], params[2], params[3], params[4]
+
+	// amount must be positive
+	clawbackAmountBig, err := util.ConvertToBigPositive("clawbackAmount", clawbackAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can claw back
+	_, err = assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// move the from address's amount to address (balance must be sufficient)
+	_, _, err = moveBalance(stub, fromAddress, toAddress, clawbackAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit clawback event (not transfer event, for audit clarity)
+	err = repository.EmitClawbackEvent(stub, callerAddress, fromAddress, toAddress, clawbackAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("clawback success"))
 }
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// maxKeyPolicyOrgs is the max number of organizations of a key policy
+const maxKeyPolicyOrgs = 16
+
+// SetKeyPolicy is invoke function that sets the key-level endorsement policy (state-based endorsement)
+// of the metadata key or the balance key of address by owner
+// Writing the key then needs the endorsement of a peer of every org besides the chaincode logic,
+// including the later metadata updates of owner (e.g. mint), so include the orgs endorsing them.
+// The empty orgs remove the policy. Requires Fabric 1.3+ (key-level endorsement)
+// params - tokenID, caller's address, target("metadata" or "balance"), address (balance only, otherwise empty),
+// orgs(JSON array of MSP IDs)
+func (cc *Controller) SetKeyPolicy(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, target, address, orgs := params[0], params[1], params[2], params[3], params[4]
+
+	// target must be the metadata or a balance
+	var objectType string
+	var attributes []string
+	switch target {
+	case "metadata":
+		if len(address) != 0 {
+			return util.BadRequest("address must be empty for metadata")
+		}
+		objectType, attributes = repository.TokenPrefix, []string{tokenID}
+	case "balance":
+		if len(address) == 0 {
+			return util.BadRequest("address cannot be empty for balance")
+		}
+		objectType, attributes = repository.BalancePrefix, []string{address}
+	default:
+		return util.BadRequest("target must be metadata or balance")
+	}
+
+	// parse orgs
+	mspIDs, err := parseKeyPolicyOrgs(orgs)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can set key policy
+	_, err = assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// every org has to endorse by a peer, the empty orgs remove the policy
+	var policy []byte
+	if len(mspIDs) > 0 {
+		endorsementPolicy, err := statebased.NewStateEP(nil)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, mspIDs...)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		policy, err = endorsementPolicy.Policy()
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// save key policy
+	err = repository.SetKeyPolicy(stub, objectType, attributes, policy)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setKeyPolicy success"))
+}
+
+// parseKeyPolicyOrgs strictly decodes orgs params
+// Empty, duplicate MSP IDs and more than maxKeyPolicyOrgs are rejected
+func parseKeyPolicyOrgs(orgs string) ([]string, error) {
+	mspIDs := []string{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(orgs)))
+	err := decoder.Decode(&mspIDs)
+	if err != nil {
+		return nil, model.NewCustomError(model.ConvertErrorType, "orgs", "must be JSON array of MSP IDs, "+err.Error())
+	}
+	if decoder.More() {
+		return nil, model.NewCustomError(model.ConvertErrorType, "orgs", "unexpected data after orgs array")
+	}
+	if len(mspIDs) > maxKeyPolicyOrgs {
+		return nil, model.NewCustomError(model.ConvertErrorType, "orgs", fmt.Sprintf("cannot be more than %d", maxKeyPolicyOrgs))
+	}
+
+	seen := map[string]bool{}
+	for i, mspID := range mspIDs {
+		if len(mspID) == 0 {
+			return nil, model.NewCustomError(model.ConvertErrorType, "orgs", fmt.Sprintf("MSP ID %d cannot be empty", i))
+		}
+		if seen[mspID] {
+			return nil, model.NewCustomError(model.ConvertErrorType, "orgs", "duplicate MSP ID "+mspID)
+		}
+		seen[mspID] = true
+	}
+
+	return mspIDs, nil
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SetLabel is invoke function that sets the label of address by owner, e.g. the KYC tier
+// params - tokenID, caller's address, address, label (one of model.AllowedLabels)
+func (cc *Controller) SetLabel(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, address, label := params[0], params[1], params[2], params[3]
+
+	// address cannot be empty
+	if len(address) == 0 {
+		return util.BadRequest("address cannot be empty")
+	}
+
+	// label must be one of the allowed labels
+	if !model.IsAllowedLabel(label) {
+		return util.BadRequest("label must be one of " + strings.Join(model.AllowedLabels, ", "))
+	}
+
+	// only owner can set label
+	_, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save label of address
+	err = repository.SaveLabel(stub, address, label)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setLabel success"))
+}
+
+// GetLabel is query function
+// params - address
+// Returns the label of address, empty if owner hasn't set one
+func (cc *Controller) GetLabel(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address := params[0]
+
+	label, err := repository.GetLabel(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(label))
+}
+
+// SetVerifiedThreshold is invoke function that sets the amount above which
+// only addresses labeled verified can receive a transfer by owner, "0" threshold disables the check
+// params - tokenID, caller's address, threshold
+func (cc *Controller) SetVerifiedThreshold(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, threshold := params[0], params[1], params[2]
+
+	// threshold must be non-negative integer
+	thresholdBig, err := util.ParseBigBalance("threshold", threshold)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can change threshold
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new threshold
+	oldThreshold := erc20Metadata.GetVerifiedThreshold().String()
+	erc20Metadata.VerifiedThreshold = thresholdBig
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "verifiedThreshold", oldThreshold, thresholdBig.String())
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setVerifiedThreshold success"))
+}
+
+// checkRecipientLabel returns error if amount is above the verified threshold
+// and the recipient is not labeled verified
+func checkRecipientLabel(stub shim.ChaincodeStubInterface, recipientAddress string, amount *big.Int) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	threshold := erc20Metadata.GetVerifiedThreshold()
+	if !util.ExceedsLimit(amount, threshold) {
+		return nil
+	}
+
+	label, err := repository.GetLabel(stub, recipientAddress)
+	if err != nil {
+		return err
+	}
+	if label != model.LabelVerified {
+		return model.NewStatusError(model.StatusForbidden, recipientAddress+" must be "+model.LabelVerified+" to receive more than "+threshold.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SetRateLimit is invoke function that configures the per-address transfer limit (owner only)
+// An address cannot make more than maxTransfers transfers or more than maxAmount total
+// in a window of windowSeconds, "0" windowSeconds disables rate limit, "0" max value disables each limit
+// params - tokenID, caller's address, windowSeconds, maxTransfers, maxAmount
+func (cc *Controller) SetRateLimit(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, windowSeconds, maxTransfers, maxAmount := params[0], params[1], params[2], params[3], params[4]
+
+	// check limits are non-negative integer
+	windowSecondsInt, err := util.ParseDecimalInt("windowSeconds", windowSeconds, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	maxTransfersInt, err := util.ConvertToNonNegative("maxTransfers", maxTransfers)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	maxAmountBig, err := util.ParseBigBalance("maxAmount", maxAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can configure
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save rate limit
+	erc20Metadata.RateLimit = nil
+	if windowSecondsInt > 0 {
+		if maxAmountBig.Sign() == 0 {
+			maxAmountBig = nil
+		}
+		erc20Metadata.RateLimit = model.NewRateLimit(windowSecondsInt, *maxTransfersInt, maxAmountBig)
+	}
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setRateLimit success"))
+}
+
+// SetTransferCooldown is invoke function that configures the per-address transfer cooldown (owner only)
+// After a transfer, an address cannot send again until cooldownSeconds elapse, "0" disables cooldown
+// params - tokenID, caller's address, cooldownSeconds
+func (cc *Controller) SetTransferCooldown(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, cooldownSeconds := params[0], params[1], params[2]
+
+	// check cooldown is non-negative integer
+	cooldownSecondsInt, err := util.ParseDecimalInt("cooldownSeconds", cooldownSeconds, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can configure
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save cooldown
+	erc20Metadata.TransferCooldownSeconds = cooldownSecondsInt
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setTransferCooldown success"))
+}
+
+// checkSenderLimits checks the pause of token and the cooldown & rate limit of sender before the sender's balance is debited
+func checkSenderLimits(stub shim.ChaincodeStubInterface, senderAddress string, amount *big.Int) error {
+	err := checkPaused(stub)
+	if err != nil {
+		return err
+	}
+
+	err = checkCooldown(stub, senderAddress)
+	if err != nil {
+		return err
+	}
+
+	return checkRateLimit(stub, senderAddress, amount)
+}
+
+// checkCooldown returns error if the cooldown of sender's last transfer is active,
+// otherwise records the transfer as the sender's last transfer
+func checkCooldown(stub shim.ChaincodeStubInterface, senderAddress string) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	if erc20Metadata.TransferCooldownSeconds == 0 {
+		return nil
+	}
+
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return err
+	}
+	retryAfter, err := cooldownRetryAfter(stub, senderAddress, erc20Metadata.TransferCooldownSeconds)
+	if err != nil {
+		return err
+	}
+	if txSeconds < retryAfter {
+		return model.NewStatusError(model.StatusConflict, fmt.Sprintf("cooldown active, retry after %d", retryAfter))
+	}
+
+	return repository.SaveLastTransferSeconds(stub, senderAddress, txSeconds)
+}
+
+// cooldownRetryAfter returns the unix seconds sender can send again after the last transfer
+func cooldownRetryAfter(stub shim.ChaincodeStubInterface, senderAddress string, cooldownSeconds int64) (int64, error) {
+	lastSeconds, err := repository.GetLastTransferSeconds(stub, senderAddress)
+	if err != nil {
+		return 0, err
+	}
+	if lastSeconds == 0 {
+		return 0, nil
+	}
+
+	return util.AddSeconds(lastSeconds, cooldownSeconds), nil
+}
+
+// remainingRateLimit returns the amount sender can still transfer in the current window
+// Returns nil if the amount is not limited
+func remainingRateLimit(stub shim.ChaincodeStubInterface, senderAddress string) (*big.Int, error) {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return nil, err
+	}
+	rateLimit := erc20Metadata.GetRateLimit()
+	if rateLimit == nil {
+		return nil, nil
+	}
+
+	// get the counter of current window
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return nil, err
+	}
+	windowStart := txSeconds / rateLimit.WindowSeconds * rateLimit.WindowSeconds
+	counter, err := repository.GetTransferCounter(stub, senderAddress, windowStart)
+	if err != nil {
+		return nil, err
+	}
+
+	// no transfer is left in the window
+	if rateLimit.MaxTransfers > 0 && counter.Count >= rateLimit.MaxTransfers {
+		return big.NewInt(0), nil
+	}
+	if rateLimit.MaxAmount == nil {
+		return nil, nil
+	}
+	if counter.Amount.Cmp(rateLimit.MaxAmount) >= 0 {
+		return big.NewInt(0), nil
+	}
+
+	return new(big.Int).Sub(rateLimit.MaxAmount, counter.Amount), nil
+}
+
+// checkRateLimit counts the transfer of sender in the current window
+// Returns error if the transfer exceeds the rate limit of token
+func checkRateLimit(stub shim.ChaincodeStubInterface, senderAddress string, amount *big.Int) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	rateLimit := erc20Metadata.GetRateLimit()
+	if rateLimit == nil {
+		return nil
+	}
+
+	// get the window of transaction
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return err
+	}
+	windowStart := txSeconds / rateLimit.WindowSeconds * rateLimit.WindowSeconds
+
+	// count the transfer
+	counter, err := repository.GetTransferCounter(stub, senderAddress, windowStart)
+	if err != nil {
+		return err
+	}
+	counter.Count++
+	counter.Amount = util.AddBigBalance(counter.Amount, amount)
+
+	// check limits
+	if rateLimit.MaxTransfers > 0 && counter.Count > rateLimit.MaxTransfers {
+		return model.NewStatusError(model.StatusConflict, fmt.Sprintf("rate limit exceeded, %s cannot transfer more than %d times in %d seconds", senderAddress, rateLimit.MaxTransfers, rateLimit.WindowSeconds))
+	}
+	if util.ExceedsLimit(counter.Amount, rateLimit.MaxAmount) {
+		return model.NewStatusError(model.StatusConflict, fmt.Sprintf("rate limit exceeded, %s cannot transfer more than %s in %d seconds", senderAddress, rateLimit.MaxAmount.String(), rateLimit.WindowSeconds))
+	}
+
+	return repository.SaveTransferCounter(stub, senderAddress, windowStart, counter)
+}
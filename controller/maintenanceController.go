@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// maintenanceFunctions are the only functions allowed in maintenance, so owner can migrate, configure the token,
+// inspect metadata and end maintenance. The functions changing supply or moving balances (mint, mintTo, mintBatch,
+// clawback, sweep, reconcileSupply) are frozen like transfers, admin checks its sub-command against the list too
+var maintenanceFunctions = map[string]bool{
+	"setMaintenance":        true,
+	"getMetadata":           true,
+	"admin":                 true,
+	"migrateBalances":       true,
+	"seedBalances":          true,
+	"setName":               true,
+	"setSymbol":             true,
+	"lockMetadata":          true,
+	"setEmitEvents":         true,
+	"setReserve":            true,
+	"setRateLimit":          true,
+	"setTransferCooldown":   true,
+	"setCircuitBreaker":     true,
+	"unpause":               true,
+	"setStrictApprovals":    true,
+	"setLabel":              true,
+	"setVerifiedThreshold":  true,
+	"addMinter":             true,
+	"removeMinter":          true,
+	"addExcludedAddress":    true,
+	"removeExcludedAddress": true,
+	"setBurnAddress":        true,
+	"setKeyPolicy":          true,
+	"setWhitelistMode":      true,
+	"addAllowed":            true,
+	"removeAllowed":         true,
+}
+
+// CheckMaintenance returns 503 error when owner enabled maintenance and function is not in maintenanceFunctions
+// Unlike blocking transfers only, maintenance blocks queries too
+func (cc *Controller) CheckMaintenance(erc20Metadata *model.ERC20Metadata, function string) error {
+	if !erc20Metadata.Maintenance || maintenanceFunctions[function] {
+		return nil
+	}
+
+	return model.NewStatusError(model.StatusUnavailable, "token "+erc20Metadata.ID+" is under maintenance, "+function+" is unavailable")
+}
+
+// SetMaintenance is invoke function that enables or disables maintenance by owner
+// params - tokenID, caller's address, maintenance("true" or "false")
+func (cc *Controller) SetMaintenance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, maintenance := params[0], params[1], params[2]
+
+	// maintenance must be true or false
+	if maintenance != "true" && maintenance != "false" {
+		return util.BadRequest("maintenance must be true or false")
+	}
+
+	// only owner can change maintenance
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new maintenance
+	oldMaintenance := strconv.FormatBool(erc20Metadata.Maintenance)
+	erc20Metadata.Maintenance = maintenance == "true"
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "maintenance", oldMaintenance, maintenance)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setMaintenance success"))
+}
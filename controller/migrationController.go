@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// MigrateBalances is invoke function that moves one page of the balances stored under bare address keys
+// to the composite keys balance/{address} by owner, for the tokens deployed before composite keys
+// The legacy key is deleted once moved and added to the balance already under the composite key,
+// so re-running is safe. The migration is marked complete in state after the last page
+// params - tokenID, caller's address, pageSize, bookmark
+// Returns the number of migrated balances, the next bookmark and whether the migration is complete
+func (cc *Controller) MigrateBalances(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, pageSize, bookmark := params[0], params[1], params[2], params[3]
+
+	// check page size is integer in [1, MaxInt32]
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can migrate balances
+	_, err = assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// nothing is left to migrate once complete
+	migration := model.BalanceMigration{}
+	migration.Completed, err = repository.IsBalanceMigrated(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	if !migration.Completed {
+		legacyBalances, nextBookmark, err := repository.GetLegacyBalancePage(stub, tokenID, int32(pageSizeInt), bookmark)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+
+		// move each legacy balance to the composite key
+		for _, legacyBalance := range legacyBalances {
+			curBalance, err := repository.GetBalance(stub, legacyBalance.Address, true)
+			if err != nil {
+				return util.ErrorResponse(err)
+			}
+			resultBalance := util.AddBigBalance(curBalance, legacyBalance.Balance)
+			err = repository.SaveBalance(stub, legacyBalance.Address, resultBalance)
+			if err != nil {
+				return util.ErrorResponse(err)
+			}
+			err = repository.DeleteLegacyBalance(stub, legacyBalance.Address)
+			if err != nil {
+				return util.ErrorResponse(err)
+			}
+		}
+		migration.Migrated = len(legacyBalances)
+		migration.Bookmark = nextBookmark
+
+		// the last page completes the migration
+		if len(nextBookmark) == 0 {
+			err = repository.SaveBalanceMigrated(stub)
+			if err != nil {
+				return util.ErrorResponse(err)
+			}
+			migration.Completed = true
+		}
+	}
+
+	// convert migration to bytes for return
+	response, err := json.Marshal(migration)
+	if err != nil {
+		return shim.Error("failed to Marshal balanceMigration, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// SeedBalances is invoke function that sets the balances of a snapshot from another ledger by owner
+// to bootstrap the token, unlike mint each balance is replaced (not increased) and
+// totalSupply is recomputed to match, other balances are kept
+// It succeeds only once, a marker in state rejects any later seeding after launch
+// params - tokenID, caller's address, balances(JSON) - [{"address": address, "amount": amount}, ...]
+// Returns the number of seeded balances and the recomputed totalSupply
+func (cc *Controller) SeedBalances(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, balances := params[0], params[1], params[2]
+
+	// parse seed entries
+	entries, amounts, err := parseSeed(balances)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can seed balances
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// balances can be seeded only once
+	seeded, err := repository.IsBalancesSeeded(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if seeded {
+		return util.Conflict("balances of " + tokenID + " are already seeded")
+	}
+
+	// replace each balance, the supply changes by the difference
+	// A seeded address passes the recipient checks (burn address, verified label, whitelist) and gets its account marker
+	// like a credited recipient, but no txlog entry, as replacing the balance is no movement
+	oldSupply := erc20Metadata.GetTotalSupply()
+	totalSupply := new(big.Int).Set(oldSupply)
+	for i, entry := range entries {
+		err = checkRecipient(stub, entry.Address, amounts[i])
+		if err != nil {
+			return util.ErrorResponse(model.NewStatusError(util.ErrorStatus(err), fmt.Sprintf("invalid seed entry %d: %s", i, err.Error())))
+		}
+
+		curBalance, err := repository.GetBalance(stub, entry.Address, true)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		totalSupply.Sub(totalSupply, curBalance)
+		totalSupply.Add(totalSupply, amounts[i])
+
+		err = repository.SaveBalance(stub, entry.Address, amounts[i])
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		err = markAccountCreated(stub, entry.Address)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// save metadata with recomputed supply & the seeded marker
+	erc20Metadata.TotalSupply = totalSupply
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.SaveBalancesSeeded(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "totalSupply", oldSupply.String(), totalSupply.String())
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert seed to bytes for return
+	response, err := json.Marshal(model.BalanceSeed{Seeded: len(entries), TotalSupply: totalSupply})
+	if err != nil {
+		return shim.Error("failed to Marshal balanceSeed, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// parseSeed strictly decodes seedBalances params and validates them like batch entries
+// (no empty or duplicate addresses, positive amounts)
+// Returns the entries and the parsed amounts
+func parseSeed(balances string) ([]model.SeedEntry, []*big.Int, error) {
+	entries := []model.SeedEntry{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(balances)))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&entries)
+	if err != nil {
+		return nil, nil, model.NewCustomError(model.ConvertErrorType, "balances", "must be JSON array of {\"address\", \"amount\"}, "+err.Error())
+	}
+	if decoder.More() {
+		return nil, nil, model.NewCustomError(model.ConvertErrorType, "balances", "unexpected data after balances array")
+	}
+
+	batchEntries := []model.BatchEntry{}
+	for _, entry := range entries {
+		batchEntries = append(batchEntries, model.BatchEntry{Recipient: entry.Address, Amount: entry.Amount})
+	}
+	amounts, err := validateBatch(batchEntries)
+	if err != nil {
+		return nil, nil, model.NewStatusError(model.StatusBadRequest, "invalid balances: "+err.Error())
+	}
+
+	return entries, amounts, nil
+}
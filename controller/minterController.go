@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// AddMinter is invoke function that adds minter to the minter set of token by owner
+// params - tokenID, caller's address, minter's address
+func (cc *Controller) AddMinter(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, minterAddress := params[0], params[1], params[2]
+
+	// minter cannot be empty
+	if len(minterAddress) == 0 {
+		return util.BadRequest("minter cannot be empty")
+	}
+
+	// only owner can add minter
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// minter cannot be duplicated
+	if erc20Metadata.IsMinter(minterAddress) {
+		return util.Conflict(minterAddress + " is already a minter")
+	}
+
+	// save metadata with new minter set
+	oldMinters := strings.Join(erc20Metadata.GetMinters(), ",")
+	erc20Metadata.Minters = append(erc20Metadata.GetMinters(), minterAddress)
+	erc20Metadata.Standard = erc20Metadata.DeriveStandard()
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "minters", oldMinters, strings.Join(erc20Metadata.GetMinters(), ","))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("addMinter success"))
+}
+
+// RemoveMinter is invoke function that removes minter from the minter set of token by owner
+// params - tokenID, caller's address, minter's address
+func (cc *Controller) RemoveMinter(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, minterAddress := params[0], params[1], params[2]
+
+	// only owner can remove minter
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// minter must be in the minter set
+	if !erc20Metadata.IsMinter(minterAddress) {
+		return util.Conflict(minterAddress + " is not a minter")
+	}
+
+	// save metadata with new minter set
+	oldMinters := strings.Join(erc20Metadata.GetMinters(), ",")
+	minters := []string{}
+	for _, minter := range erc20Metadata.GetMinters() {
+		if minter != minterAddress {
+			minters = append(minters, minter)
+		}
+	}
+	erc20Metadata.Minters = minters
+	erc20Metadata.Standard = erc20Metadata.DeriveStandard()
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "minters", oldMinters, strings.Join(minters, ","))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("removeMinter success"))
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// ClaimOwnership is invoke function that moves the ownership of inactive owner to the backup owner set at Init,
+// so a lost owner key doesn't brick the token
+// Owner is inactive once no owner action (assertOwner, or mint by owner) was performed for inactivityPeriod seconds,
+// the new owner has no backup owner
+// read & write set - token/{tokenID}
+// params - tokenID, caller's address (backup owner)
+func (cc *Controller) ClaimOwnership(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress := params[0], params[1]
+
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only backup owner can claim
+	if len(erc20Metadata.BackupOwner) == 0 || erc20Metadata.BackupOwner != callerAddress {
+		return util.Forbidden("caller is not the backup owner of " + tokenID)
+	}
+	if erc20Metadata.IsOwnershipRenounced() {
+		return util.Conflict("ownership of " + tokenID + " is renounced")
+	}
+
+	// owner must be inactive for the period
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if !erc20Metadata.IsOwnershipClaimable(txSeconds) {
+		return util.Conflict(fmt.Sprintf("owner of %s is active, ownership is claimable from %d", tokenID, erc20Metadata.OwnerActiveAt+erc20Metadata.InactivityPeriod))
+	}
+
+	// save metadata with the backup owner as owner
+	previousOwner := *erc20Metadata.GetOwner()
+	erc20Metadata.Owner = callerAddress
+	erc20Metadata.BackupOwner = ""
+	erc20Metadata.InactivityPeriod = 0
+	erc20Metadata.OwnerActiveAt = 0
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit ownership transferred event
+	err = repository.EmitOwnershipTransferredEvent(stub, tokenID, previousOwner, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("claimOwnership success"))
+}
+
+// recordOwnerActivity saves the tx time as the last owner activity when the backup owner is set
+// The handler saving erc20 later in the transaction keeps it, as erc20 carries the new time
+func recordOwnerActivity(stub shim.ChaincodeStubInterface, erc20 *model.ERC20Metadata) error {
+	if len(erc20.BackupOwner) == 0 {
+		return nil
+	}
+
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return err
+	}
+	erc20.OwnerActiveAt = txSeconds
+
+	return saveMetadata(stub, erc20.ID, erc20)
+}
+
+// validateBackupOwner checks the backup owner options of Init, backupOwner must be another address than owner
+// and inactivityPeriod must be positive, neither set disables the claim
+func validateBackupOwner(owner string, initOptions *model.InitOptions) error {
+	if len(initOptions.BackupOwner) == 0 && initOptions.InactivityPeriod == 0 {
+		return nil
+	}
+
+	if len(strings.TrimSpace(initOptions.BackupOwner)) == 0 {
+		return model.NewStatusError(model.StatusBadRequest, "backupOwner is required with inactivityPeriod")
+	}
+	if initOptions.BackupOwner == owner {
+		return model.NewStatusError(model.StatusBadRequest, "backupOwner cannot be the owner")
+	}
+	if initOptions.InactivityPeriod <= 0 {
+		return model.NewStatusError(model.StatusBadRequest, "inactivityPeriod must be positive with backupOwner")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Permit is invoke function that sets amount as the allowance of spender
+// over the owner tokens with the owner's signature, so a relayer can submit it
+// The owner's address must be derived from publicKey (see util.AddressFromPublicKey),
+// signature is the owner's ECDSA signature over util.PermitMessage with the current nonce of owner
+// params - owner's address, spender's address, amount of token, deadline(unix seconds),
+// publicKey(hex of uncompressed P-256 point), signature(hex of DER)
+func (cc *Controller) Permit(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 6
+	if len(params) != 6 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	ownerAddress, spenderAddress, allowanceAmount, deadline, publicKey, signature := params[0], params[1], params[2], params[3], params[4], params[5]
+
+	// owner cannot approve itself
+	err := validateAllowanceParties(ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check amount is integer & not negative (zero revokes allowance)
+	allowanceAmountBig, err := util.ParseBigBalance("AllowanceAmount", allowanceAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// in strict mode, allowance cannot exceed the owner's balance
+	err = validateAllowanceCap(stub, ownerAddress, spenderAddress, allowanceAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// permit cannot be used after deadline
+	deadlineInt, err := util.ParseDecimalInt("deadline", deadline, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if txSeconds > deadlineInt {
+		return util.BadRequest("permit is expired at " + deadline)
+	}
+
+	// owner's address must be derived from the public key
+	ownerPublicKey, err := util.ParsePublicKey(publicKey)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if util.AddressFromPublicKey(ownerPublicKey) != ownerAddress {
+		return util.ErrorResponse(model.NewCustomError(model.AuthorizeErrorType, ownerAddress, "publicKey does not belong to the owner"))
+	}
+
+	// verify the owner's signature with the current nonce
+	tokenID, err := repository.GetTokenID(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	nonce, err := repository.GetNonce(stub, ownerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	message := util.PermitMessage(tokenID, ownerAddress, spenderAddress, allowanceAmount, deadline, nonce)
+	err = util.VerifySignature(ownerPublicKey, message, signature)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// use up the nonce so the permit cannot be replayed
+	err = repository.SaveNonce(stub, ownerAddress, nonce+1)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save allowance amount, which replaces the recurring allowance like approve
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, util.FormatBigBalance(allowanceAmountBig))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.DeleteRecurringAllowance(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit approval event
+	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, allowanceAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("permit success"))
+}
+
+// Nonce is query function
+// params - address
+// Returns the nonce the next signed operation (e.g. permit) of address must be signed with,
+// every signed operation requires the current nonce and increments it so a signature cannot be replayed
+func (cc *Controller) Nonce(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address := params[0]
+
+	// get nonce
+	nonce, err := repository.GetNonce(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(strconv.FormatInt(nonce, 10)))
+}
@@ -3,28 +3,37 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/erc20/model"
+	"github.com/erc20/pb"
 	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
 // TotalSupply is query function
-// params - tokenName
+// params - tokenID
 // Returns the amount of token in existence
 func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of params is one
 	if len(params) != 1 {
-		return shim.Error("incorrect number of parameter")
+		return util.BadRequest("incorrect number of parameter")
 	}
 
-	tokenName := params[0]
+	tokenID := params[0]
 
 	// Get ERC20 TotalSupply
-	totalSupply, err := repository.GetERC20TotalSupply(stub, tokenName)
+	totalSupply, err := repository.GetERC20TotalSupply(stub, tokenID)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	// Convert TotalSupply to Bytes
@@ -32,40 +41,143 @@ func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []str
 	if err != nil {
 		return shim.Error("failed to Marshal totalSupply, error: " + err.Error())
 	}
-	fmt.Println(tokenName + "'s totalSupply is " + string(totalSupplyBytes))
+	fmt.Println(tokenID + "'s totalSupply is " + string(totalSupplyBytes))
 
 	return shim.Success(totalSupplyBytes)
 }
 
+// GetMetadata is query function
+// params - tokenID, [format]
+// Returns the metadata of token, as pb.TokenMetadata with "format=proto" (see parseFormat)
+func (cc *Controller) GetMetadata(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one or two
+	if len(params) != 1 && len(params) != 2 {
+		return util.BadRequest("incorrect number of parameter")
+	}
+
+	tokenID := params[0]
+	protoFormat, err := parseFormat(params[1:])
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// get metadata
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if protoFormat {
+		return protoResponse("erc20Metadata", pb.NewTokenMetadata(erc20Metadata))
+	}
+
+	// convert metadata to bytes for return
+	response, err := json.Marshal(erc20Metadata)
+	if err != nil {
+		return shim.Error("failed to Marshal erc20Metadata, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
 // BalanceOf is query function
-// params - address
+// params - address, ["formatted" or format]
 // Returns the amount of tokens owned by addresss
+// "formatted" returns the amount formatted with decimals & displayFormat of token (e.g. "123.45 dt"),
+// "format=proto" returns pb.Balance (see parseFormat)
+// Otherwise the payload is the decimal integer string in the smallest unit, "0" for a missing address,
+// this format is the stable contract for other chaincodes calling balanceOf through InvokeChaincode
 func (cc *Controller) BalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
+	// check the number of params is one or two
+	if len(params) != 1 && len(params) != 2 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address := params[0]
+
+	// get Balance
+	amountBig, err := repository.GetBalance(stub, address, true)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// raw integer by default, in the same format as stored
+	if len(params) == 1 {
+		return shim.Success([]byte(util.FormatBigBalance(amountBig)))
+	}
+	if params[1] != "formatted" {
+		protoFormat, err := parseFormat(params[1:])
+		if err != nil {
+			return util.BadRequest("unknown balance format " + params[1])
+		}
+		if protoFormat {
+			return protoResponse("balance", pb.NewBalance(address, amountBig))
+		}
+		return shim.Success([]byte(util.FormatBigBalance(amountBig)))
+	}
+
+	// format with decimals
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	formatted, err := util.FormatDisplay(erc20Metadata.GetDisplayFormat(), amountBig, *erc20Metadata.GetDecimals(), *erc20Metadata.GetSymbol())
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	return shim.Success([]byte(formatted))
+}
+
+// ChaincodeBalanceOf is query function for other chaincodes on the same channel
+// params - address
+// Returns model.ChaincodeBalance of address as JSON, the stable cross-chaincode contract:
+// invoke with InvokeChaincode(<token chaincode>, ["chaincodeBalanceOf", address], channel)
+// Balance is in the same format as balanceOf, decimals & tokenId let the caller interpret it
+func (cc *Controller) ChaincodeBalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
 	// check the number of params is one
 	if len(params) != 1 {
-		return shim.Error("incorrect number of parameters")
+		return util.BadRequest("incorrect number of parameters")
 	}
 
 	address := params[0]
 
-	// get Balance
-	amountBytes, err := repository.GetBalanceBytes(stub, address, true)
+	amountBig, err := repository.GetBalance(stub, address, true)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
-	return shim.Success(amountBytes)
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	balance := model.ChaincodeBalance{
+		TokenID:  *erc20Metadata.GetID(),
+		Address:  address,
+		Balance:  util.FormatBigBalance(amountBig),
+		Decimals: *erc20Metadata.GetDecimals(),
+	}
+
+	response, err := json.Marshal(balance)
+	if err != nil {
+		return shim.Error("failed to Marshal balance, error: " + err.Error())
+	}
+
+	return shim.Success(response)
 }
 
 // ApprovalList is query function
 // params - owner's address
 // Returns the approval list approved by owner
+// All-or-nothing, an iterator error fails the call (see model.PartialResult)
 func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of parmas is 1
 	if len(params) != 1 {
-		return shim.Error("incorrect number of params")
+		return util.BadRequest("incorrect number of params")
 	}
 
 	ownerAddress := params[0]
@@ -73,7 +185,7 @@ func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []st
 	// get approval List
 	approvalSlice, err := repository.GetApprovalList(stub, ownerAddress)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	// convert approvalSlice to bytes for return
@@ -85,6 +197,80 @@ func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []st
 	return shim.Success(response)
 }
 
+// SpenderCount is query function
+// params - owner's address
+// Returns the number of spenders currently holding a non-zero allowance from owner, e.g. as a risk indicator
+// A revoked allowance doesn't count, a recurring allowance counts with the amount of the current period
+func (cc *Controller) SpenderCount(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	ownerAddress := params[0]
+
+	// spenders are unique in the keys
+	approvals, err := activeApprovals(stub, ownerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(strconv.Itoa(len(approvals))))
+}
+
+// TotalAllowanceGranted is query function
+// params - owner's address
+// Returns the sum of the non-zero allowances owner granted across spenders (decimal string), e.g. as a risk indicator
+// A recurring allowance counts with the amount of the current period
+func (cc *Controller) TotalAllowanceGranted(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	ownerAddress := params[0]
+
+	approvals, err := activeApprovals(stub, ownerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// sum as big integer like balances
+	total := big.NewInt(0)
+	for _, approval := range approvals {
+		total = util.AddBigBalance(total, approval.Allowance)
+	}
+
+	return shim.Success([]byte(total.String()))
+}
+
+// activeApprovals returns the approvals of owner holding a non-zero allowance,
+// the allowance of a recurring allowance is the amount of the current period
+func activeApprovals(stub shim.ChaincodeStubInterface, ownerAddress string) ([]model.Approval, error) {
+	approvalSlice, err := repository.GetApprovalList(stub, ownerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := []model.Approval{}
+	for _, approval := range approvalSlice {
+		recurring, err := dueRecurringAllowance(stub, ownerAddress, approval.Spender)
+		if err != nil {
+			return nil, err
+		}
+		if recurring != nil {
+			approval.Allowance = recurring.Amount
+		}
+		if approval.Allowance.Sign() > 0 {
+			approvals = append(approvals, approval)
+		}
+	}
+
+	return approvals, nil
+}
+
 // Allowance is query function
 // params - owner's address, spender's address
 // Returns the remaining amount of token to invoke {transferFrom}
@@ -92,17 +278,888 @@ func (cc *Controller) Allowance(stub shim.ChaincodeStubInterface, params []strin
 
 	// check the number of params is 2
 	if len(params) != 2 {
-		return shim.Error("incorrect number of parameters")
+		return util.BadRequest("incorrect number of parameters")
 	}
 
 	ownerAddress, spenderAddress := params[0], params[1]
 
+	// the recurring allowance is reset once a new period started
+	recurring, err := dueRecurringAllowance(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if recurring != nil {
+		return shim.Success([]byte(util.FormatBigBalance(recurring.Amount)))
+	}
+
 	// get amount
 	amountBytes, err := repository.GetAllowanceBytes(stub, ownerAddress, spenderAddress, true)
 	if err != nil {
-		return shim.Error(err.Error())
+		return util.ErrorResponse(err)
 	}
 
 	return shim.Success(amountBytes)
 
 }
+
+// BalanceAndAllowance is query function
+// params - owner's address, spender's address
+// Returns the owner's balance and the allowance of spender in one call (e.g. for approval screens)
+func (cc *Controller) BalanceAndAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	ownerAddress, spenderAddress := params[0], params[1]
+
+	// owner & spender cannot be empty
+	if len(ownerAddress) == 0 || len(spenderAddress) == 0 {
+		return util.BadRequest("owner or spender cannot be empty")
+	}
+
+	// get balance & allowance, missing values are 0
+	balance, err := repository.GetBalance(stub, ownerAddress, true)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	allowanceBytes, err := repository.GetAllowanceBytes(stub, ownerAddress, spenderAddress, true)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	allowance, err := util.ParseBigBalance("allowance", string(allowanceBytes))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert balance & allowance to bytes for return
+	response, err := json.Marshal(model.BalanceAndAllowance{Balance: balance, Allowance: allowance})
+	if err != nil {
+		return shim.Error("failed to Marshal balanceAndAllowance, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// TokenAge is query function
+// params - tokenID
+// Returns the creation txID & unix seconds of token and the seconds since then (see model.TokenAge)
+// The age is measured at the query transaction timestamp, which the client proposing it sets
+func (cc *Controller) TokenAge(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	tokenID := params[0]
+
+	// get metadata
+	erc20, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// tokens created before the creation was recorded are unknown
+	tokenAge := model.TokenAge{CreatedTx: model.UnknownCreation}
+	if len(erc20.CreatedTx) != 0 {
+		txSeconds, err := getTxSeconds(stub)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		ageSeconds := txSeconds - erc20.CreatedAt
+		tokenAge = model.TokenAge{CreatedTx: erc20.CreatedTx, CreatedAt: &erc20.CreatedAt, AgeSeconds: &ageSeconds}
+	}
+
+	// convert token age to bytes for return
+	response, err := json.Marshal(tokenAge)
+	if err != nil {
+		return shim.Error("failed to Marshal tokenAge, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// RecentTransfers is query function
+// params - address, page size, bookmark, [format]
+// Returns one page of transfers sent or received by address, as pb.TransferLogPage with "format=proto" (see parseFormat)
+func (cc *Controller) RecentTransfers(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3 or 4
+	if len(params) != 3 && len(params) != 4 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address, pageSize, bookmark := params[0], params[1], params[2]
+	protoFormat, err := parseFormat(params[3:])
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check page size is integer in [1, MaxInt32]
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// get transfer logs
+	page, err := repository.GetTransferLogs(stub, address, int32(pageSizeInt), bookmark)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if protoFormat {
+		return protoResponse("transferLogPage", pb.NewTransferLogPage(page))
+	}
+
+	// convert page to bytes for return
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal transferLogPage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// EventByTxID is query function
+// params - address, txID, [format]
+// Returns JSON array of the txlog entries (txID, movement index & transfer event) of the transaction txID
+// moving the tokens of address, one entry per movement (a batch has one per recipient)
+// txlog keys begin with the address & txID, so the address (sender or recipient) makes it a prefix lookup
+// "format=proto" returns pb.TransferLogs (see parseFormat)
+func (cc *Controller) EventByTxID(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2 or 3
+	if len(params) != 2 && len(params) != 3 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address, txID := params[0], params[1]
+	protoFormat, err := parseFormat(params[2:])
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// get transfer logs
+	transferLogs, err := repository.GetTransferLog(stub, address, txID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if len(transferLogs) == 0 {
+		return util.NotFound("event of " + txID + " for " + address + " not found")
+	}
+	if protoFormat {
+		return protoResponse("transferLogs", pb.NewTransferLogs(transferLogs))
+	}
+
+	// convert transfer logs to bytes for return
+	response, err := json.Marshal(transferLogs)
+	if err != nil {
+		return shim.Error("failed to Marshal transferLog, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// ListTokens is query function
+// params - page size, bookmark
+// Returns one page of token metadata in the channel
+func (cc *Controller) ListTokens(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	pageSize, bookmark := params[0], params[1]
+
+	// check page size is integer in [1, MaxInt32]
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// get token list
+	page, err := repository.GetTokenList(stub, int32(pageSizeInt), bookmark)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert page to bytes for return
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal tokenPage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// QueryBalances is query function
+// params - start address, end address, page size, bookmark, [minBalance]
+// Returns one page of address & balance pairs in [start address, end address) with balance >= minBalance
+// The empty addresses leave the range open, the empty bookmark starts from the start address
+// pageSize balance keys are read per page whatever the filter keeps, so a sparse filter costs
+// about (accounts in range / pageSize) queries to walk with the returned bookmark
+// A page cut short by an iterator error is marked incomplete (see model.PartialResult)
+func (cc *Controller) QueryBalances(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4 or 5
+	if len(params) != 4 && len(params) != 5 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	startAddress, endAddress, pageSize, bookmark := params[0], params[1], params[2], params[3]
+
+	// check page size is integer in [1, MaxInt32]
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check minBalance is non-negative integer, if given
+	var minBalance *big.Int
+	if len(params) == 5 {
+		minBalance, err = util.ParseBigBalance("minBalance", params[4])
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// get balances in range
+	page, err := repository.GetBalanceRange(stub, startAddress, endAddress, minBalance, int32(pageSizeInt), bookmark)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert page to bytes for return
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal balancePage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// balanceScanPageSize is the page size of full balance scans
+const balanceScanPageSize = 100
+
+// CheckInvariant is query function
+// params - tokenID
+// Returns the report whether the sum of all balances & locked escrows equals the total supply
+// All balances are scanned page by page, so the cost grows with the number of accounts
+func (cc *Controller) CheckInvariant(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameter")
+	}
+
+	tokenID := params[0]
+
+	// get total supply
+	totalSupply, err := repository.GetERC20TotalSupply(stub, tokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// sum all balances & locked escrows
+	report := model.InvariantReport{TotalSupply: totalSupply}
+	report.BalanceSum, report.Accounts, err = sumBalances(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	report.Escrowed, err = sumLockedEscrows(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	report.Match = util.AddBigBalance(report.BalanceSum, report.Escrowed).Cmp(totalSupply) == 0
+
+	// convert report to bytes for return
+	response, err := json.Marshal(report)
+	if err != nil {
+		return shim.Error("failed to Marshal invariantReport, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// ReconcileReport is query function
+// params - pageSize, bookmark, partialSum
+// Returns one page of address & balance pairs with the running partial sum, the incremental checkInvariant
+// for tokens with too many accounts to scan in one query. To drive it to completion, start with the empty
+// bookmark and "0" partialSum, then pass the returned bookmark & partialSum until last is true;
+// the last page compares the partial sum plus the locked escrows with the total supply
+// Each page is a separate query at its own ledger height, so transfers between pages can skew the sum
+func (cc *Controller) ReconcileReport(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	pageSize, bookmark, partialSum := params[0], params[1], params[2]
+
+	// check page size is integer in [1, MaxInt32], partialSum is non-negative integer
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	partialSumBig, err := util.ParseBigBalance("partialSum", partialSum)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	balances, nextBookmark, err := repository.GetBalancePage(stub, int32(pageSizeInt), bookmark)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// add the page to the running sum
+	page := model.ReconcilePage{Balances: balances, PageSum: big.NewInt(0), Bookmark: nextBookmark, Last: len(nextBookmark) == 0}
+	for _, balance := range balances {
+		page.PageSum = util.AddBigBalance(page.PageSum, balance.Balance)
+	}
+	page.PartialSum = util.AddBigBalance(partialSumBig, page.PageSum)
+
+	// the last page compares the sum with the total supply like checkInvariant
+	if page.Last {
+		erc20Metadata, err := repository.GetTokenMetadata(stub)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		page.TotalSupply = erc20Metadata.GetTotalSupply()
+		page.Escrowed, err = sumLockedEscrows(stub)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		page.Match = util.AddBigBalance(page.PartialSum, page.Escrowed).Cmp(page.TotalSupply) == 0
+	}
+
+	// convert page to bytes for return
+	response, err := json.Marshal(page)
+	if err != nil {
+		return shim.Error("failed to Marshal reconcilePage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// sumBalances returns the sum of all balances and the number of accounts
+// All balances are scanned page by page
+func sumBalances(stub shim.ChaincodeStubInterface) (*big.Int, int, error) {
+	sum, accounts := big.NewInt(0), 0
+	bookmark := ""
+	for {
+		balances, nextBookmark, err := repository.GetBalancePage(stub, balanceScanPageSize, bookmark)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, balance := range balances {
+			sum = util.AddBigBalance(sum, balance.Balance)
+			accounts++
+		}
+
+		if len(nextBookmark) == 0 {
+			return sum, accounts, nil
+		}
+		bookmark = nextBookmark
+	}
+}
+
+// AccountInfo is query function
+// params - address
+// Returns the account-created marker (created timestamp & first-seen txID) of address
+func (cc *Controller) AccountInfo(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address := params[0]
+
+	// get account info
+	accountInfo, err := repository.GetAccountInfo(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if accountInfo == nil {
+		return util.NotFound("account info of " + address + " is not found")
+	}
+	accountInfo.Label, err = repository.GetLabel(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert account info to bytes for return
+	response, err := json.Marshal(accountInfo)
+	if err != nil {
+		return shim.Error("failed to Marshal accountInfo, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// StateSchema is query function
+// params - none
+// Returns the key layout & value format of every state (see repository.StateSchema), no state is read
+func (cc *Controller) StateSchema(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// convert schema to bytes for return
+	response, err := json.Marshal(repository.StateSchema)
+	if err != nil {
+		return shim.Error("failed to Marshal stateSchema, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// VerifyNamespaceIntegrity is query function for owner
+// params - tokenID, caller's address
+// Returns the keys matching no layout of stateSchema (see repository.GetNamespaceReport), e.g. a bare address
+// or token key left by the shared namespace before composite keys, empty when the state is consistent
+func (cc *Controller) VerifyNamespaceIntegrity(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	tokenID, callerAddress := params[0], params[1]
+
+	// only owner can scan the state
+	_, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	report, err := repository.GetNamespaceReport(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert report to bytes for return
+	response, err := json.Marshal(report)
+	if err != nil {
+		return shim.Error("failed to Marshal namespaceReport, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// SpendableBalanceOf is query function
+// params - address
+// Returns the portion of balance transferable right now, the balance capped by
+// the amount left in the current rate limit window, 0 while the cooldown is active. Escrowed amounts are already
+// debited from the balance by conditionalTransfer, so they are never spendable
+func (cc *Controller) SpendableBalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address := params[0]
+
+	// get balance
+	spendable, err := repository.GetBalance(stub, address, true)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// cap by the rate limit
+	remaining, err := remainingRateLimit(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if remaining != nil && remaining.Cmp(spendable) < 0 {
+		spendable = remaining
+	}
+
+	// nothing is spendable while the cooldown is active
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if erc20Metadata.TransferCooldownSeconds > 0 {
+		txSeconds, err := getTxSeconds(stub)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		retryAfter, err := cooldownRetryAfter(stub, address, erc20Metadata.TransferCooldownSeconds)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		if txSeconds < retryAfter {
+			spendable = big.NewInt(0)
+		}
+	}
+
+	return shim.Success([]byte(spendable.String()))
+}
+
+// EstimateFee is query function
+// params - amount of token
+// Returns the fee & net amount the recipient would receive (see model.FeeEstimate) for UIs to show before
+// the user confirms. transfer charges no fee, so the fee is zero & the net is the full amount
+func (cc *Controller) EstimateFee(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	// check amount is integer & positive
+	amount, err := util.ConvertToBigPositive("amount", params[0])
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	estimate := model.FeeEstimate{Fee: "0", Net: util.FormatBigBalance(amount)}
+
+	// convert estimate to bytes for return
+	response, err := json.Marshal(estimate)
+	if err != nil {
+		return shim.Error("failed to Marshal feeEstimate, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// SimulateTransfer is query function
+// params - caller's address, recipient's address, amount of token
+// Returns the result of each check transfer would run (see model.TransferSimulation) without writing state,
+// the pre-flight call of wallets. Maintenance rejects the query itself, like the transfer
+// The cooldown & rate limit are read rather than checked, as their checkers record the transfer
+func (cc *Controller) SimulateTransfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2]
+
+	// caller & recipient are required, whitespace only counts as empty
+	if len(strings.TrimSpace(callerAddress)) == 0 {
+		return util.BadRequest("caller address is required")
+	}
+	if len(strings.TrimSpace(recipientAddress)) == 0 {
+		return util.BadRequest("recipient address is required")
+	}
+
+	// check amount is integer & positive
+	transferAmountBig, err := util.ConvertToBigPositive("transferAmount", transferAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	simulation := model.TransferSimulation{NetAmount: transferAmountBig, Reasons: []string{}}
+	fail := func(reason string) bool {
+		simulation.Reasons = append(simulation.Reasons, reason)
+		return false
+	}
+	pass := func(err error) bool {
+		if err != nil {
+			return fail(err.Error())
+		}
+		return true
+	}
+
+	// circuit breaker
+	simulation.Checks.NotPaused = pass(checkPaused(stub))
+
+	// supply
+	simulation.Checks.Supply = erc20Metadata.GetTotalSupply().Sign() != 0 || fail("totalSupply of "+erc20Metadata.ID+" is zero, transfer is rejected")
+
+	// balance
+	callerBalance, err := repository.GetBalance(stub, callerAddress, true)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	_, err = util.SubBigBalance("sender's balance", callerBalance, transferAmountBig)
+	simulation.Checks.Balance = pass(err)
+
+	// cooldown
+	simulation.Checks.Cooldown = true
+	if erc20Metadata.TransferCooldownSeconds > 0 {
+		retryAfter, err := cooldownRetryAfter(stub, callerAddress, erc20Metadata.TransferCooldownSeconds)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		simulation.Checks.Cooldown = txSeconds >= retryAfter || fail(fmt.Sprintf("cooldown active, retry after %d", retryAfter))
+	}
+
+	// rate limit
+	remaining, err := remainingRateLimit(stub, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	simulation.Checks.RateLimit = remaining == nil || remaining.Cmp(transferAmountBig) >= 0 || fail(fmt.Sprintf("rate limit exceeded, %s can transfer %s more in the current window", callerAddress, remaining.String()))
+
+	// the burn address burns instead of crediting, so the recipient checks don't apply
+	if erc20Metadata.IsBurnAddress(recipientAddress) {
+		simulation.NetAmount = big.NewInt(0)
+		simulation.Checks.Verified = true
+		simulation.Checks.Whitelist = true
+	} else {
+		simulation.Checks.Verified = pass(checkRecipientLabel(stub, recipientAddress, transferAmountBig))
+		simulation.Checks.Whitelist = pass(checkRecipientAllowed(stub, recipientAddress))
+	}
+	simulation.Passes = len(simulation.Reasons) == 0
+
+	// convert simulation to bytes for return
+	response, err := json.Marshal(simulation)
+	if err != nil {
+		return shim.Error("failed to Marshal transferSimulation, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// HasActivity is query function
+// params - address
+// Returns JSON boolean whether the address has ever held or moved tokens
+func (cc *Controller) HasActivity(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address := params[0]
+
+	// check the history of balance
+	hasActivity, err := repository.HasBalanceHistory(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert boolean to bytes for return
+	response, err := json.Marshal(hasActivity)
+	if err != nil {
+		return shim.Error("failed to Marshal hasActivity, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// tokenScanPageSize is the page size of full token scans
+const tokenScanPageSize = 100
+
+// IsNameAvailable is query function
+// params - name
+// Returns whether no token uses name as its tokenID (metadata key), name or symbol
+// There is no name or symbol index, so all token metadata is scanned page by page
+func (cc *Controller) IsNameAvailable(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	name := params[0]
+
+	// name cannot be empty
+	if len(name) == 0 {
+		return util.BadRequest("name cannot be empty")
+	}
+
+	// compare name with each token
+	available := true
+	bookmark := ""
+	for available {
+		page, err := repository.GetTokenList(stub, tokenScanPageSize, bookmark)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		if page.Incomplete {
+			return util.StatusResponse(model.StatusInternalError, "failed to scan tokens, error: "+page.Error)
+		}
+		for _, token := range page.Tokens {
+			if token.ID == name || token.Name == name || token.Symbol == name {
+				available = false
+				break
+			}
+		}
+
+		if len(page.Bookmark) == 0 {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	// convert boolean to bytes for return
+	response, err := json.Marshal(available)
+	if err != nil {
+		return shim.Error("failed to Marshal available, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// maxTopHolders is the max n of topHolders
+const maxTopHolders = 1000
+
+// TopHolders is query function
+// params - n
+// Returns the n accounts with the largest balances sorted by balance (descending)
+// All balances are scanned page by page, so the cost grows with the number of accounts
+func (cc *Controller) TopHolders(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	// n must be positive & not larger than maxTopHolders
+	n, err := util.ConvertToPositive("n", params[0])
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if *n > maxTopHolders {
+		return util.BadRequest(fmt.Sprintf("n cannot be larger than %d", maxTopHolders))
+	}
+
+	// keep the running top n
+	holders := []model.AccountBalance{}
+	bookmark := ""
+	for {
+		balances, nextBookmark, err := repository.GetBalancePage(stub, balanceScanPageSize, bookmark)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		for _, balance := range balances {
+			holders = insertTopHolder(holders, balance, *n)
+		}
+
+		if len(nextBookmark) == 0 {
+			break
+		}
+		bookmark = nextBookmark
+	}
+
+	// convert holders to bytes for return
+	response, err := json.Marshal(holders)
+	if err != nil {
+		return shim.Error("failed to Marshal holders, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// insertTopHolder inserts balance into holders sorted by balance (descending, ties by address)
+// and keeps at most n holders
+func insertTopHolder(holders []model.AccountBalance, balance model.AccountBalance, n int) []model.AccountBalance {
+	index := sort.Search(len(holders), func(i int) bool {
+		cmp := holders[i].Balance.Cmp(balance.Balance)
+		return cmp < 0 || (cmp == 0 && holders[i].Address > balance.Address)
+	})
+	if index >= n {
+		return holders
+	}
+
+	holders = append(holders, model.AccountBalance{})
+	copy(holders[index+1:], holders[index:])
+	holders[index] = balance
+	if len(holders) > n {
+		holders = holders[:n]
+	}
+
+	return holders
+}
+
+// AllowanceHistory is query function
+// params - owner's address, spender's address
+// Returns the changes of allowance (txID, timestamp, allowance) in chronological order
+func (cc *Controller) AllowanceHistory(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	ownerAddress, spenderAddress := params[0], params[1]
+
+	// get allowance history
+	changes, err := repository.GetAllowanceHistory(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert changes to bytes for return
+	response, err := json.Marshal(changes)
+	if err != nil {
+		return shim.Error("failed to Marshal allowanceHistory, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// BalanceOfAtTime is query function
+// params - address, timestamp (unix seconds)
+// Returns the balance of address which was current at or before timestamp, "0" if address had no balance yet
+// It reads the key history, so the peers need the history database enabled (ledger.history.enableHistoryDatabase)
+func (cc *Controller) BalanceOfAtTime(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of parameters")
+	}
+
+	address, at := params[0], params[1]
+
+	atInt, err := util.ParseDecimalInt("timestamp", at, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	amountBig, err := repository.GetBalanceAtTime(stub, address, atInt)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(util.FormatBigBalance(amountBig)))
+}
+
+// Encodings of the query responses selected by the optional format param
+const (
+	formatJSON  = "format=json"
+	formatProto = "format=proto"
+)
+
+// parseFormat returns whether the optional format param (none or one) selects protobuf,
+// "format=json" is the default and "format=proto" encodes the pb message mirroring the JSON (see pb/erc20.proto)
+// Only balanceOf, getMetadata, recentTransfers & eventByTxId take it, events stay JSON for the listeners
+func parseFormat(params []string) (bool, error) {
+	if len(params) == 0 {
+		return false, nil
+	}
+
+	switch params[0] {
+	case formatJSON:
+		return false, nil
+	case formatProto:
+		return true, nil
+	}
+	return false, model.NewStatusError(model.StatusBadRequest, "unknown format "+params[0]+", must be "+formatJSON+" or "+formatProto)
+}
+
+// protoResponse returns message of name encoded in protobuf as payload
+func protoResponse(name string, message proto.Message) sc.Response {
+	response, err := proto.Marshal(message)
+	if err != nil {
+		return shim.Error("failed to Marshal " + name + ", error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
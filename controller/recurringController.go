@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"math"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SetRecurringAllowance is invoke function that sets amount as the allowance of spender over the owner tokens
+// and resets it to amount at the start of every period (e.g. subscription billing)
+// The first period starts at the transaction, the next ones every period seconds after it.
+// The unused allowance of a period is not carried over, and the reset happens lazily:
+// allowance returns amount once a new period started, the first spend of the period saves the reset.
+// approve & revokeAllAllowances replace the recurring allowance, "0" period stops it keeping the current allowance
+// params - owner's address, spender's address, amount, period(seconds)
+func (cc *Controller) SetRecurringAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	ownerAddress, spenderAddress, amount, period := params[0], params[1], params[2], params[3]
+
+	// owner cannot approve itself
+	err := validateAllowanceParties(ownerAddress, spenderAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// check amount is non-negative integer & period is non-negative seconds
+	amountBig, err := util.ParseBigBalance("amount", amount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	periodInt, err := util.ParseDecimalInt("period", period, 0, math.MaxInt64)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// "0" period stops resetting
+	if periodInt == 0 {
+		err = repository.DeleteRecurringAllowance(stub, ownerAddress, spenderAddress)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		return shim.Success([]byte("setRecurringAllowance success"))
+	}
+
+	// in strict mode, allowance cannot exceed the owner's balance
+	err = validateAllowanceCap(stub, ownerAddress, spenderAddress, amountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save the recurring allowance starting now & the allowance of the first period
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.SaveRecurringAllowance(stub, ownerAddress, spenderAddress, model.NewRecurringAllowance(amountBig, periodInt, txSeconds))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, util.FormatBigBalance(amountBig))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit approval event
+	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, amountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setRecurringAllowance success"))
+}
+
+// dueRecurringAllowance returns the recurring allowance of spender moved to the current period
+// when a new period started since its LastReset, nil when there is none or the period didn't change
+func dueRecurringAllowance(stub shim.ChaincodeStubInterface, ownerAddress, spenderAddress string) (*model.RecurringAllowance, error) {
+	recurring, err := repository.GetRecurringAllowance(stub, ownerAddress, spenderAddress)
+	if err != nil || recurring == nil {
+		return nil, err
+	}
+
+	txSeconds, err := getTxSeconds(stub)
+	if err != nil {
+		return nil, err
+	}
+	periodStart := recurring.CurrentPeriodStart(txSeconds)
+	if periodStart == recurring.LastReset {
+		return nil, nil
+	}
+
+	recurring.LastReset = periodStart
+	return recurring, nil
+}
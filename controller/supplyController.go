@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// CirculatingSupply is query function
+// params - tokenID
+// Returns the total supply minus the balances of excluded addresses (e.g. owner treasury & reserve)
+// and the locked escrows, exchanges report it rather than the total supply
+func (cc *Controller) CirculatingSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameter")
+	}
+
+	tokenID := params[0]
+
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// subtract the balances of excluded addresses
+	circulatingSupply := new(big.Int).Set(erc20Metadata.GetTotalSupply())
+	for _, excludedAddress := range erc20Metadata.GetExcludedAddresses() {
+		balance, err := repository.GetBalance(stub, excludedAddress, true)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		circulatingSupply.Sub(circulatingSupply, balance)
+	}
+
+	// subtract the locked escrows
+	escrowed, err := sumLockedEscrows(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	circulatingSupply.Sub(circulatingSupply, escrowed)
+
+	// convert circulating supply to bytes for return
+	response, err := json.Marshal(circulatingSupply)
+	if err != nil {
+		return shim.Error("failed to Marshal circulatingSupply, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// RemainingMintable is query function
+// params - tokenID
+// Returns how much more can be minted, cap - totalSupply as decimal string or model.UnlimitedMintable
+// when the token has no cap (see model.ERC20Metadata.GetCap), "0" when supply somehow exceeds the cap
+func (cc *Controller) RemainingMintable(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return util.BadRequest("incorrect number of parameter")
+	}
+
+	erc20Metadata, err := repository.GetERC20Metadata(stub, params[0])
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(util.RemainingBelowCap(erc20Metadata.GetCap(), erc20Metadata.GetTotalSupply())))
+}
+
+// AddExcludedAddress is invoke function that excludes the balance of address from the circulating supply by owner
+// params - tokenID, caller's address, excluded address
+func (cc *Controller) AddExcludedAddress(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, excludedAddress := params[0], params[1], params[2]
+
+	// excluded address must be a valid, non-zero address
+	err := checkNonZeroAddress("excluded address", excludedAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can add excluded address
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// excluded address cannot be duplicated
+	if erc20Metadata.IsExcludedAddress(excludedAddress) {
+		return util.Conflict(excludedAddress + " is already excluded")
+	}
+
+	// save metadata with new excluded set
+	oldExcluded := strings.Join(erc20Metadata.GetExcludedAddresses(), ",")
+	erc20Metadata.ExcludedAddresses = append(erc20Metadata.GetExcludedAddresses(), excludedAddress)
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "excludedAddresses", oldExcluded, strings.Join(erc20Metadata.GetExcludedAddresses(), ","))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("addExcludedAddress success"))
+}
+
+// RemoveExcludedAddress is invoke function that counts the balance of address in the circulating supply again by owner
+// params - tokenID, caller's address, excluded address
+func (cc *Controller) RemoveExcludedAddress(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, excludedAddress := params[0], params[1], params[2]
+
+	// excluded address must be a valid, non-zero address
+	err := checkNonZeroAddress("excluded address", excludedAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can remove excluded address
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// excluded address must be in the excluded set
+	if !erc20Metadata.IsExcludedAddress(excludedAddress) {
+		return util.Conflict(excludedAddress + " is not excluded")
+	}
+
+	// save metadata with new excluded set
+	oldExcluded := strings.Join(erc20Metadata.GetExcludedAddresses(), ",")
+	excludedAddresses := []string{}
+	for _, address := range erc20Metadata.GetExcludedAddresses() {
+		if address != excludedAddress {
+			excludedAddresses = append(excludedAddresses, address)
+		}
+	}
+	erc20Metadata.ExcludedAddresses = excludedAddresses
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "excludedAddresses", oldExcluded, strings.Join(excludedAddresses, ","))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("removeExcludedAddress success"))
+}
+
+// SetBurnAddress is invoke function that sets the burn address of token by owner
+// Transfers to the burn address reduce TotalSupply instead of crediting it, "" disables
+// An address holding a balance is rejected, as that balance would stay in TotalSupply but never move again
+// params - tokenID, caller's address, burn address
+func (cc *Controller) SetBurnAddress(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, burnAddress := params[0], params[1], params[2]
+
+	// burn address must be a valid address unless it is disabled
+	if len(burnAddress) != 0 {
+		err := util.ValidateAddress("burn address", burnAddress)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// only owner can change burn address
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// transfers to owner cannot burn
+	if burnAddress == erc20Metadata.Owner {
+		return util.BadRequest("burn address cannot be the owner")
+	}
+
+	// burn address cannot hold a balance
+	if len(burnAddress) != 0 {
+		balance, err := repository.GetBalance(stub, burnAddress, true)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		if balance.Sign() != 0 {
+			return util.Conflict(burnAddress + " holds a balance, it cannot be the burn address")
+		}
+	}
+
+	// save metadata with new burn address
+	oldBurnAddress := erc20Metadata.BurnAddress
+	erc20Metadata.BurnAddress = burnAddress
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "burnAddress", oldBurnAddress, burnAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setBurnAddress success"))
+}
+
+// ReconcileSupply is invoke function that sets TotalSupply to the sum of all balances & locked escrows by owner
+// It is a recovery tool for supply drifted by an accounting bug, checkInvariant reports the drift first.
+// Use with caution: a wrong balance becomes part of the supply, the supplyReconciled event keeps the audit trail
+// All balances are scanned page by page in one transaction, so the cost grows with the number of accounts
+// params - tokenID, caller's address
+// Returns the old & new supply (see model.SupplyReconciledEvent)
+func (cc *Controller) ReconcileSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress := params[0], params[1]
+
+	// only owner can reconcile supply
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// sum all balances & locked escrows as checkInvariant does
+	balanceSum, _, err := sumBalances(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	escrowed, err := sumLockedEscrows(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with the reconciled supply
+	reconciledEvent := model.NewSupplyReconciledEvent(tokenID, erc20Metadata.GetTotalSupply(), util.AddBigBalance(balanceSum, escrowed))
+	erc20Metadata.TotalSupply = reconciledEvent.NewSupply
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit supply reconciled event
+	err = repository.EmitSupplyReconciledEvent(stub, reconciledEvent)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert event to bytes for return
+	response, err := json.Marshal(reconciledEvent)
+	if err != nil {
+		return shim.Error("failed to Marshal supplyReconciledEvent, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// MintTo is invoke function that creates amount tokens for recipient like mint by owner or minters
+// The recipient cannot be the burn address or a zero address (empty or only zeros, e.g. "0x0"),
+// which would lose the minted amount
+// Emits one mintEvent combining the credit of recipient & the new total supply (see model.MintEvent)
+// instead of the transfer event of mint, skipped when events are disabled
+// params - tokenID, caller's address, recipient's address, amount
+func (cc *Controller) MintTo(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, recipientAddress, mintAmount := params[0], params[1], params[2], params[3]
+
+	// recipient cannot be a zero address
+	if isZeroAddress(recipientAddress) {
+		return util.BadRequest("recipient cannot be a zero address")
+	}
+
+	// amount must be positive
+	mintAmountBig, err := util.ConvertToBigPositive("mintAmount", mintAmount)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner or minters can mint
+	erc20Metadata, err := assertMinter(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// recipient cannot be the burn address
+	if erc20Metadata.IsBurnAddress(recipientAddress) {
+		return util.BadRequest("recipient cannot be the burn address")
+	}
+
+	// increase TotalSupply & the recipient balance
+	oldSupply := erc20Metadata.GetTotalSupply()
+	err = mint(stub, tokenID, erc20Metadata, recipientAddress, mintAmountBig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit mint event (skipped when events are disabled)
+	if erc20Metadata.IsEmitEvents() {
+		mintEvent := model.NewMintEvent(tokenID, callerAddress, recipientAddress, mintAmountBig, oldSupply, erc20Metadata.GetTotalSupply(), erc20Metadata.Decimals)
+		err = repository.EmitMintEvent(stub, mintEvent)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	return shim.Success([]byte("mintTo success"))
+}
+
+// checkNonZeroAddress checks address passes util.ValidateAddress and is not a zero address
+func checkNonZeroAddress(name, address string) error {
+	err := util.ValidateAddress(name, address)
+	if err != nil {
+		return err
+	}
+	if isZeroAddress(address) {
+		return model.NewStatusError(model.StatusBadRequest, name+" cannot be a zero address")
+	}
+
+	return nil
+}
+
+// isZeroAddress returns whether address is empty or only zeros with an optional "0x" prefix
+func isZeroAddress(address string) bool {
+	address = strings.TrimPrefix(strings.TrimSpace(address), "0x")
+	return len(strings.Trim(address, "0")) == 0
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// AtomicSwap is invoke function that moves amountA of this token from party1 to party2
+// and amountB of tokenB (other chaincode on the channel) from party2 to party1 in one transaction
+// Both parties consent by allowance, party1 approves party2 for amountA on this token
+// and party2 approves party1 for amountB on tokenB. The failure of either leg aborts the whole transaction
+// params - party1's address, party2's address, amountA, chaincodeName of tokenB, tokenID of tokenB, amountB
+func (cc *Controller) AtomicSwap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 6
+	if len(params) != 6 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	party1, party2, amountA, chaincodeName, tokenIDB, amountB := params[0], params[1], params[2], params[3], params[4], params[5]
+
+	// parties must be distinct & amounts must be positive
+	if len(party1) == 0 || len(party2) == 0 || party1 == party2 {
+		return util.BadRequest("parties cannot be empty or the same")
+	}
+	amountABig, err := util.ConvertToBigPositive("amountA", amountA)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	amountBBig, err := util.ConvertToBigPositive("amountB", amountB)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if len(chaincodeName) == 0 || len(tokenIDB) == 0 {
+		return util.BadRequest("chaincode name or tokenID of tokenB cannot be empty")
+	}
+
+	// both tokens must exist
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	metadataResponse := stub.InvokeChaincode(chaincodeName, [][]byte{[]byte("getMetadata"), []byte(tokenIDB)}, stub.GetChannelID())
+	if metadataResponse.GetStatus() >= 400 {
+		return util.StatusResponse(metadataResponse.GetStatus(), fmt.Sprintf("failed to get metadata of %s, error: %s", chaincodeName, metadataResponse.GetMessage()))
+	}
+
+	// leg A - party2 spends the allowance of party1 on this token
+	_, err = cc.spendAllowance(stub, party1, party2, amountABig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	_, _, err = transfer(stub, party1, party2, amountABig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// leg B - party1 spends the allowance of party2 on tokenB
+	args := [][]byte{[]byte("transferFrom"), []byte(party2), []byte(party1), []byte(party1), []byte(amountB)}
+	transferResponse := stub.InvokeChaincode(chaincodeName, args, stub.GetChannelID())
+	if transferResponse.GetStatus() >= 400 {
+		return util.StatusResponse(transferResponse.GetStatus(), fmt.Sprintf("failed to transfer %s, error: %s", chaincodeName, transferResponse.GetMessage()))
+	}
+
+	// emit one swap event summarizing both legs
+	err = repository.EmitSwapEvent(stub, model.NewSwapEvent(party1, party2, *erc20Metadata.GetID(), amountABig, tokenIDB, amountBBig))
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// count the volume of leg A in circuit breaker, last since tripping replaces the event
+	// (leg B is counted by the transferFrom of tokenB)
+	err = recordTransferVolume(stub, amountABig)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("atomicSwap success"))
+}
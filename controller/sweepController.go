@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Sweep is invoke function that consolidates one page of dust balances into collector by owner,
+// for closing out dormant accounts. Each positive balance below threshold is moved to collector
+// and its key is deleted, one sweep event lists the swept accounts of the page
+// The collector is credited with each swept balance through creditRecipient like a transfer recipient,
+// and the txlog of each swept holder & the collector indexes the movement
+// params - tokenID, caller's address, collector's address, threshold, pageSize, bookmark
+// Returns the number of swept accounts, the swept total and the next bookmark
+func (cc *Controller) Sweep(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 6
+	if len(params) != 6 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, collectorAddress, threshold, pageSize, bookmark := params[0], params[1], params[2], params[3], params[4], params[5]
+
+	// collector must be a well-formed address, threshold & page size must be positive
+	err := util.ValidateAddress("collector", collectorAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	thresholdBig, err := util.ConvertToBigPositive("threshold", threshold)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	pageSizeInt, err := util.ParseDecimalInt("pageSize", pageSize, 1, math.MaxInt32)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// only owner can sweep
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// the burn address is never credited
+	if erc20Metadata.IsBurnAddress(collectorAddress) {
+		return util.BadRequest("collector cannot be the burn address")
+	}
+
+	balances, nextBookmark, err := repository.GetBalancePage(stub, int32(pageSizeInt), bookmark)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// delete each dust balance of the page, the collector's own balance is kept
+	swept := []model.AccountBalance{}
+	total := big.NewInt(0)
+	for _, balance := range balances {
+		if balance.Address == collectorAddress || balance.Balance.Sign() == 0 || balance.Balance.Cmp(thresholdBig) >= 0 {
+			continue
+		}
+		err = repository.DeleteBalance(stub, balance.Address)
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+		swept = append(swept, balance)
+		total = util.AddBigBalance(total, balance.Balance)
+	}
+
+	if len(swept) > 0 {
+		// credit collector with each swept balance, GetState doesn't read the writes of the same transaction,
+		// so each credit adds to the balance written by the previous one
+		var collectorBalance *big.Int
+		for i, balance := range swept {
+			collectorBalance, err = creditRecipient(stub, balance.Address, collectorAddress, balance.Balance, collectorBalance, i)
+			if err != nil {
+				return util.ErrorResponse(err)
+			}
+		}
+
+		// emit one sweep event for the page
+		err = repository.EmitSweepEvent(stub, model.NewSweepEvent(tokenID, callerAddress, collectorAddress, swept, total))
+		if err != nil {
+			return util.ErrorResponse(err)
+		}
+	}
+
+	// convert result to bytes for return
+	response, err := json.Marshal(model.SweepResult{Swept: len(swept), Total: total, Bookmark: nextBookmark})
+	if err != nil {
+		return shim.Error("failed to Marshal sweepResult, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
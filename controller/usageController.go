@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// MeterUsage records the invocation of function when owner enabled usage metering
+// Only committed transactions are counted, so queries evaluated without submit are not
+func (cc *Controller) MeterUsage(stub shim.ChaincodeStubInterface, erc20Metadata *model.ERC20Metadata, function string) error {
+	if !erc20Metadata.UsageMetering {
+		return nil
+	}
+
+	return repository.SaveUsage(stub, function)
+}
+
+// SetUsageMetering is invoke function that enables or disables usage metering by owner
+// Metering adds one write to every transaction, but no MVCC conflict (see repository.SaveUsage)
+// params - tokenID, caller's address, usageMetering("true" or "false")
+func (cc *Controller) SetUsageMetering(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, usageMetering := params[0], params[1], params[2]
+
+	// usageMetering must be true or false
+	if usageMetering != "true" && usageMetering != "false" {
+		return util.BadRequest("usageMetering must be true or false")
+	}
+
+	// only owner can change usageMetering
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new usageMetering
+	oldUsageMetering := strconv.FormatBool(erc20Metadata.UsageMetering)
+	erc20Metadata.UsageMetering = usageMetering == "true"
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "usageMetering", oldUsageMetering, usageMetering)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setUsageMetering success"))
+}
+
+// UsageStats is query function
+// params - none
+// Returns the number of invocations per function recorded while usage metering was enabled (JSON map)
+// All-or-nothing, an iterator error fails the call (see model.PartialResult)
+func (cc *Controller) UsageStats(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	stats, err := repository.GetUsageStats(stub)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// convert stats to bytes for return
+	response, err := json.Marshal(stats)
+	if err != nil {
+		return shim.Error("failed to Marshal usageStats, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SetWhitelistMode is invoke function that enables or disables the recipient whitelist by owner
+// In whitelist mode, transfers only reach owner and the addresses added by addAllowed (e.g. closed-loop campus cards)
+// params - tokenID, caller's address, whitelistMode("true" or "false")
+func (cc *Controller) SetWhitelistMode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, whitelistMode := params[0], params[1], params[2]
+
+	// whitelistMode must be true or false
+	if whitelistMode != "true" && whitelistMode != "false" {
+		return util.BadRequest("whitelistMode must be true or false")
+	}
+
+	// only owner can change whitelist mode
+	erc20Metadata, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// save metadata with new whitelist mode
+	oldWhitelistMode := strconv.FormatBool(erc20Metadata.WhitelistMode)
+	erc20Metadata.WhitelistMode = whitelistMode == "true"
+	err = saveMetadata(stub, tokenID, erc20Metadata)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// emit metadata updated event
+	err = repository.EmitMetadataUpdatedEvent(stub, tokenID, "whitelistMode", oldWhitelistMode, whitelistMode)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte("setWhitelistMode success"))
+}
+
+// AddAllowed is invoke function that adds address to the recipient whitelist by owner
+// params - tokenID, caller's address, address
+func (cc *Controller) AddAllowed(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.saveAllowed(stub, params, true, "addAllowed")
+}
+
+// RemoveAllowed is invoke function that removes address from the recipient whitelist by owner
+// params - tokenID, caller's address, address
+func (cc *Controller) RemoveAllowed(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.saveAllowed(stub, params, false, "removeAllowed")
+}
+
+// saveAllowed adds or removes address of params to the recipient whitelist after the owner check
+func (cc *Controller) saveAllowed(stub shim.ChaincodeStubInterface, params []string, allowed bool, function string) sc.Response {
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return util.BadRequest("incorrect number of params")
+	}
+
+	tokenID, callerAddress, address := params[0], params[1], params[2]
+
+	// address cannot be empty
+	if len(address) == 0 {
+		return util.BadRequest("address cannot be empty")
+	}
+
+	// only owner can manage whitelist
+	_, err := assertOwner(stub, tokenID, callerAddress)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	// adding twice or removing a missing address is a mistake of client
+	isAllowed, err := repository.IsAllowed(stub, address)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+	if isAllowed && allowed {
+		return util.Conflict(address + " is already allowed")
+	}
+	if !isAllowed && !allowed {
+		return util.Conflict(address + " is not allowed")
+	}
+
+	// save whitelist of address
+	err = repository.SaveAllowed(stub, address, allowed)
+	if err != nil {
+		return util.ErrorResponse(err)
+	}
+
+	return shim.Success([]byte(function + " success"))
+}
+
+// checkRecipientAllowed returns error in whitelist mode if recipient is neither owner nor in the whitelist
+func checkRecipientAllowed(stub shim.ChaincodeStubInterface, recipientAddress string) error {
+	erc20Metadata, err := repository.GetTokenMetadata(stub)
+	if err != nil {
+		return err
+	}
+	if !erc20Metadata.WhitelistMode || recipientAddress == erc20Metadata.Owner {
+		return nil
+	}
+
+	isAllowed, err := repository.IsAllowed(stub, recipientAddress)
+	if err != nil {
+		return err
+	}
+	if !isAllowed {
+		return model.NewStatusError(model.StatusForbidden, "recipient "+recipientAddress+" is not in the whitelist of "+erc20Metadata.ID)
+	}
+
+	return nil
+}
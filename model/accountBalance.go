@@ -0,0 +1,57 @@
+package model
+
+import "math/big"
+
+// AccountBalance is the definition of address & balance pair
+type AccountBalance struct {
+	Address string   `json:"address"`
+	Balance *big.Int `json:"balance"`
+}
+
+// BalancePage is the definition of queryBalances response format
+type BalancePage struct {
+	Balances []AccountBalance `json:"balances"`
+	Bookmark string           `json:"bookmark"`
+	PartialResult
+}
+
+// InvariantReport is the definition of checkInvariant response format
+// Escrowed is the amount held by locked escrows, which is out of balances but in supply
+type InvariantReport struct {
+	TotalSupply *big.Int `json:"totalSupply"`
+	BalanceSum  *big.Int `json:"balanceSum"`
+	Escrowed    *big.Int `json:"escrowed"`
+	Accounts    int      `json:"accounts"`
+	Match       bool     `json:"match"`
+}
+
+// ReconcilePage is the definition of reconcileReport response format
+// PageSum is the sum of Balances, PartialSum is the given running sum plus PageSum
+// TotalSupply, Escrowed & Match are only set on the Last page, where PartialSum covers every balance
+type ReconcilePage struct {
+	Balances    []AccountBalance `json:"balances"`
+	PageSum     *big.Int         `json:"pageSum"`
+	PartialSum  *big.Int         `json:"partialSum"`
+	Bookmark    string           `json:"bookmark"`
+	Last        bool             `json:"last"`
+	TotalSupply *big.Int         `json:"totalSupply,omitempty"`
+	Escrowed    *big.Int         `json:"escrowed,omitempty"`
+	Match       bool             `json:"match"`
+}
+
+// BalanceAndAllowance is the definition of balanceAndAllowance response format
+// Missing balance or allowance is 0
+type BalanceAndAllowance struct {
+	Balance   *big.Int `json:"balance"`
+	Allowance *big.Int `json:"allowance"`
+}
+
+// ChaincodeBalance is the definition of chaincodeBalanceOf response format, the cross-chaincode contract
+// Balance is the decimal integer string in the smallest unit, as balanceOf returns, so callers parse it without rounding
+// Fields are only ever added, never renamed or removed
+type ChaincodeBalance struct {
+	TokenID  string `json:"tokenId"`
+	Address  string `json:"address"`
+	Balance  string `json:"balance"`
+	Decimals uint8  `json:"decimals"`
+}
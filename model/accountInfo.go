@@ -0,0 +1,20 @@
+package model
+
+// AccountInfo is the definition of account-created marker
+// written when an address receives tokens for the first time
+type AccountInfo struct {
+	Address   string `json:"address"`
+	CreatedAt int64  `json:"createdAt"`
+	FirstTxID string `json:"firstTxId"`
+
+	// Label is the label of address set by owner, filled by the accountInfo query
+	Label string `json:"label,omitempty"`
+}
+
+func NewAccountInfo(address string, createdAt int64, firstTxID string) *AccountInfo {
+	return &AccountInfo{
+		Address:   address,
+		CreatedAt: createdAt,
+		FirstTxID: firstTxID,
+	}
+}
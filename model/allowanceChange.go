@@ -0,0 +1,9 @@
+package model
+
+// AllowanceChange is the definition of an entry of allowanceHistory response format
+type AllowanceChange struct {
+	TxID      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+	Allowance string `json:"allowance"`
+	IsDelete  bool   `json:"isDelete"`
+}
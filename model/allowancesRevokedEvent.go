@@ -0,0 +1,17 @@
+package model
+
+// AllowancesRevokedEvent is the event definition of RevokeAllAllowances
+// Fabric keeps only one event per transaction, so one event lists all spenders whose allowance became zero
+type AllowancesRevokedEvent struct {
+	EventType string   `json:"eventType"`
+	Owner     string   `json:"owner"`
+	Spenders  []string `json:"spenders"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+func NewAllowancesRevokedEvent(owner string, spenders []string) *AllowancesRevokedEvent {
+	return &AllowancesRevokedEvent{
+		Owner:    owner,
+		Spenders: spenders,
+	}
+}
@@ -1,13 +1,20 @@
 package model
 
+import "math/big"
+
 // Approval is the definition of Approval Event & Data format
 type Approval struct {
-	Owner     string `json:"owner"`
-	Spender   string `json:"spender"`
-	Allowance int    `json:"allowance"`
+	// EventType is the base event name, only set in event
+	EventType string   `json:"eventType,omitempty"`
+	Owner     string   `json:"owner"`
+	Spender   string   `json:"spender"`
+	Allowance *big.Int `json:"allowance"`
+
+	// Timestamp is the unix seconds of transaction timestamp, only set in event
+	Timestamp int64 `json:"timestamp,omitempty"`
 }
 
-func NewApproval(owner, spender string, allowance int) *Approval {
+func NewApproval(owner, spender string, allowance *big.Int) *Approval {
 	return &Approval{
 		Owner:     owner,
 		Spender:   spender,
@@ -0,0 +1,25 @@
+package model
+
+import "math/big"
+
+// BalanceMigration is the definition of migrateBalances response format
+// Migrated is the number of balances moved to composite keys in this page,
+// Completed is set once the last page is migrated
+type BalanceMigration struct {
+	Migrated  int    `json:"migrated"`
+	Bookmark  string `json:"bookmark"`
+	Completed bool   `json:"completed"`
+}
+
+// SeedEntry is the definition of an entry of seedBalances params, amount is the balance set to address
+type SeedEntry struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+}
+
+// BalanceSeed is the definition of seedBalances response format
+// TotalSupply is the supply recomputed with the seeded balances
+type BalanceSeed struct {
+	Seeded      int      `json:"seeded"`
+	TotalSupply *big.Int `json:"totalSupply"`
+}
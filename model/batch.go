@@ -0,0 +1,21 @@
+package model
+
+// BatchEntry is the definition of an entry of batch params format (JSON array)
+type BatchEntry struct {
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+}
+
+// BatchTransferEvent is the event definition of transferBatch & mintBatch
+// Fabric keeps only one event per transaction (SetEvent overwrites the previous event),
+// so the movements are aggregated into one event sorted by recipient
+type BatchTransferEvent struct {
+	EventType string          `json:"eventType"`
+	Transfers []TransferEvent `json:"transfers"`
+}
+
+func NewBatchTransferEvent(transfers []TransferEvent) *BatchTransferEvent {
+	return &BatchTransferEvent{
+		Transfers: transfers,
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "math/big"
+
+// BurnEvent is the event definition of transfer to the burn address, which burns amount
+// instead of crediting the burn address
+type BurnEvent struct {
+	EventType   string   `json:"eventType"`
+	Burner      string   `json:"burner"`
+	BurnAddress string   `json:"burnAddress"`
+	Amount      *big.Int `json:"amount"`
+	Decimals    uint8    `json:"decimals"`
+	Timestamp   int64    `json:"timestamp"`
+}
+
+func NewBurnEvent(burner, burnAddress string, amount *big.Int, decimals uint8) *BurnEvent {
+	return &BurnEvent{
+		Burner:      burner,
+		BurnAddress: burnAddress,
+		Amount:      amount,
+		Decimals:    decimals,
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import "math/big"
+
+// PausedReasonVolumeExceeded is the reason of the pause tripped by the circuit breaker
+const PausedReasonVolumeExceeded = "volume threshold exceeded"
+
+// CircuitBreaker is the definition of the transfer volume threshold configured by owner
+// The token pauses when more than MaxVolume is transferred in a window of WindowSeconds
+type CircuitBreaker struct {
+	WindowSeconds int64    `json:"windowSeconds"`
+	MaxVolume     *big.Int `json:"maxVolume"`
+}
+
+func NewCircuitBreaker(windowSeconds int64, maxVolume *big.Int) *CircuitBreaker {
+	return &CircuitBreaker{
+		WindowSeconds: windowSeconds,
+		MaxVolume:     maxVolume,
+	}
+}
+
+// CircuitBreakerState is the definition of the volume transferred in the window starting at WindowStart
+// and whether the circuit breaker paused the token, the pause lasts until owner unpauses
+type CircuitBreakerState struct {
+	WindowStart int64    `json:"windowStart"`
+	Volume      *big.Int `json:"volume"`
+	Paused      bool     `json:"paused"`
+	PausedAt    int64    `json:"pausedAt,omitempty"`
+}
+
+// PausedEvent is the event definition of the pause tripped by the circuit breaker
+// Volume is the volume of the window including the tripping transfer
+type PausedEvent struct {
+	EventType     string   `json:"eventType"`
+	TokenID       string   `json:"tokenId"`
+	Reason        string   `json:"reason"`
+	Volume        *big.Int `json:"volume"`
+	MaxVolume     *big.Int `json:"maxVolume"`
+	WindowSeconds int64    `json:"windowSeconds"`
+	Timestamp     int64    `json:"timestamp"`
+}
+
+func NewPausedEvent(tokenID, reason string, volume *big.Int, circuitBreaker *CircuitBreaker) *PausedEvent {
+	return &PausedEvent{
+		TokenID:       tokenID,
+		Reason:        reason,
+		Volume:        volume,
+		MaxVolume:     circuitBreaker.MaxVolume,
+		WindowSeconds: circuitBreaker.WindowSeconds,
+	}
+}
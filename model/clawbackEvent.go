@@ -0,0 +1,21 @@
+package model
+
+import "math/big"
+
+// ClawbackEvent is the event definition of Clawback
+type ClawbackEvent struct {
+	EventType string   `json:"eventType"`
+	Owner     string   `json:"owner"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Amount    *big.Int `json:"amount"`
+}
+
+func NewClawbackEvent(owner, from, to string, amount *big.Int) *ClawbackEvent {
+	return &ClawbackEvent{
+		Owner:  owner,
+		From:   from,
+		To:     to,
+		Amount: amount,
+	}
+}
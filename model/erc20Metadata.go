@@ -1,22 +1,148 @@
 package model
 
+import "math/big"
+
+// Placeholders of DisplayFormat, replaced with the formatted amount & symbol
+const (
+	AmountPlaceholder    = "{amount}"
+	SymbolPlaceholder    = "{symbol}"
+	DefaultDisplayFormat = AmountPlaceholder + " " + SymbolPlaceholder
+)
+
+// MaxDecimals is the max Decimals accepted at Init, as ERC20 tokens commonly use at most 18
+const MaxDecimals = 18
+
+// Standards of token for wallets & explorers to decide which functions to expose
+// StandardCapped is reserved for tokens with a supply cap, which this chaincode doesn't configure yet
+const (
+	StandardERC20    = "ERC20"
+	StandardMintable = "ERC20-mintable"
+	StandardCapped   = "ERC20-capped"
+)
+
+// UnlimitedMintable is the remainingMintable response of a token without a supply cap
+const UnlimitedMintable = "unlimited"
+
+// IsKnownStandard returns whether standard is one of the known standards
+func IsKnownStandard(standard string) bool {
+	switch standard {
+	case StandardERC20, StandardMintable, StandardCapped:
+		return true
+	}
+	return false
+}
+
+// RenouncedOwner is the Owner of token after RenounceOwnership, no caller can match it
+const RenouncedOwner = ""
+
 // ERC20Metadata is the definition of Token Meta Info
 type ERC20Metadata struct {
-	Name        string `json:"name"`
-	Symbol      string `json:"symbol"`
-	Owner       string `json:"owner"`
-	TotalSupply uint64 `json:"totalSupply"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Symbol      string   `json:"symbol"`
+	Owner       string   `json:"owner"`
+	TotalSupply *big.Int `json:"totalSupply"`
+
+	// Decimals is the number of digits after the point when amounts are displayed,
+	// amounts are always stored in the smallest unit
+	Decimals uint8 `json:"decimals"`
+
+	// MinEndorsements is the intended number of endorsements for client tooling,
+	// the actual endorsement policy is enforced by Fabric
+	MinEndorsements int `json:"minEndorsements"`
+
+	// RateLimit is the per-address transfer limit configured by owner, nil is disabled
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+
+	// CircuitBreaker is the token-wide transfer volume threshold configured by owner, nil (default) is disabled
+	// The pause it trips is kept in config/circuitBreaker, so turning it off doesn't unpause
+	CircuitBreaker *CircuitBreaker `json:"circuitBreaker,omitempty"`
+
+	// TransferCooldownSeconds is the seconds an address has to wait to send again after a transfer, zero is disabled
+	TransferCooldownSeconds int64 `json:"transferCooldownSeconds"`
+
+	// ReserveSupply is the floor burn cannot reduce TotalSupply below, zero is disabled
+	ReserveSupply *big.Int `json:"reserveSupply"`
+
+	// VerifiedThreshold is the amount above which only addresses labeled verified can receive a transfer, zero is disabled
+	VerifiedThreshold *big.Int `json:"verifiedThreshold,omitempty"`
+
+	// BurnAddress is the address whose incoming transfers burn the amount (reduce TotalSupply)
+	// instead of crediting it, e.g. a well-known dead address, empty is disabled
+	BurnAddress string `json:"burnAddress,omitempty"`
+
+	// Minters are the addresses allowed to mint besides owner, managed by owner
+	Minters []string `json:"minters"`
+
+	// ExcludedAddresses are the addresses whose balances are not in the circulating supply,
+	// e.g. owner treasury & reserve, managed by owner
+	ExcludedAddresses []string `json:"excludedAddresses"`
+
+	// EmitEvents is whether transfer, mint and burn emit the transfer event, nil is true
+	// When false, listeners lose the real-time notification and have to poll the state
+	EmitEvents *bool `json:"emitEvents"`
+
+	// StrictApprovals is whether approvals exceeding the owner's balance are rejected, false (default) allows them as ERC20
+	StrictApprovals bool `json:"strictApprovals"`
+
+	// WhitelistMode is whether transfers only reach owner and the addresses owner allowed, false (default) allows all
+	WhitelistMode bool `json:"whitelistMode"`
+
+	// Maintenance is whether owner blocks every function except the maintenance whitelist, e.g. during migrations
+	Maintenance bool `json:"maintenance"`
+
+	// MetadataLocked is whether owner locked name & symbol for good (see LockMetadata),
+	// decimals never change after Init anyway
+	MetadataLocked bool `json:"metadataLocked"`
+
+	// UsageMetering is whether each invocation is recorded for usageStats, false (default) records nothing
+	UsageMetering bool `json:"usageMetering"`
+
+	// CreatedAt & CreatedTx are the unix seconds & txID of Init which created the token,
+	// zero & empty for tokens created before they were recorded
+	CreatedAt int64  `json:"createdAt,omitempty"`
+	CreatedTx string `json:"createdTx,omitempty"`
+
+	// DisplayFormat is the template of formatted amounts set at Init, e.g. "{amount} {symbol}"
+	// Some locales put the symbol before the amount
+	DisplayFormat string `json:"displayFormat"`
+
+	// BackupOwner is the address which can claim the ownership (see ClaimOwnership) once owner performed
+	// no owner action for InactivityPeriod seconds since OwnerActiveAt (unix seconds), all set at Init,
+	// empty BackupOwner is disabled and OwnerActiveAt is not tracked
+	BackupOwner      string `json:"backupOwner,omitempty"`
+	InactivityPeriod int64  `json:"inactivityPeriod,omitempty"`
+	OwnerActiveAt    int64  `json:"ownerActiveAt,omitempty"`
+
+	// EventPrefix is the prefix of event names set at Init, e.g. "acme" emits "acme.transferEvent.{tokenID}",
+	// empty emits the plain names
+	EventPrefix string `json:"eventPrefix,omitempty"`
+
+	// Standard is the token standard derived from the enabled features, e.g. "ERC20-mintable",
+	// empty for tokens created before it was recorded, which are "ERC20"
+	Standard string `json:"standard"`
 }
 
-func NewERC20MetaData(name, symbol, owner string, totalSupply uint64) *ERC20Metadata {
+func NewERC20MetaData(id, name, symbol, owner string, totalSupply *big.Int) *ERC20Metadata {
+	emitEvents := true
 	return &ERC20Metadata{
-		Name:        name,
-		Symbol:      symbol,
-		Owner:       owner,
-		TotalSupply: totalSupply,
+		ID:                id,
+		Name:              name,
+		Symbol:            symbol,
+		Owner:             owner,
+		TotalSupply:       totalSupply,
+		ReserveSupply:     big.NewInt(0),
+		Minters:           []string{},
+		ExcludedAddresses: []string{},
+		EmitEvents:        &emitEvents,
+		DisplayFormat:     DefaultDisplayFormat,
 	}
 }
 
+func (erc20 *ERC20Metadata) GetID() *string {
+	return &erc20.ID
+}
+
 func (erc20 *ERC20Metadata) GetName() *string {
 	return &erc20.Name
 }
@@ -29,6 +155,131 @@ func (erc20 *ERC20Metadata) GetOwner() *string {
 	return &erc20.Owner
 }
 
-func (erc20 *ERC20Metadata) GetTotalSupply() *uint64 {
-	return &erc20.TotalSupply
+// IsOwnershipRenounced returns whether owner has renounced the ownership of token
+func (erc20 *ERC20Metadata) IsOwnershipRenounced() bool {
+	return erc20.Owner == RenouncedOwner
+}
+
+func (erc20 *ERC20Metadata) GetTotalSupply() *big.Int {
+	return erc20.TotalSupply
+}
+
+func (erc20 *ERC20Metadata) GetDecimals() *uint8 {
+	return &erc20.Decimals
+}
+
+func (erc20 *ERC20Metadata) GetMinEndorsements() *int {
+	return &erc20.MinEndorsements
+}
+
+func (erc20 *ERC20Metadata) GetRateLimit() *RateLimit {
+	return erc20.RateLimit
+}
+
+func (erc20 *ERC20Metadata) GetReserveSupply() *big.Int {
+	if erc20.ReserveSupply == nil {
+		return big.NewInt(0)
+	}
+	return erc20.ReserveSupply
+}
+
+func (erc20 *ERC20Metadata) GetVerifiedThreshold() *big.Int {
+	if erc20.VerifiedThreshold == nil {
+		return big.NewInt(0)
+	}
+	return erc20.VerifiedThreshold
+}
+
+// GetCap returns the max total supply, zero is no cap
+// The metadata has no Cap field yet, so every token is uncapped until it is configured here
+func (erc20 *ERC20Metadata) GetCap() *big.Int {
+	return big.NewInt(0)
+}
+
+func (erc20 *ERC20Metadata) GetMinters() []string {
+	return erc20.Minters
+}
+
+func (erc20 *ERC20Metadata) GetExcludedAddresses() []string {
+	return erc20.ExcludedAddresses
+}
+
+// GetDisplayFormat returns the template of formatted amounts, the metadata without the field uses the default
+func (erc20 *ERC20Metadata) GetDisplayFormat() string {
+	if len(erc20.DisplayFormat) == 0 {
+		return DefaultDisplayFormat
+	}
+	return erc20.DisplayFormat
+}
+
+// GetStandard returns the token standard, the metadata without the field is StandardERC20
+func (erc20 *ERC20Metadata) GetStandard() string {
+	if len(erc20.Standard) == 0 {
+		return StandardERC20
+	}
+	return erc20.Standard
+}
+
+// DeriveStandard returns the standard of the enabled features, minters make token mintable
+func (erc20 *ERC20Metadata) DeriveStandard() string {
+	if len(erc20.Minters) != 0 {
+		return StandardMintable
+	}
+	return StandardERC20
+}
+
+// IsEmitEvents returns whether the transfer event is emitted, the metadata without the field emits
+func (erc20 *ERC20Metadata) IsEmitEvents() bool {
+	if erc20.EmitEvents == nil {
+		return true
+	}
+	return *erc20.EmitEvents
+}
+
+// IsMinter returns whether address is in the minter set
+func (erc20 *ERC20Metadata) IsMinter(address string) bool {
+	for _, minter := range erc20.Minters {
+		if minter == address {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcludedAddress returns whether address is in the excluded set of the circulating supply
+func (erc20 *ERC20Metadata) IsExcludedAddress(address string) bool {
+	for _, excludedAddress := range erc20.ExcludedAddresses {
+		if excludedAddress == address {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOwnershipClaimable returns whether the backup owner can claim the ownership at unix seconds now
+func (erc20 *ERC20Metadata) IsOwnershipClaimable(now int64) bool {
+	return len(erc20.BackupOwner) != 0 && !erc20.IsOwnershipRenounced() && now-erc20.OwnerActiveAt >= erc20.InactivityPeriod
+}
+
+// IsBurnAddress returns whether transfers to address burn the amount
+func (erc20 *ERC20Metadata) IsBurnAddress(address string) bool {
+	return len(erc20.BurnAddress) != 0 && erc20.BurnAddress == address
+}
+
+// UnknownCreation is the createdTx of tokenAge for tokens created before the creation was recorded
+const UnknownCreation = "unknown"
+
+// TokenAge is the definition of tokenAge response format
+// CreatedAt & AgeSeconds are omitted when CreatedTx is UnknownCreation
+type TokenAge struct {
+	CreatedTx  string `json:"createdTx"`
+	CreatedAt  *int64 `json:"createdAt,omitempty"`
+	AgeSeconds *int64 `json:"ageSeconds,omitempty"`
+}
+
+// TokenPage is the definition of listTokens response format
+type TokenPage struct {
+	Tokens   []ERC20Metadata `json:"tokens"`
+	Bookmark string          `json:"bookmark"`
+	PartialResult
 }
@@ -12,6 +12,24 @@ const (
 	CreateCompositeKeyErrorType          = "CreateCompositeKey"
 	GetStatePartialCompositeKeyErrorType = "GetStatePartialCompositeKey"
 	SpliteCompositeKeyErrorType          = "SpliteCompositeKey"
+	AuthorizeErrorType                   = "Authorize"
+	VerifyErrorType                      = "Verify"
+	GetHistoryErrorType                  = "GetHistory"
+	GetStateByRangeErrorType             = "GetStateByRange"
+	DelStateErrorType                    = "DelState"
+	ValidateErrorType                    = "Validate"
+	SetValidationParameterErrorType      = "SetStateValidationParameter"
+)
+
+// HTTP-like statuses of error responses, clients can branch on status without parsing messages
+// All are at least shim.ERRORTHRESHOLD (400), so Fabric treats them as errors
+const (
+	StatusBadRequest    = 400
+	StatusForbidden     = 403
+	StatusNotFound      = 404
+	StatusConflict      = 409
+	StatusInternalError = 500
+	StatusUnavailable   = 503
 )
 
 type CustomError struct {
@@ -31,3 +49,36 @@ func NewCustomError(errorType, typeName, message string) *CustomError {
 func (e *CustomError) Error() string {
 	return fmt.Sprintf("failed to %s %s, error: %s", e.ErrorType, e.TypeName, e.Message)
 }
+
+// Status returns the response status of error type, bad params are 400 and unauthorized callers are 403,
+// failures of state & marshaling are 500
+func (e *CustomError) Status() int32 {
+	switch e.ErrorType {
+	case ConvertErrorType:
+		return StatusBadRequest
+	case AuthorizeErrorType, VerifyErrorType:
+		return StatusForbidden
+	}
+	return StatusInternalError
+}
+
+// StatusError is the error with the response status, e.g. 404 for missing state and 409 for insufficient balance
+type StatusError struct {
+	status  int32
+	message string
+}
+
+func NewStatusError(status int32, message string) *StatusError {
+	return &StatusError{
+		status:  status,
+		message: message,
+	}
+}
+
+func (e *StatusError) Error() string {
+	return e.message
+}
+
+func (e *StatusError) Status() int32 {
+	return e.status
+}
@@ -0,0 +1,44 @@
+package model
+
+import "math/big"
+
+const (
+	EscrowLocked   = "locked"
+	EscrowClaimed  = "claimed"
+	EscrowRefunded = "refunded"
+)
+
+// Escrow is the definition of amount held by conditionalTransfer
+// The recipient can claim until Deadline, the sender can refund after Deadline
+type Escrow struct {
+	ID        string   `json:"id"`
+	Sender    string   `json:"sender"`
+	Recipient string   `json:"recipient"`
+	Amount    *big.Int `json:"amount"`
+	Deadline  int64    `json:"deadline"`
+	Status    string   `json:"status"`
+}
+
+func NewEscrow(id, sender, recipient string, amount *big.Int, deadline int64) *Escrow {
+	return &Escrow{
+		ID:        id,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    amount,
+		Deadline:  deadline,
+		Status:    EscrowLocked,
+	}
+}
+
+// EscrowEvent is the event definition of conditionalTransfer, claim & refund
+type EscrowEvent struct {
+	EventType string `json:"eventType"`
+	Escrow
+	Timestamp int64 `json:"timestamp"`
+}
+
+func NewEscrowEvent(escrow *Escrow) *EscrowEvent {
+	return &EscrowEvent{
+		Escrow: *escrow,
+	}
+}
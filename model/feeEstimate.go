@@ -0,0 +1,9 @@
+package model
+
+// FeeEstimate is the definition of estimateFee response format
+// fee - fee transfer would charge on amount, net - amount the recipient would receive
+// Both are decimal strings, fees are disabled in this tree so fee is always "0"
+type FeeEstimate struct {
+	Fee string `json:"fee"`
+	Net string `json:"net"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// FunctionInfo is the definition of an entry of functions response format
+type FunctionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Params      string `json:"params"`
+}
@@ -0,0 +1,14 @@
+package model
+
+// statuses of ImplementationStatus
+const (
+	StatusStub        = "stub"
+	StatusImplemented = "implemented"
+)
+
+// ImplementationStatus is the definition of an entry of implementationStatus response format
+// Todo is the note of what is left for a stub
+type ImplementationStatus struct {
+	Status string `json:"status"`
+	Todo   string `json:"todo,omitempty"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// InitOptions is the definition of optional Init parameter format (JSON)
+type InitOptions struct {
+	MinEndorsements *int    `json:"minEndorsements"`
+	Decimals        uint8   `json:"decimals"`
+	DisplayFormat   *string `json:"displayFormat"`
+	EventPrefix     string  `json:"eventPrefix"`
+
+	// BackupOwner can claim the ownership once owner is inactive for InactivityPeriod seconds, both or neither are set
+	BackupOwner      string `json:"backupOwner"`
+	InactivityPeriod int64  `json:"inactivityPeriod"`
+}
@@ -0,0 +1,25 @@
+package model
+
+// Labels of accounts set by owner, e.g. the KYC tier of address
+const (
+	LabelUnverified    = "unverified"
+	LabelVerified      = "verified"
+	LabelInstitutional = "institutional"
+)
+
+// AllowedLabels is the set of labels setLabel accepts
+var AllowedLabels = []string{
+	LabelUnverified,
+	LabelVerified,
+	LabelInstitutional,
+}
+
+// IsAllowedLabel returns whether label is one of AllowedLabels
+func IsAllowedLabel(label string) bool {
+	for _, allowed := range AllowedLabels {
+		if allowed == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package model
+
+// MetadataUpdatedEvent is the event definition of metadata change
+type MetadataUpdatedEvent struct {
+	EventType string `json:"eventType"`
+	TokenID   string `json:"tokenId"`
+	Field     string `json:"field"`
+	OldValue  string `json:"oldValue"`
+	NewValue  string `json:"newValue"`
+}
+
+func NewMetadataUpdatedEvent(tokenID, field, oldValue, newValue string) *MetadataUpdatedEvent {
+	return &MetadataUpdatedEvent{
+		TokenID:  tokenID,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import "math/big"
+
+// MintEvent is the event definition of MintTo, combining the holder credit & the supply change,
+// as Fabric keeps only one event per transaction
+// {"eventType": "mintEvent", "tokenId", "minter", "recipient", "amount", "decimals",
+// "supply": {"oldTotalSupply", "totalSupply"}, "timestamp"}
+type MintEvent struct {
+	EventType string       `json:"eventType"`
+	TokenID   string       `json:"tokenId"`
+	Minter    string       `json:"minter"`
+	Recipient string       `json:"recipient"`
+	Amount    *big.Int     `json:"amount"`
+	Decimals  uint8        `json:"decimals"`
+	Supply    SupplyChange `json:"supply"`
+	Timestamp int64        `json:"timestamp"`
+}
+
+// SupplyChange is the supply part of MintEvent, TotalSupply is the new total supply
+type SupplyChange struct {
+	OldTotalSupply *big.Int `json:"oldTotalSupply"`
+	TotalSupply    *big.Int `json:"totalSupply"`
+}
+
+func NewMintEvent(tokenID, minter, recipient string, amount, oldTotalSupply, totalSupply *big.Int, decimals uint8) *MintEvent {
+	return &MintEvent{
+		TokenID:   tokenID,
+		Minter:    minter,
+		Recipient: recipient,
+		Amount:    amount,
+		Decimals:  decimals,
+		Supply:    SupplyChange{OldTotalSupply: oldTotalSupply, TotalSupply: totalSupply},
+	}
+}
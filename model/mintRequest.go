@@ -0,0 +1,24 @@
+package model
+
+import "math/big"
+
+// MintRequest is the definition of a processed mint with mintRequestId, the outcome returned on retry
+type MintRequest struct {
+	RequestID string   `json:"requestId"`
+	TxID      string   `json:"txId"`
+	Caller    string   `json:"caller"`
+	Recipient string   `json:"recipient"`
+	Amount    *big.Int `json:"amount"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+func NewMintRequest(requestID, txID, caller, recipient string, amount *big.Int, timestamp int64) *MintRequest {
+	return &MintRequest{
+		RequestID: requestID,
+		TxID:      txID,
+		Caller:    caller,
+		Recipient: recipient,
+		Amount:    amount,
+		Timestamp: timestamp,
+	}
+}
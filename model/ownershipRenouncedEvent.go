@@ -0,0 +1,16 @@
+package model
+
+// OwnershipRenouncedEvent is the event definition of RenounceOwnership
+type OwnershipRenouncedEvent struct {
+	EventType     string `json:"eventType"`
+	TokenID       string `json:"tokenId"`
+	PreviousOwner string `json:"previousOwner"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+func NewOwnershipRenouncedEvent(tokenID, previousOwner string) *OwnershipRenouncedEvent {
+	return &OwnershipRenouncedEvent{
+		TokenID:       tokenID,
+		PreviousOwner: previousOwner,
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+// OwnershipTransferredEvent is the event definition of ClaimOwnership
+type OwnershipTransferredEvent struct {
+	EventType     string `json:"eventType"`
+	TokenID       string `json:"tokenId"`
+	PreviousOwner string `json:"previousOwner"`
+	NewOwner      string `json:"newOwner"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+func NewOwnershipTransferredEvent(tokenID, previousOwner, newOwner string) *OwnershipTransferredEvent {
+	return &OwnershipTransferredEvent{
+		TokenID:       tokenID,
+		PreviousOwner: previousOwner,
+		NewOwner:      newOwner,
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+// PartialResult marks a list response cut short by an iterator error mid-scan
+// The entries gathered before the error are returned, the rest of the page is missing,
+// and the page's bookmark resumes after the last entry read
+// Every paginated list query returns it: recentTransfers, categoryTransfers, listTokens & queryBalances
+// The other scans are all-or-nothing, an iterator error fails the whole call:
+// approvalList & usageStats return a bare JSON array & map without room for the mark, and
+// the sums (reconcileReport, checkInvariant, circulatingSupply...) & the invokes scanning pages
+// (sweep, migrateBalances) would be wrong or write state from a partial scan
+type PartialResult struct {
+	Error      string `json:"error,omitempty"`
+	Incomplete bool   `json:"incomplete,omitempty"`
+}
+
+// SetError marks the result incomplete with err
+func (result *PartialResult) SetError(err error) {
+	result.Error = err.Error()
+	result.Incomplete = true
+}
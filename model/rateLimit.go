@@ -0,0 +1,25 @@
+package model
+
+import "math/big"
+
+// RateLimit is the definition of per-address transfer limit in a time window
+// Zero MaxTransfers or nil MaxAmount disables each limit
+type RateLimit struct {
+	WindowSeconds int64    `json:"windowSeconds"`
+	MaxTransfers  int      `json:"maxTransfers"`
+	MaxAmount     *big.Int `json:"maxAmount"`
+}
+
+func NewRateLimit(windowSeconds int64, maxTransfers int, maxAmount *big.Int) *RateLimit {
+	return &RateLimit{
+		WindowSeconds: windowSeconds,
+		MaxTransfers:  maxTransfers,
+		MaxAmount:     maxAmount,
+	}
+}
+
+// TransferCounter is the definition of transfers an address made in a time window
+type TransferCounter struct {
+	Count  int      `json:"count"`
+	Amount *big.Int `json:"amount"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "math/big"
+
+// RecurringAllowance is the definition of allowance reset to Amount at the start of every period
+// Period is the length of period in seconds, LastReset is the unix seconds the current period started
+// Periods are aligned to the first LastReset (LastReset + k*Period), so late spends don't shift them
+type RecurringAllowance struct {
+	Amount    *big.Int `json:"amount"`
+	Period    int64    `json:"period"`
+	LastReset int64    `json:"lastReset"`
+}
+
+func NewRecurringAllowance(amount *big.Int, period, lastReset int64) *RecurringAllowance {
+	return &RecurringAllowance{
+		Amount:    amount,
+		Period:    period,
+		LastReset: lastReset,
+	}
+}
+
+// CurrentPeriodStart returns the start of the period containing seconds
+func (recurring *RecurringAllowance) CurrentPeriodStart(seconds int64) int64 {
+	if seconds < recurring.LastReset {
+		return recurring.LastReset
+	}
+	return recurring.LastReset + (seconds-recurring.LastReset)/recurring.Period*recurring.Period
+}
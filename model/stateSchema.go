@@ -0,0 +1,22 @@
+package model
+
+// StateKeySchema is the definition of a key layout of state in stateSchema response
+// Key is the composite key attributes after Prefix, e.g. "{owner}/{spender}"
+type StateKeySchema struct {
+	Prefix string `json:"prefix"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+}
+
+// UnexpectedKey is the definition of a state key matching no layout of StateSchema
+type UnexpectedKey struct {
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// NamespaceReport is the definition of verifyNamespaceIntegrity response format
+// Scanned is the number of keys read, UnexpectedKeys are empty when every key matches a layout
+type NamespaceReport struct {
+	Scanned        int             `json:"scanned"`
+	UnexpectedKeys []UnexpectedKey `json:"unexpectedKeys"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "math/big"
+
+// SupplyReconciledEvent is the event definition of ReconcileSupply, also its response
+// OldSupply is the TotalSupply before, NewSupply is the sum of balances & locked escrows it was set to
+type SupplyReconciledEvent struct {
+	EventType string   `json:"eventType"`
+	TokenID   string   `json:"tokenId"`
+	OldSupply *big.Int `json:"oldSupply"`
+	NewSupply *big.Int `json:"newSupply"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+func NewSupplyReconciledEvent(tokenID string, oldSupply, newSupply *big.Int) *SupplyReconciledEvent {
+	return &SupplyReconciledEvent{
+		TokenID:   tokenID,
+		OldSupply: oldSupply,
+		NewSupply: newSupply,
+	}
+}
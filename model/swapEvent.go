@@ -0,0 +1,27 @@
+package model
+
+import "math/big"
+
+// SwapEvent is the event definition of atomicSwap, summarizing both legs
+// Leg A moves AmountA of TokenA from Party1 to Party2, leg B moves AmountB of TokenB from Party2 to Party1
+type SwapEvent struct {
+	EventType string   `json:"eventType"`
+	Party1    string   `json:"party1"`
+	Party2    string   `json:"party2"`
+	TokenA    string   `json:"tokenA"`
+	AmountA   *big.Int `json:"amountA"`
+	TokenB    string   `json:"tokenB"`
+	AmountB   *big.Int `json:"amountB"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+func NewSwapEvent(party1, party2, tokenA string, amountA *big.Int, tokenB string, amountB *big.Int) *SwapEvent {
+	return &SwapEvent{
+		Party1:  party1,
+		Party2:  party2,
+		TokenA:  tokenA,
+		AmountA: amountA,
+		TokenB:  tokenB,
+		AmountB: amountB,
+	}
+}
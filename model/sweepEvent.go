@@ -0,0 +1,33 @@
+package model
+
+import "math/big"
+
+// SweepEvent is the event definition of Sweep, one event lists every account swept in the page
+// Accounts are the swept addresses with the dust balance each had, Total is the sum credited to Collector
+type SweepEvent struct {
+	EventType string           `json:"eventType"`
+	TokenID   string           `json:"tokenId"`
+	Owner     string           `json:"owner"`
+	Collector string           `json:"collector"`
+	Accounts  []AccountBalance `json:"accounts"`
+	Total     *big.Int         `json:"total"`
+	Timestamp int64            `json:"timestamp"`
+}
+
+func NewSweepEvent(tokenID, owner, collector string, accounts []AccountBalance, total *big.Int) *SweepEvent {
+	return &SweepEvent{
+		TokenID:   tokenID,
+		Owner:     owner,
+		Collector: collector,
+		Accounts:  accounts,
+		Total:     total,
+	}
+}
+
+// SweepResult is the definition of sweep response format
+// Swept is the number of accounts swept in this page, Bookmark is empty after the last page
+type SweepResult struct {
+	Swept    int      `json:"swept"`
+	Total    *big.Int `json:"total"`
+	Bookmark string   `json:"bookmark"`
+}
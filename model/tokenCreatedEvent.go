@@ -0,0 +1,25 @@
+package model
+
+import "math/big"
+
+// TokenCreatedEvent is the event definition of token creation at Init
+type TokenCreatedEvent struct {
+	EventType   string   `json:"eventType"`
+	TokenID     string   `json:"tokenId"`
+	Name        string   `json:"name"`
+	Symbol      string   `json:"symbol"`
+	Owner       string   `json:"owner"`
+	TotalSupply *big.Int `json:"totalSupply"`
+	Decimals    uint8    `json:"decimals"`
+}
+
+func NewTokenCreatedEvent(erc20 *ERC20Metadata) *TokenCreatedEvent {
+	return &TokenCreatedEvent{
+		TokenID:     erc20.ID,
+		Name:        erc20.Name,
+		Symbol:      erc20.Symbol,
+		Owner:       erc20.Owner,
+		TotalSupply: erc20.TotalSupply,
+		Decimals:    erc20.Decimals,
+	}
+}
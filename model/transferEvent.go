@@ -1,13 +1,26 @@
 package model
 
+import "math/big"
+
 // TransferEvent is the event definition of Transfer
 type TransferEvent struct {
-	Sender    string `json:"sender"`
-	Recipient string `json:"recipient"`
-	Amount    int    `json:"amount"`
+	// EventType is the base event name, only set in event (not in txlog)
+	EventType string   `json:"eventType,omitempty"`
+	Sender    string   `json:"sender"`
+	Recipient string   `json:"recipient"`
+	Amount    *big.Int `json:"amount"`
+
+	// Decimals is the decimals of token to interpret Amount without metadata, only set in event (not in txlog)
+	Decimals *uint8 `json:"decimals,omitempty"`
+
+	// Timestamp is the unix seconds of transaction timestamp, 0 if unavailable
+	Timestamp int64 `json:"timestamp"`
+
+	// Category is the accounting category of categorizedTransfer, e.g. "salary", empty for other transfers
+	Category string `json:"category,omitempty"`
 }
 
-func NewTransferEvent(sender, recipient string, amount int) *TransferEvent {
+func NewTransferEvent(sender, recipient string, amount *big.Int) *TransferEvent {
 	return &TransferEvent{
 		Sender:    sender,
 		Recipient: recipient,
@@ -0,0 +1,28 @@
+package model
+
+import "math/big"
+
+// TransferFromEvent is the event definition of TransferFrom & BurnFrom, the transfers by spender
+// Fabric keeps only one event per transaction, so it replaces the separate
+// Transfer & Approval events with the transfer and the resulting allowance
+// Unlike TransferEvent, the tokens leave Owner's balance, not the caller's (Spender)
+type TransferFromEvent struct {
+	EventType          string   `json:"eventType"`
+	Owner              string   `json:"owner"`
+	Spender            string   `json:"spender"`
+	Recipient          string   `json:"recipient"`
+	Amount             *big.Int `json:"amount"`
+	RemainingAllowance *big.Int `json:"remainingAllowance"`
+	Decimals           uint8    `json:"decimals"`
+	Timestamp          int64    `json:"timestamp"`
+}
+
+func NewTransferFromEvent(owner, spender, recipient string, amount *big.Int, remainingAllowance *big.Int) *TransferFromEvent {
+	return &TransferFromEvent{
+		Owner:              owner,
+		Spender:            spender,
+		Recipient:          recipient,
+		Amount:             amount,
+		RemainingAllowance: remainingAllowance,
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+// TransferLog is the definition of a txlog index entry
+// Index is the movement of the transaction, 0 unless the transaction moved tokens more than once (batch, sweep)
+type TransferLog struct {
+	TxID  string        `json:"txId"`
+	Index int           `json:"index,omitempty"`
+	Event TransferEvent `json:"event"`
+}
+
+// TransferLogPage is the definition of recentTransfers & categoryTransfers response format
+type TransferLogPage struct {
+	Logs     []TransferLog `json:"logs"`
+	Bookmark string        `json:"bookmark"`
+	PartialResult
+}
@@ -0,0 +1,24 @@
+package model
+
+import "math/big"
+
+// TransferReceiptNote is the client-facing note of MVCC, the balances of receipt are
+// the simulated result, they are final only when the transaction is committed as valid
+const TransferReceiptNote = "balances are final once committed; concurrent transfers of the same sender or recipient " +
+	"in one block fail with MVCC_READ_CONFLICT, resubmit after the previous transfer is committed"
+
+// TransferReceipt is the definition of transfer response format
+// The field names are stable, clients can rely on them without a follow-up query
+// txId - transaction ID, from & to - sender's & recipient's address, amount - moved amount
+// fromBalance & toBalance - result balances after the transfer, timestamp - unix seconds of transaction
+// note - the MVCC note for clients (see TransferReceiptNote)
+type TransferReceipt struct {
+	Note        string   `json:"note"`
+	TxID        string   `json:"txId"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Amount      *big.Int `json:"amount"`
+	FromBalance *big.Int `json:"fromBalance"`
+	ToBalance   *big.Int `json:"toBalance"`
+	Timestamp   int64    `json:"timestamp"`
+}
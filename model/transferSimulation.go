@@ -0,0 +1,24 @@
+package model
+
+import "math/big"
+
+// TransferSimulation is the definition of simulateTransfer response format
+// Passes is whether every check passes, Reasons are the errors of the failing checks in check order
+// NetAmount is the amount credited to recipient, 0 when the recipient is the burn address
+type TransferSimulation struct {
+	Passes    bool           `json:"passes"`
+	Checks    TransferChecks `json:"checks"`
+	NetAmount *big.Int       `json:"netAmount"`
+	Reasons   []string       `json:"reasons"`
+}
+
+// TransferChecks are the results of each check of transfer, true passes
+type TransferChecks struct {
+	NotPaused bool `json:"notPaused"`
+	Supply    bool `json:"supply"`
+	Balance   bool `json:"balance"`
+	Cooldown  bool `json:"cooldown"`
+	RateLimit bool `json:"rateLimit"`
+	Verified  bool `json:"verified"`
+	Whitelist bool `json:"whitelist"`
+}
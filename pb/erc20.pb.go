@@ -0,0 +1,767 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: erc20.proto
+
+package pb
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// Balance is the balanceOf response
+type Balance struct {
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// balance is the decimal integer string in the smallest unit, "0" for a missing address
+	Balance              string   `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Balance) Reset()         { *m = Balance{} }
+func (m *Balance) String() string { return proto.CompactTextString(m) }
+func (*Balance) ProtoMessage()    {}
+func (*Balance) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{0}
+}
+
+func (m *Balance) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Balance.Unmarshal(m, b)
+}
+func (m *Balance) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Balance.Marshal(b, m, deterministic)
+}
+func (m *Balance) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Balance.Merge(m, src)
+}
+func (m *Balance) XXX_Size() int {
+	return xxx_messageInfo_Balance.Size(m)
+}
+func (m *Balance) XXX_DiscardUnknown() {
+	xxx_messageInfo_Balance.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Balance proto.InternalMessageInfo
+
+func (m *Balance) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *Balance) GetBalance() string {
+	if m != nil {
+		return m.Balance
+	}
+	return ""
+}
+
+// RateLimit mirrors model.RateLimit, zero max_transfers or empty max_amount disables each limit
+type RateLimit struct {
+	WindowSeconds        int64    `protobuf:"varint,1,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	MaxTransfers         int64    `protobuf:"varint,2,opt,name=max_transfers,json=maxTransfers,proto3" json:"max_transfers,omitempty"`
+	MaxAmount            string   `protobuf:"bytes,3,opt,name=max_amount,json=maxAmount,proto3" json:"max_amount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RateLimit) Reset()         { *m = RateLimit{} }
+func (m *RateLimit) String() string { return proto.CompactTextString(m) }
+func (*RateLimit) ProtoMessage()    {}
+func (*RateLimit) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{1}
+}
+
+func (m *RateLimit) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RateLimit.Unmarshal(m, b)
+}
+func (m *RateLimit) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RateLimit.Marshal(b, m, deterministic)
+}
+func (m *RateLimit) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RateLimit.Merge(m, src)
+}
+func (m *RateLimit) XXX_Size() int {
+	return xxx_messageInfo_RateLimit.Size(m)
+}
+func (m *RateLimit) XXX_DiscardUnknown() {
+	xxx_messageInfo_RateLimit.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RateLimit proto.InternalMessageInfo
+
+func (m *RateLimit) GetWindowSeconds() int64 {
+	if m != nil {
+		return m.WindowSeconds
+	}
+	return 0
+}
+
+func (m *RateLimit) GetMaxTransfers() int64 {
+	if m != nil {
+		return m.MaxTransfers
+	}
+	return 0
+}
+
+func (m *RateLimit) GetMaxAmount() string {
+	if m != nil {
+		return m.MaxAmount
+	}
+	return ""
+}
+
+// CircuitBreaker mirrors model.CircuitBreaker
+type CircuitBreaker struct {
+	WindowSeconds        int64    `protobuf:"varint,1,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	MaxVolume            string   `protobuf:"bytes,2,opt,name=max_volume,json=maxVolume,proto3" json:"max_volume,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CircuitBreaker) Reset()         { *m = CircuitBreaker{} }
+func (m *CircuitBreaker) String() string { return proto.CompactTextString(m) }
+func (*CircuitBreaker) ProtoMessage()    {}
+func (*CircuitBreaker) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{2}
+}
+
+func (m *CircuitBreaker) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CircuitBreaker.Unmarshal(m, b)
+}
+func (m *CircuitBreaker) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CircuitBreaker.Marshal(b, m, deterministic)
+}
+func (m *CircuitBreaker) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CircuitBreaker.Merge(m, src)
+}
+func (m *CircuitBreaker) XXX_Size() int {
+	return xxx_messageInfo_CircuitBreaker.Size(m)
+}
+func (m *CircuitBreaker) XXX_DiscardUnknown() {
+	xxx_messageInfo_CircuitBreaker.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CircuitBreaker proto.InternalMessageInfo
+
+func (m *CircuitBreaker) GetWindowSeconds() int64 {
+	if m != nil {
+		return m.WindowSeconds
+	}
+	return 0
+}
+
+func (m *CircuitBreaker) GetMaxVolume() string {
+	if m != nil {
+		return m.MaxVolume
+	}
+	return ""
+}
+
+// TokenMetadata mirrors model.ERC20Metadata, the getMetadata response
+type TokenMetadata struct {
+	Id              string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Symbol          string `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Owner           string `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	TotalSupply     string `protobuf:"bytes,5,opt,name=total_supply,json=totalSupply,proto3" json:"total_supply,omitempty"`
+	Decimals        uint32 `protobuf:"varint,6,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	MinEndorsements int64  `protobuf:"varint,7,opt,name=min_endorsements,json=minEndorsements,proto3" json:"min_endorsements,omitempty"`
+	// rate_limit & circuit_breaker are unset when disabled
+	RateLimit               *RateLimit      `protobuf:"bytes,8,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	CircuitBreaker          *CircuitBreaker `protobuf:"bytes,9,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	TransferCooldownSeconds int64           `protobuf:"varint,10,opt,name=transfer_cooldown_seconds,json=transferCooldownSeconds,proto3" json:"transfer_cooldown_seconds,omitempty"`
+	ReserveSupply           string          `protobuf:"bytes,11,opt,name=reserve_supply,json=reserveSupply,proto3" json:"reserve_supply,omitempty"`
+	VerifiedThreshold       string          `protobuf:"bytes,12,opt,name=verified_threshold,json=verifiedThreshold,proto3" json:"verified_threshold,omitempty"`
+	BurnAddress             string          `protobuf:"bytes,13,opt,name=burn_address,json=burnAddress,proto3" json:"burn_address,omitempty"`
+	Minters                 []string        `protobuf:"bytes,14,rep,name=minters,proto3" json:"minters,omitempty"`
+	ExcludedAddresses       []string        `protobuf:"bytes,15,rep,name=excluded_addresses,json=excludedAddresses,proto3" json:"excluded_addresses,omitempty"`
+	// emit_events is the effective value, true when never set
+	EmitEvents           bool     `protobuf:"varint,16,opt,name=emit_events,json=emitEvents,proto3" json:"emit_events,omitempty"`
+	StrictApprovals      bool     `protobuf:"varint,17,opt,name=strict_approvals,json=strictApprovals,proto3" json:"strict_approvals,omitempty"`
+	WhitelistMode        bool     `protobuf:"varint,18,opt,name=whitelist_mode,json=whitelistMode,proto3" json:"whitelist_mode,omitempty"`
+	Maintenance          bool     `protobuf:"varint,19,opt,name=maintenance,proto3" json:"maintenance,omitempty"`
+	MetadataLocked       bool     `protobuf:"varint,20,opt,name=metadata_locked,json=metadataLocked,proto3" json:"metadata_locked,omitempty"`
+	UsageMetering        bool     `protobuf:"varint,21,opt,name=usage_metering,json=usageMetering,proto3" json:"usage_metering,omitempty"`
+	CreatedAt            int64    `protobuf:"varint,22,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CreatedTx            string   `protobuf:"bytes,23,opt,name=created_tx,json=createdTx,proto3" json:"created_tx,omitempty"`
+	DisplayFormat        string   `protobuf:"bytes,24,opt,name=display_format,json=displayFormat,proto3" json:"display_format,omitempty"`
+	BackupOwner          string   `protobuf:"bytes,25,opt,name=backup_owner,json=backupOwner,proto3" json:"backup_owner,omitempty"`
+	InactivityPeriod     int64    `protobuf:"varint,26,opt,name=inactivity_period,json=inactivityPeriod,proto3" json:"inactivity_period,omitempty"`
+	OwnerActiveAt        int64    `protobuf:"varint,27,opt,name=owner_active_at,json=ownerActiveAt,proto3" json:"owner_active_at,omitempty"`
+	EventPrefix          string   `protobuf:"bytes,28,opt,name=event_prefix,json=eventPrefix,proto3" json:"event_prefix,omitempty"`
+	Standard             string   `protobuf:"bytes,29,opt,name=standard,proto3" json:"standard,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TokenMetadata) Reset()         { *m = TokenMetadata{} }
+func (m *TokenMetadata) String() string { return proto.CompactTextString(m) }
+func (*TokenMetadata) ProtoMessage()    {}
+func (*TokenMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{3}
+}
+
+func (m *TokenMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TokenMetadata.Unmarshal(m, b)
+}
+func (m *TokenMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TokenMetadata.Marshal(b, m, deterministic)
+}
+func (m *TokenMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TokenMetadata.Merge(m, src)
+}
+func (m *TokenMetadata) XXX_Size() int {
+	return xxx_messageInfo_TokenMetadata.Size(m)
+}
+func (m *TokenMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_TokenMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TokenMetadata proto.InternalMessageInfo
+
+func (m *TokenMetadata) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetSymbol() string {
+	if m != nil {
+		return m.Symbol
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetTotalSupply() string {
+	if m != nil {
+		return m.TotalSupply
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetDecimals() uint32 {
+	if m != nil {
+		return m.Decimals
+	}
+	return 0
+}
+
+func (m *TokenMetadata) GetMinEndorsements() int64 {
+	if m != nil {
+		return m.MinEndorsements
+	}
+	return 0
+}
+
+func (m *TokenMetadata) GetRateLimit() *RateLimit {
+	if m != nil {
+		return m.RateLimit
+	}
+	return nil
+}
+
+func (m *TokenMetadata) GetCircuitBreaker() *CircuitBreaker {
+	if m != nil {
+		return m.CircuitBreaker
+	}
+	return nil
+}
+
+func (m *TokenMetadata) GetTransferCooldownSeconds() int64 {
+	if m != nil {
+		return m.TransferCooldownSeconds
+	}
+	return 0
+}
+
+func (m *TokenMetadata) GetReserveSupply() string {
+	if m != nil {
+		return m.ReserveSupply
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetVerifiedThreshold() string {
+	if m != nil {
+		return m.VerifiedThreshold
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetBurnAddress() string {
+	if m != nil {
+		return m.BurnAddress
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetMinters() []string {
+	if m != nil {
+		return m.Minters
+	}
+	return nil
+}
+
+func (m *TokenMetadata) GetExcludedAddresses() []string {
+	if m != nil {
+		return m.ExcludedAddresses
+	}
+	return nil
+}
+
+func (m *TokenMetadata) GetEmitEvents() bool {
+	if m != nil {
+		return m.EmitEvents
+	}
+	return false
+}
+
+func (m *TokenMetadata) GetStrictApprovals() bool {
+	if m != nil {
+		return m.StrictApprovals
+	}
+	return false
+}
+
+func (m *TokenMetadata) GetWhitelistMode() bool {
+	if m != nil {
+		return m.WhitelistMode
+	}
+	return false
+}
+
+func (m *TokenMetadata) GetMaintenance() bool {
+	if m != nil {
+		return m.Maintenance
+	}
+	return false
+}
+
+func (m *TokenMetadata) GetMetadataLocked() bool {
+	if m != nil {
+		return m.MetadataLocked
+	}
+	return false
+}
+
+func (m *TokenMetadata) GetUsageMetering() bool {
+	if m != nil {
+		return m.UsageMetering
+	}
+	return false
+}
+
+func (m *TokenMetadata) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+func (m *TokenMetadata) GetCreatedTx() string {
+	if m != nil {
+		return m.CreatedTx
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetDisplayFormat() string {
+	if m != nil {
+		return m.DisplayFormat
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetBackupOwner() string {
+	if m != nil {
+		return m.BackupOwner
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetInactivityPeriod() int64 {
+	if m != nil {
+		return m.InactivityPeriod
+	}
+	return 0
+}
+
+func (m *TokenMetadata) GetOwnerActiveAt() int64 {
+	if m != nil {
+		return m.OwnerActiveAt
+	}
+	return 0
+}
+
+func (m *TokenMetadata) GetEventPrefix() string {
+	if m != nil {
+		return m.EventPrefix
+	}
+	return ""
+}
+
+func (m *TokenMetadata) GetStandard() string {
+	if m != nil {
+		return m.Standard
+	}
+	return ""
+}
+
+// TransferEvent mirrors model.TransferEvent as indexed in txlog
+type TransferEvent struct {
+	EventType string `protobuf:"bytes,1,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Sender    string `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	Recipient string `protobuf:"bytes,3,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	Amount    string `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	// decimals is 0 when the event doesn't carry them (txlog entries)
+	Decimals             uint32   `protobuf:"varint,5,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	Timestamp            int64    `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Category             string   `protobuf:"bytes,7,opt,name=category,proto3" json:"category,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferEvent) Reset()         { *m = TransferEvent{} }
+func (m *TransferEvent) String() string { return proto.CompactTextString(m) }
+func (*TransferEvent) ProtoMessage()    {}
+func (*TransferEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{4}
+}
+
+func (m *TransferEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferEvent.Unmarshal(m, b)
+}
+func (m *TransferEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferEvent.Marshal(b, m, deterministic)
+}
+func (m *TransferEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferEvent.Merge(m, src)
+}
+func (m *TransferEvent) XXX_Size() int {
+	return xxx_messageInfo_TransferEvent.Size(m)
+}
+func (m *TransferEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferEvent proto.InternalMessageInfo
+
+func (m *TransferEvent) GetEventType() string {
+	if m != nil {
+		return m.EventType
+	}
+	return ""
+}
+
+func (m *TransferEvent) GetSender() string {
+	if m != nil {
+		return m.Sender
+	}
+	return ""
+}
+
+func (m *TransferEvent) GetRecipient() string {
+	if m != nil {
+		return m.Recipient
+	}
+	return ""
+}
+
+func (m *TransferEvent) GetAmount() string {
+	if m != nil {
+		return m.Amount
+	}
+	return ""
+}
+
+func (m *TransferEvent) GetDecimals() uint32 {
+	if m != nil {
+		return m.Decimals
+	}
+	return 0
+}
+
+func (m *TransferEvent) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *TransferEvent) GetCategory() string {
+	if m != nil {
+		return m.Category
+	}
+	return ""
+}
+
+// TransferLog mirrors model.TransferLog
+type TransferLog struct {
+	TxId                 string         `protobuf:"bytes,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Index                int64          `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Event                *TransferEvent `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *TransferLog) Reset()         { *m = TransferLog{} }
+func (m *TransferLog) String() string { return proto.CompactTextString(m) }
+func (*TransferLog) ProtoMessage()    {}
+func (*TransferLog) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{5}
+}
+
+func (m *TransferLog) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferLog.Unmarshal(m, b)
+}
+func (m *TransferLog) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferLog.Marshal(b, m, deterministic)
+}
+func (m *TransferLog) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferLog.Merge(m, src)
+}
+func (m *TransferLog) XXX_Size() int {
+	return xxx_messageInfo_TransferLog.Size(m)
+}
+func (m *TransferLog) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferLog.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferLog proto.InternalMessageInfo
+
+func (m *TransferLog) GetTxId() string {
+	if m != nil {
+		return m.TxId
+	}
+	return ""
+}
+
+func (m *TransferLog) GetIndex() int64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *TransferLog) GetEvent() *TransferEvent {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+// TransferLogs is the eventByTxId response, one entry per movement of the transaction
+type TransferLogs struct {
+	Logs                 []*TransferLog `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *TransferLogs) Reset()         { *m = TransferLogs{} }
+func (m *TransferLogs) String() string { return proto.CompactTextString(m) }
+func (*TransferLogs) ProtoMessage()    {}
+func (*TransferLogs) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{6}
+}
+
+func (m *TransferLogs) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferLogs.Unmarshal(m, b)
+}
+func (m *TransferLogs) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferLogs.Marshal(b, m, deterministic)
+}
+func (m *TransferLogs) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferLogs.Merge(m, src)
+}
+func (m *TransferLogs) XXX_Size() int {
+	return xxx_messageInfo_TransferLogs.Size(m)
+}
+func (m *TransferLogs) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferLogs.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferLogs proto.InternalMessageInfo
+
+func (m *TransferLogs) GetLogs() []*TransferLog {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+// TransferLogPage mirrors model.TransferLogPage, the recentTransfers response
+type TransferLogPage struct {
+	Logs     []*TransferLog `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	Bookmark string         `protobuf:"bytes,2,opt,name=bookmark,proto3" json:"bookmark,omitempty"`
+	// error & incomplete mark a page cut short by an iterator error (see model.PartialResult)
+	Error                string   `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Incomplete           bool     `protobuf:"varint,4,opt,name=incomplete,proto3" json:"incomplete,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferLogPage) Reset()         { *m = TransferLogPage{} }
+func (m *TransferLogPage) String() string { return proto.CompactTextString(m) }
+func (*TransferLogPage) ProtoMessage()    {}
+func (*TransferLogPage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aad91a6883b404d3, []int{7}
+}
+
+func (m *TransferLogPage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferLogPage.Unmarshal(m, b)
+}
+func (m *TransferLogPage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferLogPage.Marshal(b, m, deterministic)
+}
+func (m *TransferLogPage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferLogPage.Merge(m, src)
+}
+func (m *TransferLogPage) XXX_Size() int {
+	return xxx_messageInfo_TransferLogPage.Size(m)
+}
+func (m *TransferLogPage) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferLogPage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferLogPage proto.InternalMessageInfo
+
+func (m *TransferLogPage) GetLogs() []*TransferLog {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+func (m *TransferLogPage) GetBookmark() string {
+	if m != nil {
+		return m.Bookmark
+	}
+	return ""
+}
+
+func (m *TransferLogPage) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *TransferLogPage) GetIncomplete() bool {
+	if m != nil {
+		return m.Incomplete
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*Balance)(nil), "erc20.Balance")
+	proto.RegisterType((*RateLimit)(nil), "erc20.RateLimit")
+	proto.RegisterType((*CircuitBreaker)(nil), "erc20.CircuitBreaker")
+	proto.RegisterType((*TokenMetadata)(nil), "erc20.TokenMetadata")
+	proto.RegisterType((*TransferEvent)(nil), "erc20.TransferEvent")
+	proto.RegisterType((*TransferLog)(nil), "erc20.TransferLog")
+	proto.RegisterType((*TransferLogs)(nil), "erc20.TransferLogs")
+	proto.RegisterType((*TransferLogPage)(nil), "erc20.TransferLogPage")
+}
+
+func init() { proto.RegisterFile("erc20.proto", fileDescriptor_aad91a6883b404d3) }
+
+var fileDescriptor_aad91a6883b404d3 = []byte{
+	// 956 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x95, 0xc1, 0x6e, 0x1b, 0x37,
+	0x10, 0x86, 0xa1, 0xc8, 0xb2, 0x2d, 0xca, 0x92, 0x6c, 0xda, 0x4e, 0x98, 0xd4, 0x69, 0x55, 0x15,
+	0x49, 0xdd, 0x16, 0xb5, 0x0b, 0x17, 0xe8, 0xa1, 0x40, 0x0b, 0xc8, 0x41, 0x0a, 0x14, 0xb0, 0x51,
+	0x63, 0x63, 0xe4, 0xd0, 0xcb, 0x82, 0x5a, 0x8e, 0x65, 0x42, 0x4b, 0x72, 0x41, 0x52, 0xb2, 0xf4,
+	0x0c, 0x7d, 0xa4, 0xbe, 0x45, 0x9f, 0xa8, 0xe0, 0x90, 0xbb, 0x96, 0x73, 0xca, 0x4d, 0xf3, 0xcd,
+	0x90, 0xb3, 0x9c, 0x9f, 0xfc, 0x45, 0x7a, 0x60, 0x8b, 0x8b, 0x9f, 0xce, 0x2a, 0x6b, 0xbc, 0xa1,
+	0x1d, 0x0c, 0xc6, 0xbf, 0x91, 0x9d, 0x4b, 0x5e, 0x72, 0x5d, 0x00, 0x65, 0x64, 0x87, 0x0b, 0x61,
+	0xc1, 0x39, 0xd6, 0x1a, 0xb5, 0x4e, 0xbb, 0x59, 0x1d, 0x86, 0xcc, 0x34, 0x16, 0xb1, 0x67, 0x31,
+	0x93, 0xc2, 0xf1, 0x92, 0x74, 0x33, 0xee, 0xe1, 0x4a, 0x2a, 0xe9, 0xe9, 0x1b, 0x32, 0x78, 0x90,
+	0x5a, 0x98, 0x87, 0xdc, 0x41, 0x61, 0xb4, 0x88, 0xfb, 0xb4, 0xb3, 0x7e, 0xa4, 0x1f, 0x22, 0xa4,
+	0xdf, 0x90, 0xbe, 0xe2, 0xab, 0xdc, 0x5b, 0xae, 0xdd, 0x1d, 0x58, 0x87, 0x7b, 0xb6, 0xb3, 0x3d,
+	0xc5, 0x57, 0xb7, 0x35, 0xa3, 0xaf, 0x09, 0x09, 0x45, 0x5c, 0x99, 0x85, 0xf6, 0xac, 0x8d, 0x5d,
+	0xbb, 0x8a, 0xaf, 0x26, 0x08, 0xc6, 0x1f, 0xc9, 0xe0, 0x9d, 0xb4, 0xc5, 0x42, 0xfa, 0x4b, 0x0b,
+	0x7c, 0x0e, 0xf6, 0x73, 0x9b, 0xa7, 0x7d, 0x97, 0xa6, 0x5c, 0xa8, 0xfa, 0x34, 0x61, 0xdf, 0x8f,
+	0x08, 0xc6, 0xff, 0xee, 0x92, 0xfe, 0xad, 0x99, 0x83, 0xbe, 0x06, 0xcf, 0x05, 0xf7, 0x9c, 0x0e,
+	0xc8, 0x33, 0x29, 0xd2, 0x40, 0x9e, 0x49, 0x41, 0x29, 0xd9, 0xd2, 0xbc, 0x59, 0x8a, 0xbf, 0xe9,
+	0x73, 0xb2, 0xed, 0xd6, 0x6a, 0x6a, 0xca, 0xf4, 0xa1, 0x29, 0xa2, 0x47, 0xa4, 0x63, 0x1e, 0x34,
+	0x58, 0xb6, 0x85, 0x38, 0x06, 0xf4, 0x6b, 0xb2, 0xe7, 0x8d, 0xe7, 0x65, 0xee, 0x16, 0x55, 0x55,
+	0xae, 0x59, 0x07, 0x93, 0x3d, 0x64, 0x1f, 0x10, 0xd1, 0x57, 0x64, 0x57, 0x40, 0x21, 0x15, 0x2f,
+	0x1d, 0xdb, 0x1e, 0xb5, 0x4e, 0xfb, 0x59, 0x13, 0xd3, 0xef, 0xc8, 0xbe, 0x92, 0x3a, 0x07, 0x2d,
+	0x8c, 0x75, 0xa0, 0x40, 0x7b, 0xc7, 0x76, 0xf0, 0xa8, 0x43, 0x25, 0xf5, 0xfb, 0x0d, 0x4c, 0xcf,
+	0x09, 0xb1, 0xdc, 0x43, 0x5e, 0x06, 0x79, 0xd8, 0xee, 0xa8, 0x75, 0xda, 0xbb, 0xd8, 0x3f, 0x8b,
+	0xb7, 0xa0, 0x91, 0x2d, 0xeb, 0xda, 0xfa, 0x27, 0xfd, 0x9d, 0x0c, 0x8b, 0x38, 0xd6, 0x7c, 0x1a,
+	0xe7, 0xca, 0xba, 0xb8, 0xea, 0x38, 0xad, 0x7a, 0x3a, 0xf4, 0x6c, 0x50, 0x3c, 0x15, 0xe1, 0x57,
+	0xf2, 0xb2, 0x96, 0x35, 0x2f, 0x8c, 0x29, 0x85, 0x79, 0xd0, 0x8d, 0x1e, 0x04, 0x3f, 0xf2, 0x45,
+	0x5d, 0xf0, 0x2e, 0xe5, 0x6b, 0x65, 0xde, 0x90, 0x81, 0x05, 0x07, 0x76, 0x09, 0xf5, 0x60, 0x7a,
+	0x38, 0x98, 0x7e, 0xa2, 0x69, 0x34, 0x3f, 0x12, 0xba, 0x04, 0x2b, 0xef, 0x24, 0x88, 0xdc, 0xdf,
+	0x5b, 0x70, 0xf7, 0xa6, 0x14, 0x6c, 0x0f, 0x4b, 0x0f, 0xea, 0xcc, 0x6d, 0x9d, 0x08, 0xc3, 0x9e,
+	0x2e, 0xac, 0xce, 0xeb, 0x9b, 0xdd, 0x8f, 0xc3, 0x0e, 0x6c, 0xf2, 0x78, 0xbb, 0x95, 0xd4, 0x3e,
+	0xdc, 0xc4, 0xc1, 0xa8, 0x1d, 0x6e, 0x77, 0x0a, 0x43, 0x2f, 0x58, 0x15, 0xe5, 0x42, 0x80, 0xa8,
+	0x37, 0x00, 0xc7, 0x86, 0x58, 0x74, 0x50, 0x67, 0x26, 0x75, 0x82, 0x7e, 0x45, 0x7a, 0xa0, 0xa4,
+	0xcf, 0x61, 0x89, 0xa2, 0xec, 0x8f, 0x5a, 0xa7, 0xbb, 0x19, 0x09, 0xe8, 0x3d, 0x92, 0x20, 0x9d,
+	0xf3, 0x56, 0x16, 0x3e, 0xe7, 0x55, 0x65, 0xcd, 0x32, 0xc8, 0x7b, 0x80, 0x55, 0xc3, 0xc8, 0x27,
+	0x35, 0xc6, 0xeb, 0x7c, 0x2f, 0x3d, 0x94, 0xd2, 0xf9, 0x5c, 0x19, 0x01, 0x8c, 0x62, 0x61, 0xbf,
+	0xa1, 0xd7, 0x46, 0x00, 0x1d, 0x91, 0x9e, 0xe2, 0xe1, 0x6b, 0x35, 0xbe, 0xce, 0x43, 0xac, 0xd9,
+	0x44, 0xf4, 0x5b, 0x32, 0x54, 0xe9, 0x2e, 0xe7, 0xa5, 0x29, 0xe6, 0x20, 0xd8, 0x11, 0x56, 0x0d,
+	0x6a, 0x7c, 0x85, 0x34, 0x74, 0x5c, 0x38, 0x3e, 0x83, 0x5c, 0x81, 0x07, 0x2b, 0xf5, 0x8c, 0x1d,
+	0xc7, 0x8e, 0x48, 0xaf, 0x13, 0x0c, 0x0f, 0xa8, 0xb0, 0xc0, 0x7d, 0x18, 0x89, 0x67, 0xcf, 0x51,
+	0xd3, 0x6e, 0x22, 0x13, 0xbf, 0x99, 0xf6, 0x2b, 0xf6, 0x22, 0xbe, 0xaf, 0x44, 0x6e, 0x57, 0xa1,
+	0x89, 0x90, 0xae, 0x2a, 0xf9, 0x3a, 0xbf, 0x33, 0x56, 0x71, 0xcf, 0x58, 0x14, 0x39, 0xd1, 0x3f,
+	0x10, 0xa2, 0x6a, 0xbc, 0x98, 0x2f, 0xaa, 0x3c, 0xbe, 0x9f, 0x97, 0x49, 0x35, 0x64, 0x7f, 0x05,
+	0x44, 0x7f, 0x20, 0x07, 0x52, 0xf3, 0xc2, 0xcb, 0xa5, 0xf4, 0xeb, 0xbc, 0x02, 0x2b, 0x8d, 0x60,
+	0xaf, 0xf0, 0x73, 0xf6, 0x1f, 0x13, 0x37, 0xc8, 0xe9, 0x5b, 0x32, 0xc4, 0x8d, 0x72, 0xe4, 0x10,
+	0xbe, 0xfc, 0x8b, 0xe8, 0x0e, 0x88, 0x27, 0x48, 0x27, 0xd8, 0x17, 0xc5, 0xcb, 0x2b, 0x0b, 0x77,
+	0x72, 0xc5, 0x4e, 0x62, 0x5f, 0x64, 0x37, 0x88, 0xc2, 0xd3, 0x74, 0x9e, 0x6b, 0xc1, 0xad, 0x60,
+	0xaf, 0x31, 0xdd, 0xc4, 0xe3, 0xff, 0x5a, 0xa4, 0x5f, 0x5b, 0x18, 0x4a, 0x1e, 0xc6, 0x11, 0x37,
+	0xf4, 0xeb, 0x0a, 0x92, 0x8b, 0x74, 0x91, 0xdc, 0xae, 0xab, 0x68, 0x1c, 0xa0, 0x05, 0xd8, 0x64,
+	0x27, 0x29, 0xa2, 0x27, 0xa4, 0x6b, 0xa1, 0x90, 0x95, 0x84, 0x47, 0xf3, 0x6b, 0x40, 0x58, 0x95,
+	0x7c, 0x31, 0xfa, 0x4a, 0x8a, 0x9e, 0xb8, 0x46, 0xe7, 0x13, 0xd7, 0x38, 0x21, 0x5d, 0x2f, 0x15,
+	0x38, 0xcf, 0x55, 0x85, 0x96, 0xd2, 0xce, 0x1e, 0x41, 0x58, 0x59, 0x70, 0x0f, 0x33, 0x63, 0xd7,
+	0xe8, 0x25, 0xdd, 0xac, 0x89, 0xc7, 0x82, 0xf4, 0xea, 0x33, 0x5d, 0x99, 0x19, 0x3d, 0x24, 0x1d,
+	0xbf, 0xca, 0x1b, 0x4b, 0xdc, 0xf2, 0xab, 0x3f, 0x45, 0x30, 0x3a, 0xa9, 0x05, 0xac, 0x92, 0x95,
+	0xc7, 0x80, 0x7e, 0x4f, 0x3a, 0xb0, 0xac, 0x4f, 0xd0, 0xbb, 0x38, 0x4a, 0x1e, 0xf2, 0x64, 0x42,
+	0x59, 0x2c, 0x19, 0xff, 0x42, 0xf6, 0x36, 0xba, 0x38, 0xfa, 0x96, 0x6c, 0x95, 0x66, 0x16, 0x4c,
+	0xbc, 0x7d, 0xda, 0xbb, 0xa0, 0x9f, 0x2c, 0xbd, 0x32, 0xb3, 0x0c, 0xf3, 0xe3, 0x7f, 0x5a, 0x64,
+	0xb8, 0x41, 0x6f, 0xf8, 0x0c, 0x3e, 0x77, 0x6d, 0x38, 0xf5, 0xd4, 0x98, 0xb9, 0xe2, 0x76, 0x9e,
+	0xe6, 0xdf, 0xc4, 0xe1, 0x44, 0x60, 0xad, 0xb1, 0x69, 0xfa, 0x31, 0xa0, 0x5f, 0x12, 0x22, 0x75,
+	0x61, 0x54, 0x55, 0x82, 0x07, 0x9c, 0xfe, 0x6e, 0xb6, 0x41, 0x2e, 0x8f, 0xff, 0x3e, 0x9c, 0x49,
+	0x7f, 0xbf, 0x98, 0x9e, 0x15, 0x46, 0x9d, 0x63, 0xdf, 0xf3, 0x6a, 0x3a, 0xdd, 0xc6, 0xbf, 0xdc,
+	0x9f, 0xff, 0x1f, 0x00, 0x24, 0x78, 0x76, 0xb9, 0x81, 0x07, 0x00, 0x00,
+}
@@ -0,0 +1,115 @@
+// Package pb holds the protobuf messages of the query responses selected by the "format=proto" param,
+// erc20.proto is the schema and erc20.pb.go is generated from it
+package pb
+
+//go:generate protoc --go_out=paths=source_relative:. erc20.proto
+
+import (
+	"math/big"
+
+	"github.com/erc20/model"
+)
+
+// NewBalance returns the message of balanceOf response
+func NewBalance(address string, balance *big.Int) *Balance {
+	return &Balance{Address: address, Balance: amountString(balance)}
+}
+
+// NewTokenMetadata returns the message mirroring erc20 metadata
+func NewTokenMetadata(erc20 *model.ERC20Metadata) *TokenMetadata {
+	metadata := &TokenMetadata{
+		Id:                      erc20.ID,
+		Name:                    erc20.Name,
+		Symbol:                  erc20.Symbol,
+		Owner:                   erc20.Owner,
+		TotalSupply:             amountString(erc20.TotalSupply),
+		Decimals:                uint32(erc20.Decimals),
+		MinEndorsements:         int64(erc20.MinEndorsements),
+		TransferCooldownSeconds: erc20.TransferCooldownSeconds,
+		ReserveSupply:           amountString(erc20.ReserveSupply),
+		VerifiedThreshold:       amountString(erc20.VerifiedThreshold),
+		BurnAddress:             erc20.BurnAddress,
+		Minters:                 erc20.Minters,
+		ExcludedAddresses:       erc20.ExcludedAddresses,
+		EmitEvents:              erc20.IsEmitEvents(),
+		StrictApprovals:         erc20.StrictApprovals,
+		WhitelistMode:           erc20.WhitelistMode,
+		Maintenance:             erc20.Maintenance,
+		MetadataLocked:          erc20.MetadataLocked,
+		UsageMetering:           erc20.UsageMetering,
+		CreatedAt:               erc20.CreatedAt,
+		CreatedTx:               erc20.CreatedTx,
+		DisplayFormat:           erc20.DisplayFormat,
+		BackupOwner:             erc20.BackupOwner,
+		InactivityPeriod:        erc20.InactivityPeriod,
+		OwnerActiveAt:           erc20.OwnerActiveAt,
+		EventPrefix:             erc20.EventPrefix,
+		Standard:                erc20.Standard,
+	}
+	if erc20.RateLimit != nil {
+		metadata.RateLimit = &RateLimit{
+			WindowSeconds: erc20.RateLimit.WindowSeconds,
+			MaxTransfers:  int64(erc20.RateLimit.MaxTransfers),
+			MaxAmount:     amountString(erc20.RateLimit.MaxAmount),
+		}
+	}
+	if erc20.CircuitBreaker != nil {
+		metadata.CircuitBreaker = &CircuitBreaker{
+			WindowSeconds: erc20.CircuitBreaker.WindowSeconds,
+			MaxVolume:     amountString(erc20.CircuitBreaker.MaxVolume),
+		}
+	}
+
+	return metadata
+}
+
+// NewTransferLog returns the message mirroring the txlog entry
+func NewTransferLog(transferLog model.TransferLog) *TransferLog {
+	event := transferLog.Event
+	transferEvent := &TransferEvent{
+		EventType: event.EventType,
+		Sender:    event.Sender,
+		Recipient: event.Recipient,
+		Amount:    amountString(event.Amount),
+		Timestamp: event.Timestamp,
+		Category:  event.Category,
+	}
+	if event.Decimals != nil {
+		transferEvent.Decimals = uint32(*event.Decimals)
+	}
+
+	return &TransferLog{TxId: transferLog.TxID, Index: int64(transferLog.Index), Event: transferEvent}
+}
+
+// NewTransferLogs returns the message of eventByTxId response
+func NewTransferLogs(transferLogs []model.TransferLog) *TransferLogs {
+	return &TransferLogs{Logs: newTransferLogList(transferLogs)}
+}
+
+// NewTransferLogPage returns the message mirroring the page of txlog entries
+func NewTransferLogPage(page *model.TransferLogPage) *TransferLogPage {
+	return &TransferLogPage{
+		Logs:       newTransferLogList(page.Logs),
+		Bookmark:   page.Bookmark,
+		Error:      page.Error,
+		Incomplete: page.Incomplete,
+	}
+}
+
+func newTransferLogList(transferLogs []model.TransferLog) []*TransferLog {
+	logs := []*TransferLog{}
+	for _, transferLog := range transferLogs {
+		logs = append(logs, NewTransferLog(transferLog))
+	}
+
+	return logs
+}
+
+// amountString returns amount as decimal string, empty for nil (null in JSON)
+func amountString(amount *big.Int) string {
+	if amount == nil {
+		return ""
+	}
+
+	return amount.String()
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetAccountInfo returns the account-created marker of address
+// Returns nil if the address has never received tokens
+func GetAccountInfo(stub shim.ChaincodeStubInterface, address string) (*model.AccountInfo, error) {
+	// create composite key for account - account/{address}
+	accountKey, err := stub.CreateCompositeKey(AccountPrefix, []string{address})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, AccountPrefix, err.Error())
+	}
+
+	accountBytes, err := stub.GetState(accountKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, accountKey, err.Error())
+	}
+	if accountBytes == nil {
+		return nil, nil
+	}
+
+	accountInfo := model.AccountInfo{}
+	err = json.Unmarshal(accountBytes, &accountInfo)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, accountKey, err.Error())
+	}
+
+	return &accountInfo, nil
+}
+
+func SaveAccountInfo(stub shim.ChaincodeStubInterface, accountInfo *model.AccountInfo) error {
+	// create composite key for account - account/{address}
+	accountKey, err := stub.CreateCompositeKey(AccountPrefix, []string{accountInfo.Address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, AccountPrefix, err.Error())
+	}
+
+	accountBytes, err := json.Marshal(accountInfo)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, accountKey, err.Error())
+	}
+
+	err = stub.PutState(accountKey, accountBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, accountKey, err.Error())
+	}
+
+	return nil
+}
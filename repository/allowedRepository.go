@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// allowedMarker is the value of allowed/{address}
+const allowedMarker = "1"
+
+// IsAllowed returns whether owner added address to the recipient whitelist
+func IsAllowed(stub shim.ChaincodeStubInterface, address string) (bool, error) {
+	// create composite key for whitelist - allowed/{address}
+	allowedKey, err := stub.CreateCompositeKey(AllowedPrefix, []string{address})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, AllowedPrefix, err.Error())
+	}
+
+	allowedBytes, err := stub.GetState(allowedKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, allowedKey, err.Error())
+	}
+
+	return allowedBytes != nil, nil
+}
+
+// SaveAllowed adds address to the recipient whitelist, or removes it when allowed is false
+func SaveAllowed(stub shim.ChaincodeStubInterface, address string, allowed bool) error {
+	// create composite key for whitelist - allowed/{address}
+	allowedKey, err := stub.CreateCompositeKey(AllowedPrefix, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, AllowedPrefix, err.Error())
+	}
+
+	if !allowed {
+		err = stub.DelState(allowedKey)
+		if err != nil {
+			return model.NewCustomError(model.DelStateErrorType, allowedKey, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.PutState(allowedKey, []byte(allowedMarker))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, allowedKey, err.Error())
+	}
+
+	return nil
+}
@@ -1,19 +1,19 @@
 package repository
 
 import (
-	"strconv"
+	"encoding/json"
+	"sort"
 
 	"github.com/erc20/model"
+	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-const approvalCompositeKey = "approval"
-
 func SaveAllowance(stub shim.ChaincodeStubInterface, owner, spender, allowance string) error {
 	// create composite key for allowance - approval/{owner}/{spender}
-	approvalKey, err := stub.CreateCompositeKey(approvalCompositeKey, []string{owner, spender})
+	approvalKey, err := stub.CreateCompositeKey(AllowancePrefix, []string{owner, spender})
 	if err != nil {
-		return model.NewCustomError(model.CreateCompositeKeyErrorType, approvalCompositeKey, err.Error())
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, AllowancePrefix, err.Error())
 	}
 
 	// save allowance amount
@@ -25,11 +25,71 @@ func SaveAllowance(stub shim.ChaincodeStubInterface, owner, spender, allowance s
 	return nil
 }
 
+// GetRecurringAllowance returns the recurring allowance of spender over the owner tokens, nil if none
+func GetRecurringAllowance(stub shim.ChaincodeStubInterface, owner, spender string) (*model.RecurringAllowance, error) {
+	// create composite key for recurring allowance - recurring/{owner}/{spender}
+	recurringKey, err := stub.CreateCompositeKey(RecurringPrefix, []string{owner, spender})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, RecurringPrefix, err.Error())
+	}
+
+	recurringBytes, err := stub.GetState(recurringKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, recurringKey, err.Error())
+	}
+	if recurringBytes == nil {
+		return nil, nil
+	}
+
+	recurring := model.RecurringAllowance{}
+	err = json.Unmarshal(recurringBytes, &recurring)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, recurringKey, err.Error())
+	}
+
+	return &recurring, nil
+}
+
+func SaveRecurringAllowance(stub shim.ChaincodeStubInterface, owner, spender string, recurring *model.RecurringAllowance) error {
+	// create composite key for recurring allowance - recurring/{owner}/{spender}
+	recurringKey, err := stub.CreateCompositeKey(RecurringPrefix, []string{owner, spender})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, RecurringPrefix, err.Error())
+	}
+
+	recurringBytes, err := json.Marshal(recurring)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, recurringKey, err.Error())
+	}
+
+	err = stub.PutState(recurringKey, recurringBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, recurringKey, err.Error())
+	}
+
+	return nil
+}
+
+// DeleteRecurringAllowance stops resetting the allowance of spender, the current allowance is kept
+func DeleteRecurringAllowance(stub shim.ChaincodeStubInterface, owner, spender string) error {
+	recurringKey, err := stub.CreateCompositeKey(RecurringPrefix, []string{owner, spender})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, RecurringPrefix, err.Error())
+	}
+
+	err = stub.DelState(recurringKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, recurringKey, err.Error())
+	}
+
+	return nil
+}
+
 func GetAllowanceBytes(stub shim.ChaincodeStubInterface, owner, spender string, isZero bool) ([]byte, error) {
 	// create composite key
-	approvalKey, err := stub.CreateCompositeKey(approvalCompositeKey, []string{owner, spender})
+	approvalKey, err := stub.CreateCompositeKey(AllowancePrefix, []string{owner, spender})
 	if err != nil {
-		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, approvalCompositeKey, err.Error())
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, AllowancePrefix, err.Error())
 	}
 
 	allowanceBytes, err := stub.GetState(approvalKey)
@@ -46,9 +106,9 @@ func GetAllowanceBytes(stub shim.ChaincodeStubInterface, owner, spender string,
 
 func GetApprovalList(stub shim.ChaincodeStubInterface, owner string) ([]model.Approval, error) {
 	// get all approval list (format is iterator)
-	approvalIterator, err := stub.GetStateByPartialCompositeKey(approvalCompositeKey, []string{owner})
+	approvalIterator, err := stub.GetStateByPartialCompositeKey(AllowancePrefix, []string{owner})
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, approvalCompositeKey, err.Error())
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, AllowancePrefix, err.Error())
 	}
 
 	// make slice for return value
@@ -60,25 +120,66 @@ func GetApprovalList(stub shim.ChaincodeStubInterface, owner string) ([]model.Ap
 		for approvalIterator.HasNext() {
 			approvalKV, _ := approvalIterator.Next()
 
-			// get spender address
-			_, addresses, err := stub.SplitCompositeKey(approvalKV.GetKey())
+			// get spender address - approval/{owner}/{spender}
+			addresses, err := splitKeyAttributes(stub, approvalKV.GetKey(), 2)
 			if err != nil {
-				return nil, model.NewCustomError(model.SpliteCompositeKeyErrorType, approvalKV.GetKey(), err.Error())
+				return nil, err
+			}
+			if addresses == nil {
+				continue
 			}
 			spenderAddress := addresses[1]
 
 			// get amount
 			amountBytes := approvalKV.GetValue()
-			amountInt, err := strconv.Atoi(string(amountBytes))
+			amount, err := util.ParseBigBalance("allowance", string(amountBytes))
 			if err != nil {
-				return nil, model.NewCustomError(model.ConvertErrorType, string(amountBytes), err.Error())
+				return nil, err
 			}
 
 			// add approval result
-			approval := model.Approval{Owner: owner, Spender: spenderAddress, Allowance: amountInt}
+			approval := model.Approval{Owner: owner, Spender: spenderAddress, Allowance: amount}
 			approvalSlice = append(approvalSlice, approval)
 		}
 	}
 
 	return approvalSlice, nil
 }
+
+// GetAllowanceHistory returns the changes of allowance of spender over the owner tokens
+// sorted by transaction timestamp (oldest first)
+func GetAllowanceHistory(stub shim.ChaincodeStubInterface, owner, spender string) ([]model.AllowanceChange, error) {
+	// create composite key for allowance - approval/{owner}/{spender}
+	approvalKey, err := stub.CreateCompositeKey(AllowancePrefix, []string{owner, spender})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, AllowancePrefix, err.Error())
+	}
+
+	historyIterator, err := stub.GetHistoryForKey(approvalKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetHistoryErrorType, approvalKey, err.Error())
+	}
+	defer historyIterator.Close()
+
+	changes := []model.AllowanceChange{}
+	for historyIterator.HasNext() {
+		modification, err := historyIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetHistoryErrorType, approvalKey, err.Error())
+		}
+
+		changes = append(changes, model.AllowanceChange{
+			TxID:      modification.GetTxId(),
+			Timestamp: modification.GetTimestamp().GetSeconds(),
+			Allowance: string(modification.GetValue()),
+			IsDelete:  modification.GetIsDelete(),
+		})
+	}
+
+	// the order of history differs between Fabric versions
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Timestamp < changes[j].Timestamp
+	})
+
+	return changes, nil
+}
@@ -1,92 +1,243 @@
 package repository
 
 import (
-	"encoding/json"
-	"strconv"
+	"math/big"
 
 	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
 )
 
-func SaveERC20Metadata(stub shim.ChaincodeStubInterface, tokenName, symbol, owner string, amount uint64) error {
-	// make metadata
-	erc20 := model.NewERC20MetaData(tokenName, symbol, owner, amount)
-	erc20Bytes, err := json.Marshal(erc20)
+// SaveBalance saves balance formatted by util.FormatBigBalance, the format GetBalance parses
+func SaveBalance(stub shim.ChaincodeStubInterface, owner string, balance *big.Int) error {
+	balanceKey, err := stub.CreateCompositeKey(BalancePrefix, []string{owner})
 	if err != nil {
-		return model.NewCustomError(model.MarshalErrorType, "erc20", err.Error())
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, "balance", err.Error())
 	}
+	assertKeyNamespace(stub, balanceKey, BalancePrefix)
 
-	// save token meta data
-	err = stub.PutState(tokenName, erc20Bytes)
+	err = stub.PutState(balanceKey, []byte(util.FormatBigBalance(balance)))
 	if err != nil {
-		return model.NewCustomError(model.PutStateErrorType, "erc20Metadata", err.Error())
+		return model.NewCustomError(model.PutStateErrorType, "balance", err.Error())
 	}
 
 	return nil
 }
 
-func GetERC20Metadata(stub shim.ChaincodeStubInterface, tokenName string) (*model.ERC20Metadata, error) {
-	// Get ERC20 Metadata
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, err := stub.GetState(tokenName)
+// DeleteBalance deletes the balance of owner, GetBalance reads a deleted balance as missing
+func DeleteBalance(stub shim.ChaincodeStubInterface, owner string) error {
+	balanceKey, err := stub.CreateCompositeKey(BalancePrefix, []string{owner})
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, "balance", err.Error())
 	}
-	err = json.Unmarshal(erc20Bytes, &erc20)
+
+	err = stub.DelState(balanceKey)
 	if err != nil {
-		return nil, model.NewCustomError(model.UnMarshalErrorType, "erc20Metadata", err.Error())
+		return model.NewCustomError(model.DelStateErrorType, "balance", err.Error())
 	}
-	return &erc20, nil
+
+	return nil
 }
 
-func GetERC20TotalSupply(stub shim.ChaincodeStubInterface, tokenName string) (*uint64, error) {
-	// Get ERC20 Metadata
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, err := stub.GetState(tokenName)
+func GetBalance(stub shim.ChaincodeStubInterface, owner string, isZero bool) (*big.Int, error) {
+	balanceKey, err := stub.CreateCompositeKey(BalancePrefix, []string{owner})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, "balance", err.Error())
+	}
+
+	amountBytes, err := stub.GetState(balanceKey)
 	if err != nil {
 		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
 	}
-	err = json.Unmarshal(erc20Bytes, &erc20)
+
+	if isZero && amountBytes == nil {
+		amountBytes = []byte("0")
+	}
+	err = checkValueLength("balance", amountBytes, maxBalanceValueLength)
 	if err != nil {
-		return nil, model.NewCustomError(model.UnMarshalErrorType, "erc20Metadata", err.Error())
+		return nil, err
 	}
-	return erc20.GetTotalSupply(), nil
+
+	amount, err := util.ParseBigBalance("amount", string(amountBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return amount, nil
 }
 
-func SaveBalance(stub shim.ChaincodeStubInterface, owner, balance string) error {
-	err := stub.PutState(owner, []byte(balance))
+// GetBalancePage returns one page of address & balance pairs and the next bookmark
+func GetBalancePage(stub shim.ChaincodeStubInterface, pageSize int32, bookmark string) ([]model.AccountBalance, string, error) {
+	balanceIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(BalancePrefix, []string{}, pageSize, bookmark)
 	if err != nil {
-		return model.NewCustomError(model.PutStateErrorType, "balance", err.Error())
+		return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, BalancePrefix, err.Error())
 	}
+	defer balanceIterator.Close()
 
-	return nil
+	balances := []model.AccountBalance{}
+	for balanceIterator.HasNext() {
+		balanceKV, err := balanceIterator.Next()
+		if err != nil {
+			return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, BalancePrefix, err.Error())
+		}
+
+		// balance/{address}
+		keyParts, err := splitKeyAttributes(stub, balanceKV.GetKey(), 1)
+		if err != nil {
+			return nil, "", err
+		}
+		if keyParts == nil {
+			continue
+		}
+
+		err = checkValueLength("balance", balanceKV.GetValue(), maxBalanceValueLength)
+		if err != nil {
+			return nil, "", err
+		}
+		balance, err := util.ParseBigBalance("balance", string(balanceKV.GetValue()))
+		if err != nil {
+			return nil, "", err
+		}
+		balances = append(balances, model.AccountBalance{Address: keyParts[0], Balance: balance})
+	}
+
+	return balances, metadata.GetBookmark(), nil
 }
 
-func GetBalanceBytes(stub shim.ChaincodeStubInterface, owner string, isZeror bool) ([]byte, error) {
-	amountBytes, err := stub.GetState(owner)
+// GetBalanceRange returns one page of address & balance pairs whose address is in [startAddress, endAddress)
+// and whose balance is at least minBalance, the empty addresses leave the range open
+// Balances are composite keys which GetStateByRange rejects, so the balance keys are scanned
+// from the start address (or bookmark) and the scan stops at the end address
+// The filter applies after the fetch, so a page may hold fewer pairs than pageSize, even none
+// An iterator error mid-scan returns the pairs gathered so far marked incomplete
+func GetBalanceRange(stub shim.ChaincodeStubInterface, startAddress, endAddress string, minBalance *big.Int, pageSize int32, bookmark string) (*model.BalancePage, error) {
+	if startAddress != "" {
+		startKey, err := stub.CreateCompositeKey(BalancePrefix, []string{startAddress})
+		if err != nil {
+			return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, "balance", err.Error())
+		}
+		if bookmark < startKey {
+			bookmark = startKey
+		}
+	}
+
+	balanceIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(BalancePrefix, []string{}, pageSize, bookmark)
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, owner, err.Error())
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, BalancePrefix, err.Error())
 	}
-	if amountBytes == nil {
-		amountBytes = []byte("0")
+	defer balanceIterator.Close()
+
+	page := &model.BalancePage{Balances: []model.AccountBalance{}, Bookmark: metadata.GetBookmark()}
+	lastKey := ""
+	for balanceIterator.HasNext() {
+		balanceKV, err := balanceIterator.Next()
+		if err != nil {
+			partialErr := model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, BalancePrefix, err.Error())
+			logger.Error(partialErr.Error())
+			page.SetError(partialErr)
+			page.Bookmark = resumeBookmark(bookmark, lastKey)
+			break
+		}
+		lastKey = balanceKV.GetKey()
+
+		// balance/{address}
+		keyParts, err := splitKeyAttributes(stub, balanceKV.GetKey(), 1)
+		if err != nil {
+			return nil, err
+		}
+		if keyParts == nil {
+			continue
+		}
+
+		// the keys are sorted, so the rest is out of range
+		if endAddress != "" && keyParts[0] >= endAddress {
+			page.Bookmark = ""
+			break
+		}
+
+		err = checkValueLength("balance", balanceKV.GetValue(), maxBalanceValueLength)
+		if err != nil {
+			return nil, err
+		}
+		balance, err := util.ParseBigBalance("balance", string(balanceKV.GetValue()))
+		if err != nil {
+			return nil, err
+		}
+		if minBalance != nil && balance.Cmp(minBalance) < 0 {
+			continue
+		}
+		page.Balances = append(page.Balances, model.AccountBalance{Address: keyParts[0], Balance: balance})
 	}
-	return amountBytes, nil
+
+	return page, nil
 }
 
-func GetBalance(stub shim.ChaincodeStubInterface, owner string, isZero bool) (*int, error) {
-	amountBytes, err := stub.GetState(owner)
+// HasBalanceHistory returns whether the balance of address has ever been written
+// Only the first entry of history is read
+func HasBalanceHistory(stub shim.ChaincodeStubInterface, owner string) (bool, error) {
+	balanceKey, err := stub.CreateCompositeKey(BalancePrefix, []string{owner})
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, "balance", err.Error())
 	}
 
-	if isZero && amountBytes == nil {
-		amountBytes = []byte("0")
+	historyIterator, err := stub.GetHistoryForKey(balanceKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetHistoryErrorType, balanceKey, err.Error())
+	}
+	defer historyIterator.Close()
+
+	return historyIterator.HasNext(), nil
+}
+
+// GetBalanceAtTime returns the balance of owner effective at the unix seconds at,
+// the value of the latest write with a transaction timestamp at or before at, 0 if there is none or it is a delete
+// It reads GetHistoryForKey, so it needs the history database of the peer enabled (ledger.history.enableHistoryDatabase)
+func GetBalanceAtTime(stub shim.ChaincodeStubInterface, owner string, at int64) (*big.Int, error) {
+	balanceKey, err := stub.CreateCompositeKey(BalancePrefix, []string{owner})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, "balance", err.Error())
+	}
+
+	historyIterator, err := stub.GetHistoryForKey(balanceKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetHistoryErrorType, balanceKey, err.Error())
+	}
+	defer historyIterator.Close()
+
+	// the order of history differs between Fabric versions, so the latest effective write is searched
+	var effective *queryresult.KeyModification
+	for historyIterator.HasNext() {
+		modification, err := historyIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetHistoryErrorType, balanceKey, err.Error())
+		}
+
+		modificationTime := modification.GetTimestamp()
+		if modificationTime.GetSeconds() > at {
+			continue
+		}
+		if effective == nil || !isBefore(modificationTime, effective.GetTimestamp()) {
+			effective = modification
+		}
+	}
+
+	if effective == nil || effective.GetIsDelete() {
+		return big.NewInt(0), nil
 	}
 
-	amount, err := strconv.Atoi(string(amountBytes))
+	err = checkValueLength("balance", effective.GetValue(), maxBalanceValueLength)
 	if err != nil {
-		return nil, model.NewCustomError(model.ConvertErrorType, "amount", err.Error())
+		return nil, err
 	}
+	return util.ParseBigBalance("balance", string(effective.GetValue()))
+}
 
-	return &amount, nil
+// isBefore returns whether the timestamp a is strictly before b
+func isBefore(a, b *timestamp.Timestamp) bool {
+	if a.GetSeconds() != b.GetSeconds() {
+		return a.GetSeconds() < b.GetSeconds()
+	}
+	return a.GetNanos() < b.GetNanos()
 }
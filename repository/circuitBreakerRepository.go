@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetCircuitBreakerState returns the circuit breaker state stored under config/circuitBreaker
+// Returns zero state (not paused) if the circuit breaker never counted a transfer
+func GetCircuitBreakerState(stub shim.ChaincodeStubInterface) (*model.CircuitBreakerState, error) {
+	stateKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"circuitBreaker"})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	stateBytes, err := stub.GetState(stateKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, stateKey, err.Error())
+	}
+
+	state := model.CircuitBreakerState{Volume: big.NewInt(0)}
+	if stateBytes == nil {
+		return &state, nil
+	}
+	err = json.Unmarshal(stateBytes, &state)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, stateKey, err.Error())
+	}
+	if state.Volume == nil {
+		state.Volume = big.NewInt(0)
+	}
+
+	return &state, nil
+}
+
+func SaveCircuitBreakerState(stub shim.ChaincodeStubInterface, state *model.CircuitBreakerState) error {
+	stateKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"circuitBreaker"})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, stateKey, err.Error())
+	}
+
+	err = stub.PutState(stateKey, stateBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, stateKey, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetEscrow returns the escrow stored under escrowID
+// Returns nil if the escrow doesn't exist
+func GetEscrow(stub shim.ChaincodeStubInterface, escrowID string) (*model.Escrow, error) {
+	// create composite key for escrow - escrow/{escrowID}
+	escrowKey, err := stub.CreateCompositeKey(EscrowPrefix, []string{escrowID})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, EscrowPrefix, err.Error())
+	}
+
+	escrowBytes, err := stub.GetState(escrowKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, escrowKey, err.Error())
+	}
+	if escrowBytes == nil {
+		return nil, nil
+	}
+
+	escrow := model.Escrow{}
+	err = json.Unmarshal(escrowBytes, &escrow)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, escrowKey, err.Error())
+	}
+
+	return &escrow, nil
+}
+
+func SaveEscrow(stub shim.ChaincodeStubInterface, escrow *model.Escrow) error {
+	// create composite key for escrow - escrow/{escrowID}
+	escrowKey, err := stub.CreateCompositeKey(EscrowPrefix, []string{escrow.ID})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, EscrowPrefix, err.Error())
+	}
+
+	escrowBytes, err := json.Marshal(escrow)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, escrowKey, err.Error())
+	}
+
+	err = stub.PutState(escrowKey, escrowBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, escrowKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetEscrowPage returns one page of escrows and the next bookmark
+func GetEscrowPage(stub shim.ChaincodeStubInterface, pageSize int32, bookmark string) ([]model.Escrow, string, error) {
+	escrowIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(EscrowPrefix, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, EscrowPrefix, err.Error())
+	}
+	defer escrowIterator.Close()
+
+	escrows := []model.Escrow{}
+	for escrowIterator.HasNext() {
+		escrowKV, err := escrowIterator.Next()
+		if err != nil {
+			return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, EscrowPrefix, err.Error())
+		}
+
+		escrow := model.Escrow{}
+		err = json.Unmarshal(escrowKV.GetValue(), &escrow)
+		if err != nil {
+			return nil, "", model.NewCustomError(model.UnMarshalErrorType, escrowKV.GetKey(), err.Error())
+		}
+		escrows = append(escrows, escrow)
+	}
+
+	return escrows, metadata.GetBookmark(), nil
+}
@@ -2,41 +2,245 @@ package repository
 
 import (
 	"encoding/json"
+	"math/big"
 
 	"github.com/erc20/model"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
+// Event names are scoped by token as {base event key}.{tokenID} (e.g. transferEvent.dappToken)
+// Fabric listeners filter event names by regex, so "^transferEvent\." matches the transfers of all tokens
+// and "^transferEvent\.dappToken$" only those of one token. The base key is also the eventType of payload
+// A token created with eventPrefix emits {eventPrefix}.{base event key}.{tokenID} (e.g. acme.transferEvent.dappToken),
+// so "^acme\." matches every event of the tokens sharing the prefix
 const (
-	TransferEventKey = "transferEvent"
-	ApprovalEventKey = "approvalEvent"
+	TransferEventKey             = "transferEvent"
+	TransferFromEventKey         = "transferFromEvent"
+	ApprovalEventKey             = "approvalEvent"
+	AllowancesRevokedEventKey    = "allowancesRevokedEvent"
+	MetadataUpdatedEventKey      = "metadataUpdatedEvent"
+	ClawbackEventKey             = "clawbackEvent"
+	BatchTransferEventKey        = "batchTransferEvent"
+	TokenCreatedEventKey         = "tokenCreatedEvent"
+	EscrowEventKey               = "escrowEvent"
+	SwapEventKey                 = "swapEvent"
+	OwnershipRenouncedEventKey   = "ownershipRenouncedEvent"
+	BurnEventKey                 = "burnEvent"
+	SupplyReconciledEventKey     = "supplyReconciledEvent"
+	OwnershipTransferredEventKey = "ownershipTransferredEvent"
+	MintEventKey                 = "mintEvent"
+	PausedEventKey               = "pausedEvent"
+	SweepEventKey                = "sweepEvent"
 )
 
-func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string, amount int) error {
-	transferEvent := model.NewTransferEvent(sender, spender, amount)
-	transferEventBytes, err := json.Marshal(transferEvent)
-	if err != nil {
-		return model.NewCustomError(model.MarshalErrorType, TransferEventKey, err.Error())
+// EventName returns the token scoped name of event
+func EventName(eventKey, tokenID string) string {
+	return eventKey + "." + tokenID
+}
+
+// PrefixedEventName returns the token scoped name of event under eventPrefix, empty eventPrefix is EventName
+func PrefixedEventName(eventPrefix, eventKey, tokenID string) string {
+	if len(eventPrefix) == 0 {
+		return EventName(eventKey, tokenID)
+	}
+	return eventPrefix + "." + EventName(eventKey, tokenID)
+}
+
+// getEventTimestamp returns the unix seconds of transaction timestamp for events
+// Returns 0 rather than failing the transaction when the timestamp is unavailable
+func getEventTimestamp(stub shim.ChaincodeStubInterface) int64 {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil || txTimestamp == nil {
+		return 0
+	}
+	return txTimestamp.GetSeconds()
+}
+
+// setEvent sets event as the event of transaction under the token scoped name
+// Empty tokenID is resolved to the tokenID instantiated by Init
+// The metadata is read for eventPrefix, so it is in the read set of every function emitting an event
+func setEvent(stub shim.ChaincodeStubInterface, eventKey, tokenID string, event interface{}) error {
+	var erc20 *model.ERC20Metadata
+	var err error
+	if len(tokenID) == 0 {
+		erc20, err = GetTokenMetadata(stub)
+	} else {
+		erc20, err = GetERC20Metadata(stub, tokenID)
 	}
-	err = stub.SetEvent(TransferEventKey, transferEventBytes)
 	if err != nil {
-		return model.NewCustomError(model.SetEventErrorType, TransferEventKey, err.Error())
+		return err
 	}
 
-	return nil
+	return setTokenEvent(stub, eventKey, erc20, event)
 }
 
-func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string, allowance int) error {
-	approvalEvent := model.NewApproval(owner, spender, allowance)
-	approvalBytes, err := json.Marshal(approvalEvent)
+// setTokenEvent sets event as the event of transaction under the name scoped by erc20
+func setTokenEvent(stub shim.ChaincodeStubInterface, eventKey string, erc20 *model.ERC20Metadata, event interface{}) error {
+	eventBytes, err := json.Marshal(event)
 	if err != nil {
-		return model.NewCustomError(model.MarshalErrorType, ApprovalEventKey, err.Error())
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
 	}
 
-	err = stub.SetEvent(ApprovalEventKey, approvalBytes)
+	err = stub.SetEvent(PrefixedEventName(erc20.EventPrefix, eventKey, erc20.ID), eventBytes)
 	if err != nil {
-		return model.NewCustomError(model.SetEventErrorType, ApprovalEventKey, err.Error())
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
 	}
 
 	return nil
 }
+
+func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string, amount *big.Int, decimals uint8) error {
+	transferEvent := model.NewTransferEvent(sender, spender, amount)
+	transferEvent.EventType = TransferEventKey
+	transferEvent.Decimals = &decimals
+	transferEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, TransferEventKey, "", transferEvent)
+}
+
+// EmitCategorizedTransferEvent emits the transfer event of categorizedTransfer carrying the category
+func EmitCategorizedTransferEvent(stub shim.ChaincodeStubInterface, sender, recipient string, amount *big.Int, decimals uint8, category string) error {
+	transferEvent := model.NewTransferEvent(sender, recipient, amount)
+	transferEvent.EventType = TransferEventKey
+	transferEvent.Decimals = &decimals
+	transferEvent.Timestamp = getEventTimestamp(stub)
+	transferEvent.Category = category
+
+	return setEvent(stub, TransferEventKey, "", transferEvent)
+}
+
+// EmitTransferFromEvent emits the transfer of transferFrom & burnFrom with the remaining allowance of spender
+// It replaces Transfer & Approval events for the transferFrom path
+func EmitTransferFromEvent(stub shim.ChaincodeStubInterface, owner, spender, recipient string, amount *big.Int, remainingAllowance *big.Int, decimals uint8) error {
+	transferFromEvent := model.NewTransferFromEvent(owner, spender, recipient, amount, remainingAllowance)
+	transferFromEvent.EventType = TransferFromEventKey
+	transferFromEvent.Decimals = decimals
+	transferFromEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, TransferFromEventKey, "", transferFromEvent)
+}
+
+func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string, allowance *big.Int) error {
+	approvalEvent := model.NewApproval(owner, spender, allowance)
+	approvalEvent.EventType = ApprovalEventKey
+	approvalEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, ApprovalEventKey, "", approvalEvent)
+}
+
+// EmitAllowancesRevokedEvent emits one event for all allowances of owner revoked in the transaction
+func EmitAllowancesRevokedEvent(stub shim.ChaincodeStubInterface, owner string, spenders []string) error {
+	revokedEvent := model.NewAllowancesRevokedEvent(owner, spenders)
+	revokedEvent.EventType = AllowancesRevokedEventKey
+	revokedEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, AllowancesRevokedEventKey, "", revokedEvent)
+}
+
+func EmitMetadataUpdatedEvent(stub shim.ChaincodeStubInterface, tokenID, field, oldValue, newValue string) error {
+	metadataUpdatedEvent := model.NewMetadataUpdatedEvent(tokenID, field, oldValue, newValue)
+	metadataUpdatedEvent.EventType = MetadataUpdatedEventKey
+
+	return setEvent(stub, MetadataUpdatedEventKey, tokenID, metadataUpdatedEvent)
+}
+
+// EmitOwnershipTransferredEvent emits the ownership claimed by the backup owner from the inactive owner
+func EmitOwnershipTransferredEvent(stub shim.ChaincodeStubInterface, tokenID, previousOwner, newOwner string) error {
+	transferredEvent := model.NewOwnershipTransferredEvent(tokenID, previousOwner, newOwner)
+	transferredEvent.EventType = OwnershipTransferredEventKey
+	transferredEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, OwnershipTransferredEventKey, tokenID, transferredEvent)
+}
+
+func EmitOwnershipRenouncedEvent(stub shim.ChaincodeStubInterface, tokenID, previousOwner string) error {
+	renouncedEvent := model.NewOwnershipRenouncedEvent(tokenID, previousOwner)
+	renouncedEvent.EventType = OwnershipRenouncedEventKey
+	renouncedEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, OwnershipRenouncedEventKey, tokenID, renouncedEvent)
+}
+
+// EmitPausedEvent emits the pause of token tripped by the circuit breaker
+func EmitPausedEvent(stub shim.ChaincodeStubInterface, pausedEvent *model.PausedEvent) error {
+	pausedEvent.EventType = PausedEventKey
+	pausedEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, PausedEventKey, pausedEvent.TokenID, pausedEvent)
+}
+
+// EmitBurnEvent emits the burn of amount transferred by burner to the burn address
+func EmitBurnEvent(stub shim.ChaincodeStubInterface, burner, burnAddress string, amount *big.Int, decimals uint8) error {
+	burnEvent := model.NewBurnEvent(burner, burnAddress, amount, decimals)
+	burnEvent.EventType = BurnEventKey
+	burnEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, BurnEventKey, "", burnEvent)
+}
+
+// EmitMintEvent sets the timestamp & eventType of mintEvent and emits it
+func EmitMintEvent(stub shim.ChaincodeStubInterface, mintEvent *model.MintEvent) error {
+	mintEvent.EventType = MintEventKey
+	mintEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, MintEventKey, mintEvent.TokenID, mintEvent)
+}
+
+// EmitSupplyReconciledEvent sets the timestamp & eventType of reconciledEvent and emits it
+func EmitSupplyReconciledEvent(stub shim.ChaincodeStubInterface, reconciledEvent *model.SupplyReconciledEvent) error {
+	reconciledEvent.EventType = SupplyReconciledEventKey
+	reconciledEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, SupplyReconciledEventKey, reconciledEvent.TokenID, reconciledEvent)
+}
+
+func EmitClawbackEvent(stub shim.ChaincodeStubInterface, owner, from, to string, amount *big.Int) error {
+	clawbackEvent := model.NewClawbackEvent(owner, from, to, amount)
+	clawbackEvent.EventType = ClawbackEventKey
+
+	return setEvent(stub, ClawbackEventKey, "", clawbackEvent)
+}
+
+// EmitSweepEvent emits the dust balances swept to the collector
+func EmitSweepEvent(stub shim.ChaincodeStubInterface, sweepEvent *model.SweepEvent) error {
+	sweepEvent.EventType = SweepEventKey
+	sweepEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, SweepEventKey, sweepEvent.TokenID, sweepEvent)
+}
+
+func EmitBatchTransferEvent(stub shim.ChaincodeStubInterface, transfers []model.TransferEvent, decimals uint8) error {
+	timestamp := getEventTimestamp(stub)
+	for i := range transfers {
+		transfers[i].Decimals = &decimals
+		transfers[i].Timestamp = timestamp
+	}
+	batchTransferEvent := model.NewBatchTransferEvent(transfers)
+	batchTransferEvent.EventType = BatchTransferEventKey
+
+	return setEvent(stub, BatchTransferEventKey, "", batchTransferEvent)
+}
+
+// EmitTokenCreatedEvent uses the tokenID & eventPrefix of erc20, the metadata saved by Init cannot be read in the same transaction
+func EmitTokenCreatedEvent(stub shim.ChaincodeStubInterface, erc20 *model.ERC20Metadata) error {
+	tokenCreatedEvent := model.NewTokenCreatedEvent(erc20)
+	tokenCreatedEvent.EventType = TokenCreatedEventKey
+
+	return setTokenEvent(stub, TokenCreatedEventKey, erc20, tokenCreatedEvent)
+}
+
+// EmitEscrowEvent emits the escrow with its status after lock, claim or refund
+func EmitEscrowEvent(stub shim.ChaincodeStubInterface, escrow *model.Escrow) error {
+	escrowEvent := model.NewEscrowEvent(escrow)
+	escrowEvent.EventType = EscrowEventKey
+	escrowEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, EscrowEventKey, "", escrowEvent)
+}
+
+func EmitSwapEvent(stub shim.ChaincodeStubInterface, swapEvent *model.SwapEvent) error {
+	swapEvent.EventType = SwapEventKey
+	swapEvent.Timestamp = getEventTimestamp(stub)
+
+	return setEvent(stub, SwapEventKey, swapEvent.TokenA, swapEvent)
+}
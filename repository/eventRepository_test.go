@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func Test_getEventTimestamp_unavailable(t *testing.T) {
+	// the timestamp is unavailable outside of transaction
+	stub := shim.NewMockStub("event", nil)
+	if getEventTimestamp(stub) != 0 {
+		t.FailNow()
+	}
+
+	stub.MockTransactionStart("tx")
+	if getEventTimestamp(stub) == 0 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SetKeyPolicy sets the key-level endorsement policy of the composite key objectType/{attributes}
+// Nil policy removes it, then the chaincode endorsement policy applies to the key again
+func SetKeyPolicy(stub shim.ChaincodeStubInterface, objectType string, attributes []string, policy []byte) error {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, objectType, err.Error())
+	}
+
+	err = stub.SetStateValidationParameter(key, policy)
+	if err != nil {
+		return model.NewCustomError(model.SetValidationParameterErrorType, key, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var logger = shim.NewLogger("repository")
+
+// State key prefixes, every state of chaincode is stored under a composite key
+// whose object type is one of the prefixes, so keys of different kinds never collide
+const (
+	// BalancePrefix - balance/{address} : balance (decimal string)
+	BalancePrefix = "balance"
+	// AllowancePrefix - approval/{owner}/{spender} : allowance (decimal string)
+	AllowancePrefix = "approval"
+	// TokenPrefix - token/{tokenID} : metadata (JSON)
+	TokenPrefix = "token"
+	// ConfigPrefix - config/tokenID : tokenID of the token instantiated by Init,
+	// config/balanceMigration : marker of the completed migrateBalances,
+	// config/balanceSeed : marker of the one-time seedBalances,
+	// config/mintRequestCursor : the next mintslot to store a mint request (decimal string),
+	// config/circuitBreaker : transferred volume of the window & pause of the circuit breaker (JSON)
+	ConfigPrefix = "config"
+	// TxlogPrefix - txlog/{address}/{txID} : transfer event (JSON),
+	// txlog/{address}/{txID}/{index} : transfer event of the movement index > 0 of a transaction moving tokens more than once
+	TxlogPrefix = "txlog"
+	// RateLimitPrefix - ratelimit/{address}/{windowStart} : transfer counter (JSON)
+	RateLimitPrefix = "ratelimit"
+	// CooldownPrefix - cooldown/{address} : unix seconds of the last transfer (decimal string)
+	CooldownPrefix = "cooldown"
+	// AccountPrefix - account/{address} : account-created marker (JSON)
+	AccountPrefix = "account"
+	// NoncePrefix - nonce/{address} : nonce of signed operations (decimal string)
+	NoncePrefix = "nonce"
+	// EscrowPrefix - escrow/{escrowID} : escrow of conditionalTransfer (JSON)
+	EscrowPrefix = "escrow"
+	// LabelPrefix - label/{address} : label of address set by owner (string)
+	LabelPrefix = "label"
+	// MintRequestPrefix - mintrequest/{requestID} : processed mint of mintRequestId (JSON)
+	MintRequestPrefix = "mintrequest"
+	// MintSlotPrefix - mintslot/{slot} : requestID stored in the slot, the oldest is evicted when full
+	MintSlotPrefix = "mintslot"
+	// UsagePrefix - usage/{function}/{txID} : marker of one invocation while usage metering is enabled
+	UsagePrefix = "usage"
+	// RecurringPrefix - recurring/{owner}/{spender} : recurring allowance reset every period (JSON)
+	RecurringPrefix = "recurring"
+	// AllowedPrefix - allowed/{address} : marker of address in the recipient whitelist ("1")
+	AllowedPrefix = "allowed"
+	// CategoryPrefix - category/{category}/{txID} : transfer event of categorizedTransfer (JSON)
+	CategoryPrefix = "category"
+)
+
+// StatePrefixes is the list of all state key prefixes
+var StatePrefixes = []string{
+	BalancePrefix,
+	AllowancePrefix,
+	TokenPrefix,
+	ConfigPrefix,
+	TxlogPrefix,
+	RateLimitPrefix,
+	CooldownPrefix,
+	AccountPrefix,
+	NoncePrefix,
+	EscrowPrefix,
+	LabelPrefix,
+	MintRequestPrefix,
+	MintSlotPrefix,
+	UsagePrefix,
+	RecurringPrefix,
+	AllowedPrefix,
+	CategoryPrefix,
+}
+
+// StateSchema is the key layout & value format of every state, returned by stateSchema query for indexers
+// Keys are composite keys ("\x00" + prefix + "\x00" + each attribute + "\x00"), one entry per layout of a prefix
+var StateSchema = []model.StateKeySchema{
+	{Prefix: BalancePrefix, Key: "{address}", Value: "balance (decimal string)"},
+	{Prefix: AllowancePrefix, Key: "{owner}/{spender}", Value: "allowance (decimal string)"},
+	{Prefix: TokenPrefix, Key: "{tokenID}", Value: "metadata including totalSupply (JSON)"},
+	{Prefix: ConfigPrefix, Key: "tokenID", Value: "tokenID of the token instantiated by Init (string)"},
+	{Prefix: ConfigPrefix, Key: "balanceMigration", Value: "marker of the completed migrateBalances (string)"},
+	{Prefix: ConfigPrefix, Key: "balanceSeed", Value: "marker of the one-time seedBalances (string)"},
+	{Prefix: ConfigPrefix, Key: "mintRequestCursor", Value: "the next mintslot to store a mint request (decimal string)"},
+	{Prefix: ConfigPrefix, Key: "circuitBreaker", Value: "transferred volume of the window & pause of the circuit breaker (JSON)"},
+	{Prefix: TxlogPrefix, Key: "{address}/{txID}", Value: "transfer event (JSON)"},
+	{Prefix: TxlogPrefix, Key: "{address}/{txID}/{index}", Value: "transfer event of the movement index > 0 of the transaction (JSON)"},
+	{Prefix: RateLimitPrefix, Key: "{address}/{windowStart}", Value: "transfer counter (JSON)"},
+	{Prefix: CooldownPrefix, Key: "{address}", Value: "unix seconds of the last transfer (decimal string)"},
+	{Prefix: AccountPrefix, Key: "{address}", Value: "account-created marker (JSON)"},
+	{Prefix: NoncePrefix, Key: "{address}", Value: "nonce of signed operations (decimal string)"},
+	{Prefix: EscrowPrefix, Key: "{escrowID}", Value: "escrow of conditionalTransfer (JSON)"},
+	{Prefix: LabelPrefix, Key: "{address}", Value: "label of address set by owner (string)"},
+	{Prefix: MintRequestPrefix, Key: "{requestID}", Value: "processed mint of mintRequestId (JSON)"},
+	{Prefix: MintSlotPrefix, Key: "{slot}", Value: "requestID stored in the slot (string)"},
+	{Prefix: UsagePrefix, Key: "{function}/{txID}", Value: "marker of one invocation (\"1\")"},
+	{Prefix: RecurringPrefix, Key: "{owner}/{spender}", Value: "recurring allowance reset every period (JSON)"},
+	{Prefix: AllowedPrefix, Key: "{address}", Value: "marker of address in the recipient whitelist (\"1\")"},
+	{Prefix: CategoryPrefix, Key: "{category}/{txID}", Value: "transfer event of categorizedTransfer (JSON)"},
+}
+
+// Max lengths of the values read from state, a longer value is corrupt and rejected before parsing,
+// so it cannot cause a huge allocation. A balance has decimal digits only (10^128 is far beyond any supply),
+// metadata grows with the minters & excluded addresses
+const (
+	maxBalanceValueLength  = 128
+	maxMetadataValueLength = 256 * 1024
+)
+
+// checkValueLength checks value of name read from state is at most maxLength bytes
+func checkValueLength(name string, value []byte, maxLength int) error {
+	if len(value) > maxLength {
+		return model.NewCustomError(model.GetStateErrorType, name, fmt.Sprintf("value of %d bytes exceeds the max %d bytes", len(value), maxLength))
+	}
+
+	return nil
+}
+
+// splitKeyAttributes returns the attributes of composite key
+// Returns nil attributes when the key doesn't have attributeCount attributes,
+// list queries skip such malformed keys rather than indexing them
+func splitKeyAttributes(stub shim.ChaincodeStubInterface, key string, attributeCount int) ([]string, error) {
+	_, attributes, err := stub.SplitCompositeKey(key)
+	if err != nil {
+		return nil, model.NewCustomError(model.SpliteCompositeKeyErrorType, key, err.Error())
+	}
+	if len(attributes) != attributeCount {
+		return nil, nil
+	}
+
+	return attributes, nil
+}
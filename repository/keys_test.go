@@ -0,0 +1,38 @@
+package repository
+
+import "testing"
+
+func Test_StatePrefixes_unique(t *testing.T) {
+	prefixes := map[string]bool{}
+	for _, prefix := range StatePrefixes {
+		if prefixes[prefix] {
+			t.Fatalf("duplicate state key prefix %s", prefix)
+		}
+		prefixes[prefix] = true
+	}
+}
+
+func Test_StateSchema_allPrefixes(t *testing.T) {
+	described := map[string]bool{}
+	for _, schema := range StateSchema {
+		described[schema.Prefix] = true
+	}
+	for _, prefix := range StatePrefixes {
+		if !described[prefix] {
+			t.Fatalf("state key prefix %s is not in StateSchema", prefix)
+		}
+	}
+	if len(described) != len(StatePrefixes) {
+		t.Fatalf("StateSchema has a prefix not in StatePrefixes")
+	}
+}
+
+func Test_matchesLayout(t *testing.T) {
+	layouts := [][]string{{"{owner}", "{spender}"}, {"tokenID"}}
+	if !matchesLayout([]string{"owner", "spender"}, layouts) || !matchesLayout([]string{"tokenID"}, layouts) {
+		t.FailNow()
+	}
+	if matchesLayout([]string{"owner"}, layouts) || matchesLayout([]string{"a", "b", "c"}, layouts) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetLabel returns the label of address set by owner
+// Returns empty string if the address has no label
+func GetLabel(stub shim.ChaincodeStubInterface, address string) (string, error) {
+	// create composite key for label - label/{address}
+	labelKey, err := stub.CreateCompositeKey(LabelPrefix, []string{address})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, LabelPrefix, err.Error())
+	}
+
+	labelBytes, err := stub.GetState(labelKey)
+	if err != nil {
+		return "", model.NewCustomError(model.GetStateErrorType, labelKey, err.Error())
+	}
+
+	return string(labelBytes), nil
+}
+
+func SaveLabel(stub shim.ChaincodeStubInterface, address, label string) error {
+	// create composite key for label - label/{address}
+	labelKey, err := stub.CreateCompositeKey(LabelPrefix, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, LabelPrefix, err.Error())
+	}
+
+	err = stub.PutState(labelKey, []byte(label))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, labelKey, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// compositeKeyNamespace is the first character of every composite key
+const compositeKeyNamespace = "\x00"
+
+// legacyDummyKeyPrefix is the prefix of the test states putDummyData wrote in the key scheme before composite keys
+const legacyDummyKeyPrefix = "stateTest"
+
+// GetLegacyBalancePage returns one page of the balances stored under bare address keys
+// by the key scheme before composite keys, and the next bookmark
+// Range queries only scan simple keys, only the exact legacy balance shape is migrated: a well-formed address key
+// other than the metadata key tokenID & the putDummyData keys, holding a canonical decimal balance.
+// Any other simple key (e.g. a numeric counter or cursor) is skipped
+func GetLegacyBalancePage(stub shim.ChaincodeStubInterface, tokenID string, pageSize int32, bookmark string) ([]model.AccountBalance, string, error) {
+	legacyIterator, metadata, err := stub.GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, "", model.NewCustomError(model.GetStateByRangeErrorType, "legacy balance", err.Error())
+	}
+	defer legacyIterator.Close()
+
+	balances := []model.AccountBalance{}
+	for legacyIterator.HasNext() {
+		legacyKV, err := legacyIterator.Next()
+		if err != nil {
+			return nil, "", model.NewCustomError(model.GetStateByRangeErrorType, "legacy balance", err.Error())
+		}
+		if !isLegacyBalanceKey(tokenID, legacyKV.GetKey()) {
+			continue
+		}
+
+		value := string(legacyKV.GetValue())
+		balance, err := util.ParseBigBalance("balance", value)
+		if err != nil || util.FormatBigBalance(balance) != value {
+			continue
+		}
+		balances = append(balances, model.AccountBalance{Address: legacyKV.GetKey(), Balance: balance})
+	}
+
+	return balances, metadata.GetBookmark(), nil
+}
+
+// isLegacyBalanceKey returns whether the simple key has the shape of a legacy balance key
+func isLegacyBalanceKey(tokenID, key string) bool {
+	if strings.HasPrefix(key, compositeKeyNamespace) || key == tokenID || strings.HasPrefix(key, legacyDummyKeyPrefix) {
+		return false
+	}
+
+	return util.ValidateAddress("address", key) == nil
+}
+
+// DeleteLegacyBalance deletes the balance stored under bare address key
+func DeleteLegacyBalance(stub shim.ChaincodeStubInterface, address string) error {
+	err := stub.DelState(address)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, "legacy balance", err.Error())
+	}
+
+	return nil
+}
+
+// SaveBalanceMigrated marks the migration of legacy balances complete under config/balanceMigration
+func SaveBalanceMigrated(stub shim.ChaincodeStubInterface) error {
+	migrationKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"balanceMigration"})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	err = stub.PutState(migrationKey, []byte("completed"))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, migrationKey, err.Error())
+	}
+
+	return nil
+}
+
+// SaveBalancesSeeded marks the one-time seedBalances done under config/balanceSeed
+func SaveBalancesSeeded(stub shim.ChaincodeStubInterface) error {
+	seedKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"balanceSeed"})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	err = stub.PutState(seedKey, []byte("completed"))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, seedKey, err.Error())
+	}
+
+	return nil
+}
+
+// IsBalancesSeeded returns whether seedBalances was already done
+func IsBalancesSeeded(stub shim.ChaincodeStubInterface) (bool, error) {
+	seedKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"balanceSeed"})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	seededBytes, err := stub.GetState(seedKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, seedKey, err.Error())
+	}
+
+	return seededBytes != nil, nil
+}
+
+// IsBalanceMigrated returns whether the migration of legacy balances is complete
+func IsBalanceMigrated(stub shim.ChaincodeStubInterface) (bool, error) {
+	migrationKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"balanceMigration"})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	migratedBytes, err := stub.GetState(migrationKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, migrationKey, err.Error())
+	}
+
+	return migratedBytes != nil, nil
+}
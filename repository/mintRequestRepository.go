@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetMintRequest returns the processed mint of requestID
+// Returns nil if the request has not been processed or has been evicted
+func GetMintRequest(stub shim.ChaincodeStubInterface, requestID string) (*model.MintRequest, error) {
+	// create composite key for mint request - mintrequest/{requestID}
+	requestKey, err := stub.CreateCompositeKey(MintRequestPrefix, []string{requestID})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, MintRequestPrefix, err.Error())
+	}
+
+	requestBytes, err := stub.GetState(requestKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, requestKey, err.Error())
+	}
+	if requestBytes == nil {
+		return nil, nil
+	}
+
+	mintRequest := model.MintRequest{}
+	err = json.Unmarshal(requestBytes, &mintRequest)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, requestKey, err.Error())
+	}
+
+	return &mintRequest, nil
+}
+
+// SaveMintRequest saves the processed mint in the next of maxStored slots
+// and deletes the request the slot held, so at most maxStored requests are stored
+func SaveMintRequest(stub shim.ChaincodeStubInterface, mintRequest *model.MintRequest, maxStored int64) error {
+	// get the cursor of the next slot - config/mintRequestCursor
+	cursorKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"mintRequestCursor"})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+	cursorBytes, err := stub.GetState(cursorKey)
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, cursorKey, err.Error())
+	}
+	cursor := int64(0)
+	if cursorBytes != nil {
+		cursor, err = util.ParseDecimalInt(cursorKey, string(cursorBytes), 0, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+	}
+
+	// evict the request of the slot - mintslot/{slot}
+	slotKey, err := stub.CreateCompositeKey(MintSlotPrefix, []string{strconv.FormatInt(cursor%maxStored, 10)})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, MintSlotPrefix, err.Error())
+	}
+	evictedBytes, err := stub.GetState(slotKey)
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, slotKey, err.Error())
+	}
+	if evictedBytes != nil {
+		evictedKey, err := stub.CreateCompositeKey(MintRequestPrefix, []string{string(evictedBytes)})
+		if err != nil {
+			return model.NewCustomError(model.CreateCompositeKeyErrorType, MintRequestPrefix, err.Error())
+		}
+		err = stub.DelState(evictedKey)
+		if err != nil {
+			return model.NewCustomError(model.DelStateErrorType, evictedKey, err.Error())
+		}
+	}
+
+	// save the request, the slot & the next cursor
+	requestKey, err := stub.CreateCompositeKey(MintRequestPrefix, []string{mintRequest.RequestID})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, MintRequestPrefix, err.Error())
+	}
+	requestBytes, err := json.Marshal(mintRequest)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, requestKey, err.Error())
+	}
+	err = stub.PutState(requestKey, requestBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, requestKey, err.Error())
+	}
+	err = stub.PutState(slotKey, []byte(mintRequest.RequestID))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, slotKey, err.Error())
+	}
+	err = stub.PutState(cursorKey, []byte(strconv.FormatInt(cursor+1, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, cursorKey, err.Error())
+	}
+
+	return nil
+}
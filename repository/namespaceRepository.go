@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetNamespaceReport scans the state for keys matching no layout of StateSchema
+// Simple keys are all unexpected (e.g. legacy balances before migrateBalances), composite keys of each prefix
+// must match one of the layouts of the prefix. Fabric can only list composite keys by object type,
+// so keys under an unknown prefix are out of reach. Every key is read, the cost grows with the state
+func GetNamespaceReport(stub shim.ChaincodeStubInterface) (*model.NamespaceReport, error) {
+	report := &model.NamespaceReport{UnexpectedKeys: []model.UnexpectedKey{}}
+
+	// range queries only scan simple keys in Fabric, MockStub returns the composite keys too
+	simpleIterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateByRangeErrorType, "state", err.Error())
+	}
+	defer simpleIterator.Close()
+	for simpleIterator.HasNext() {
+		kv, err := simpleIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStateByRangeErrorType, "state", err.Error())
+		}
+		if strings.HasPrefix(kv.GetKey(), compositeKeyNamespace) {
+			continue
+		}
+		report.Scanned++
+		report.UnexpectedKeys = append(report.UnexpectedKeys, model.UnexpectedKey{Key: kv.GetKey(), Reason: "simple key outside the composite key namespace"})
+	}
+
+	for _, prefix := range StatePrefixes {
+		unexpected, scanned, err := getUnexpectedCompositeKeys(stub, prefix)
+		if err != nil {
+			return nil, err
+		}
+		report.Scanned += scanned
+		report.UnexpectedKeys = append(report.UnexpectedKeys, unexpected...)
+	}
+
+	return report, nil
+}
+
+// getUnexpectedCompositeKeys returns the keys of prefix matching none of its layouts and the number of keys read
+func getUnexpectedCompositeKeys(stub shim.ChaincodeStubInterface, prefix string) ([]model.UnexpectedKey, int, error) {
+	layouts := [][]string{}
+	for _, schema := range StateSchema {
+		if schema.Prefix == prefix {
+			layouts = append(layouts, strings.Split(schema.Key, "/"))
+		}
+	}
+
+	keyIterator, err := stub.GetStateByPartialCompositeKey(prefix, []string{})
+	if err != nil {
+		return nil, 0, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, prefix, err.Error())
+	}
+	defer keyIterator.Close()
+
+	unexpected, scanned := []model.UnexpectedKey{}, 0
+	for keyIterator.HasNext() {
+		kv, err := keyIterator.Next()
+		if err != nil {
+			return nil, 0, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, prefix, err.Error())
+		}
+		scanned++
+
+		_, attributes, err := stub.SplitCompositeKey(kv.GetKey())
+		if err != nil {
+			return nil, 0, model.NewCustomError(model.SpliteCompositeKeyErrorType, kv.GetKey(), err.Error())
+		}
+		if !matchesLayout(attributes, layouts) {
+			unexpected = append(unexpected, model.UnexpectedKey{Key: kv.GetKey(), Reason: fmt.Sprintf("no %s layout has the %d attributes", prefix, len(attributes))})
+		}
+	}
+
+	return unexpected, scanned, nil
+}
+
+// matchesLayout returns whether attributes match one of layouts,
+// a "{placeholder}" part matches any attribute and a literal part (e.g. config's "tokenID") only itself
+func matchesLayout(attributes []string, layouts [][]string) bool {
+	for _, layout := range layouts {
+		if len(layout) != len(attributes) {
+			continue
+		}
+		matched := true
+		for i, part := range layout {
+			if !strings.HasPrefix(part, "{") && part != attributes[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+//go:build debug
+// +build debug
+
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// assertKeyNamespace panics when key is not a composite key under prefix,
+// debug builds (go build -tags debug) check every balance & metadata key before it is written
+func assertKeyNamespace(stub shim.ChaincodeStubInterface, key, prefix string) {
+	objectType, _, err := stub.SplitCompositeKey(key)
+	if err != nil || objectType != prefix {
+		panic(fmt.Sprintf("key %q is outside the %s namespace", key, prefix))
+	}
+}
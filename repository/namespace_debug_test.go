@@ -0,0 +1,27 @@
+//go:build debug
+// +build debug
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func Test_assertKeyNamespace_outside_panics(t *testing.T) {
+	stub := shim.NewMockStub("namespace", nil)
+	balanceKey, _ := stub.CreateCompositeKey(BalancePrefix, []string{"dappcampus"})
+	assertKeyNamespace(stub, balanceKey, BalancePrefix)
+
+	for _, key := range []string{balanceKey, "dappcampus"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%q is accepted as a token key", key)
+				}
+			}()
+			assertKeyNamespace(stub, key, TokenPrefix)
+		}()
+	}
+}
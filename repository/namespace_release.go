@@ -0,0 +1,9 @@
+//go:build !debug
+// +build !debug
+
+package repository
+
+import "github.com/hyperledger/fabric/core/chaincode/shim"
+
+// assertKeyNamespace is checked in debug builds only, see namespace_debug.go
+func assertKeyNamespace(stub shim.ChaincodeStubInterface, key, prefix string) {}
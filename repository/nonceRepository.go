@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetNonce returns the number of signed operations (e.g. permit) the address has used
+// The nonce is zero until the first signed operation
+func GetNonce(stub shim.ChaincodeStubInterface, address string) (int64, error) {
+	// create composite key for nonce - nonce/{address}
+	nonceKey, err := stub.CreateCompositeKey(NoncePrefix, []string{address})
+	if err != nil {
+		return 0, model.NewCustomError(model.CreateCompositeKeyErrorType, NoncePrefix, err.Error())
+	}
+
+	nonceBytes, err := stub.GetState(nonceKey)
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, nonceKey, err.Error())
+	}
+	if nonceBytes == nil {
+		return 0, nil
+	}
+
+	nonce, err := util.ParseDecimalInt(nonceKey, string(nonceBytes), 0, math.MaxInt64)
+	if err != nil {
+		return 0, err
+	}
+
+	return nonce, nil
+}
+
+func SaveNonce(stub shim.ChaincodeStubInterface, address string, nonce int64) error {
+	// create composite key for nonce - nonce/{address}
+	nonceKey, err := stub.CreateCompositeKey(NoncePrefix, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, NoncePrefix, err.Error())
+	}
+
+	err = stub.PutState(nonceKey, []byte(strconv.FormatInt(nonce, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, nonceKey, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetTransferCounter returns the transfers of address in the window starting at windowStart
+// Returns zero counter if the address didn't transfer in the window
+func GetTransferCounter(stub shim.ChaincodeStubInterface, address string, windowStart int64) (*model.TransferCounter, error) {
+	// create composite key for counter - ratelimit/{address}/{windowStart}
+	counterKey, err := stub.CreateCompositeKey(RateLimitPrefix, []string{address, strconv.FormatInt(windowStart, 10)})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, RateLimitPrefix, err.Error())
+	}
+
+	counterBytes, err := stub.GetState(counterKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, counterKey, err.Error())
+	}
+
+	counter := model.TransferCounter{Amount: big.NewInt(0)}
+	if counterBytes == nil {
+		return &counter, nil
+	}
+	err = json.Unmarshal(counterBytes, &counter)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, counterKey, err.Error())
+	}
+
+	return &counter, nil
+}
+
+func SaveTransferCounter(stub shim.ChaincodeStubInterface, address string, windowStart int64, counter *model.TransferCounter) error {
+	// create composite key for counter - ratelimit/{address}/{windowStart}
+	counterKey, err := stub.CreateCompositeKey(RateLimitPrefix, []string{address, strconv.FormatInt(windowStart, 10)})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, RateLimitPrefix, err.Error())
+	}
+
+	counterBytes, err := json.Marshal(counter)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, counterKey, err.Error())
+	}
+
+	err = stub.PutState(counterKey, counterBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, counterKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetLastTransferSeconds returns the unix seconds of the last transfer address sent
+// Returns 0 if the address never sent
+func GetLastTransferSeconds(stub shim.ChaincodeStubInterface, address string) (int64, error) {
+	// create composite key for last transfer - cooldown/{address}
+	cooldownKey, err := stub.CreateCompositeKey(CooldownPrefix, []string{address})
+	if err != nil {
+		return 0, model.NewCustomError(model.CreateCompositeKeyErrorType, CooldownPrefix, err.Error())
+	}
+
+	secondsBytes, err := stub.GetState(cooldownKey)
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, cooldownKey, err.Error())
+	}
+	if secondsBytes == nil {
+		return 0, nil
+	}
+
+	return util.ParseDecimalInt(cooldownKey, string(secondsBytes), 0, math.MaxInt64)
+}
+
+func SaveLastTransferSeconds(stub shim.ChaincodeStubInterface, address string, seconds int64) error {
+	// create composite key for last transfer - cooldown/{address}
+	cooldownKey, err := stub.CreateCompositeKey(CooldownPrefix, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, CooldownPrefix, err.Error())
+	}
+
+	err = stub.PutState(cooldownKey, []byte(strconv.FormatInt(seconds, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, cooldownKey, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SaveERC20Metadata saves metadata under token/{tokenID}, tokenID is the stable key set at Init,
+// so it stays the same after the display name of token is changed
+func SaveERC20Metadata(stub shim.ChaincodeStubInterface, tokenID string, erc20 *model.ERC20Metadata) error {
+	// create composite key for metadata - token/{tokenID}
+	tokenKey, err := stub.CreateCompositeKey(TokenPrefix, []string{tokenID})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, TokenPrefix, err.Error())
+	}
+	assertKeyNamespace(stub, tokenKey, TokenPrefix)
+
+	erc20Bytes, err := json.Marshal(erc20)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "erc20", err.Error())
+	}
+
+	// save token meta data
+	err = stub.PutState(tokenKey, erc20Bytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, "erc20Metadata", err.Error())
+	}
+
+	return nil
+}
+
+func GetERC20Metadata(stub shim.ChaincodeStubInterface, tokenID string) (*model.ERC20Metadata, error) {
+	// create composite key for metadata - token/{tokenID}
+	tokenKey, err := stub.CreateCompositeKey(TokenPrefix, []string{tokenID})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, TokenPrefix, err.Error())
+	}
+
+	// Get ERC20 Metadata
+	erc20Bytes, err := stub.GetState(tokenKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, "erc20Metadata", err.Error())
+	}
+	if erc20Bytes == nil {
+		return nil, model.NewStatusError(model.StatusNotFound, "token "+tokenID+" is not found")
+	}
+	return decodeERC20Metadata("erc20Metadata", erc20Bytes)
+}
+
+// decodeERC20Metadata decodes metadata strictly, so corrupt or truncated metadata fails
+// instead of producing a zero-value struct
+// Empty or too long input, unknown fields, trailing data and missing id or totalSupply are rejected
+func decodeERC20Metadata(name string, erc20Bytes []byte) (*model.ERC20Metadata, error) {
+	if len(erc20Bytes) == 0 {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, name, "metadata is empty")
+	}
+	err := checkValueLength(name, erc20Bytes, maxMetadataValueLength)
+	if err != nil {
+		return nil, err
+	}
+
+	erc20 := model.ERC20Metadata{}
+	decoder := json.NewDecoder(bytes.NewReader(erc20Bytes))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&erc20)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, name, err.Error())
+	}
+	if decoder.Decode(&struct{}{}) != io.EOF {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, name, "trailing data after metadata")
+	}
+
+	// id & totalSupply are always written at Init
+	if len(erc20.ID) == 0 || erc20.TotalSupply == nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, name, "id or totalSupply is missing")
+	}
+
+	return &erc20, nil
+}
+
+func GetERC20TotalSupply(stub shim.ChaincodeStubInterface, tokenID string) (*big.Int, error) {
+	erc20, err := GetERC20Metadata(stub, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	return erc20.GetTotalSupply(), nil
+}
+
+// SaveTokenID saves tokenID of the token instantiated by Init under config/tokenID,
+// so functions without tokenID param (e.g. transfer) can read the metadata
+func SaveTokenID(stub shim.ChaincodeStubInterface, tokenID string) error {
+	// create composite key for tokenID - config/tokenID
+	tokenIDKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"tokenID"})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	err = stub.PutState(tokenIDKey, []byte(tokenID))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, tokenIDKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetTokenID returns tokenID of the token instantiated by Init
+// Returns empty string if Init was never called
+func GetTokenID(stub shim.ChaincodeStubInterface) (string, error) {
+	// create composite key for tokenID - config/tokenID
+	tokenIDKey, err := stub.CreateCompositeKey(ConfigPrefix, []string{"tokenID"})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, ConfigPrefix, err.Error())
+	}
+
+	tokenIDBytes, err := stub.GetState(tokenIDKey)
+	if err != nil {
+		return "", model.NewCustomError(model.GetStateErrorType, tokenIDKey, err.Error())
+	}
+
+	return string(tokenIDBytes), nil
+}
+
+// GetTokenMetadata returns the metadata of the token instantiated by Init
+func GetTokenMetadata(stub shim.ChaincodeStubInterface) (*model.ERC20Metadata, error) {
+	tokenID, err := GetTokenID(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetERC20Metadata(stub, tokenID)
+}
+
+// GetTokenList returns one page of token metadata
+// Only token/{tokenID} keys are scanned, balance & allowance keys are excluded
+// An iterator error mid-scan returns the tokens gathered so far marked incomplete
+func GetTokenList(stub shim.ChaincodeStubInterface, pageSize int32, bookmark string) (*model.TokenPage, error) {
+	tokenIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(TokenPrefix, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, TokenPrefix, err.Error())
+	}
+	defer tokenIterator.Close()
+
+	page := &model.TokenPage{Tokens: []model.ERC20Metadata{}, Bookmark: metadata.GetBookmark()}
+	lastKey := ""
+	for tokenIterator.HasNext() {
+		tokenKV, err := tokenIterator.Next()
+		if err != nil {
+			partialErr := model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, TokenPrefix, err.Error())
+			logger.Error(partialErr.Error())
+			page.SetError(partialErr)
+			page.Bookmark = resumeBookmark(bookmark, lastKey)
+			break
+		}
+		lastKey = tokenKV.GetKey()
+
+		erc20, err := decodeERC20Metadata(tokenKV.GetKey(), tokenKV.GetValue())
+		if err != nil {
+			return nil, err
+		}
+
+		page.Tokens = append(page.Tokens, *erc20)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SaveTransferLog indexes transfer event under txlog/{address}/{txID} for both sender and recipient
+// index is the movement of the transaction, a transaction moving tokens more than once (batch, sweep)
+// indexes the movement i > 0 under txlog/{address}/{txID}/{i}, so the movements of one address don't overwrite each other
+func SaveTransferLog(stub shim.ChaincodeStubInterface, sender, recipient string, amount *big.Int, index int) error {
+	transferEvent := model.NewTransferEvent(sender, recipient, amount)
+	transferEvent.Timestamp = getEventTimestamp(stub)
+	transferEventBytes, err := json.Marshal(transferEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, TxlogPrefix, err.Error())
+	}
+
+	txID := stub.GetTxID()
+	for _, address := range []string{sender, recipient} {
+		// create composite key for txlog - txlog/{address}/{txID}[/{index}]
+		attributes := []string{address, txID}
+		if index > 0 {
+			attributes = append(attributes, strconv.Itoa(index))
+		}
+		txlogKey, err := stub.CreateCompositeKey(TxlogPrefix, attributes)
+		if err != nil {
+			return model.NewCustomError(model.CreateCompositeKeyErrorType, TxlogPrefix, err.Error())
+		}
+
+		err = stub.PutState(txlogKey, transferEventBytes)
+		if err != nil {
+			return model.NewCustomError(model.PutStateErrorType, txlogKey, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// SaveCategoryLog indexes the transfer event of categorizedTransfer under category/{category}/{txID}
+func SaveCategoryLog(stub shim.ChaincodeStubInterface, category, sender, recipient string, amount *big.Int) error {
+	transferEvent := model.NewTransferEvent(sender, recipient, amount)
+	transferEvent.Timestamp = getEventTimestamp(stub)
+	transferEvent.Category = category
+	transferEventBytes, err := json.Marshal(transferEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, CategoryPrefix, err.Error())
+	}
+
+	// create composite key for category log - category/{category}/{txID}
+	categoryKey, err := stub.CreateCompositeKey(CategoryPrefix, []string{category, stub.GetTxID()})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, CategoryPrefix, err.Error())
+	}
+
+	err = stub.PutState(categoryKey, transferEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, categoryKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetTransferLog returns the txlog entries of address & txID in movement order,
+// empty if the transaction didn't move the tokens of address
+func GetTransferLog(stub shim.ChaincodeStubInterface, address, txID string) ([]model.TransferLog, error) {
+	txlogIterator, err := stub.GetStateByPartialCompositeKey(TxlogPrefix, []string{address, txID})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, TxlogPrefix, err.Error())
+	}
+	defer txlogIterator.Close()
+
+	transferLogs := []model.TransferLog{}
+	for txlogIterator.HasNext() {
+		txlogKV, err := txlogIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, TxlogPrefix, err.Error())
+		}
+
+		transferLog, err := parseTransferLog(stub, txlogKV.GetKey(), txlogKV.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		if transferLog != nil {
+			transferLogs = append(transferLogs, *transferLog)
+		}
+	}
+
+	// keys sort as strings ("10" before "2"), so order by index
+	sort.Slice(transferLogs, func(i, j int) bool {
+		return transferLogs[i].Index < transferLogs[j].Index
+	})
+
+	return transferLogs, nil
+}
+
+// GetTransferLogs returns one page of txlog entries of address
+// An iterator error mid-scan returns the entries gathered so far marked incomplete
+func GetTransferLogs(stub shim.ChaincodeStubInterface, address string, pageSize int32, bookmark string) (*model.TransferLogPage, error) {
+	return getTransferLogPage(stub, TxlogPrefix, address, pageSize, bookmark)
+}
+
+// GetCategoryLogs returns one page of the categorizedTransfer entries of category
+func GetCategoryLogs(stub shim.ChaincodeStubInterface, category string, pageSize int32, bookmark string) (*model.TransferLogPage, error) {
+	return getTransferLogPage(stub, CategoryPrefix, category, pageSize, bookmark)
+}
+
+// getTransferLogPage returns one page of transfer events stored under {prefix}/{attribute}/{txID}[/{index}]
+func getTransferLogPage(stub shim.ChaincodeStubInterface, prefix, attribute string, pageSize int32, bookmark string) (*model.TransferLogPage, error) {
+	txlogIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(prefix, []string{attribute}, pageSize, bookmark)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, prefix, err.Error())
+	}
+	defer txlogIterator.Close()
+
+	page := &model.TransferLogPage{Logs: []model.TransferLog{}, Bookmark: metadata.GetBookmark()}
+	lastKey := ""
+	for txlogIterator.HasNext() {
+		txlogKV, err := txlogIterator.Next()
+		if err != nil {
+			partialErr := model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, prefix, err.Error())
+			logger.Error(partialErr.Error())
+			page.SetError(partialErr)
+			page.Bookmark = resumeBookmark(bookmark, lastKey)
+			break
+		}
+		lastKey = txlogKV.GetKey()
+
+		transferLog, err := parseTransferLog(stub, txlogKV.GetKey(), txlogKV.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		if transferLog != nil {
+			page.Logs = append(page.Logs, *transferLog)
+		}
+	}
+
+	return page, nil
+}
+
+// parseTransferLog returns the entry of transfer event stored under {prefix}/{attribute}/{txID}[/{index}],
+// nil for a malformed key, list queries skip it like splitKeyAttributes
+func parseTransferLog(stub shim.ChaincodeStubInterface, key string, value []byte) (*model.TransferLog, error) {
+	_, attributes, err := stub.SplitCompositeKey(key)
+	if err != nil {
+		return nil, model.NewCustomError(model.SpliteCompositeKeyErrorType, key, err.Error())
+	}
+	if len(attributes) != 2 && len(attributes) != 3 {
+		return nil, nil
+	}
+	index := 0
+	if len(attributes) == 3 {
+		index, err = strconv.Atoi(attributes[2])
+		if err != nil || index <= 0 {
+			return nil, nil
+		}
+	}
+
+	// get event
+	transferEvent := model.TransferEvent{}
+	err = json.Unmarshal(value, &transferEvent)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, key, err.Error())
+	}
+
+	return &model.TransferLog{TxID: attributes[1], Index: index, Event: transferEvent}, nil
+}
+
+// resumeBookmark returns the bookmark of the key following lastKey, the keys of a failed page
+// after lastKey are not read yet, so the next page must start there instead of the page's bookmark
+// The bookmark is an inclusive start key, so the page is read again when no key was read
+func resumeBookmark(bookmark, lastKey string) string {
+	if len(lastKey) == 0 {
+		return bookmark
+	}
+
+	return lastKey + "\x00"
+}
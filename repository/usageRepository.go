@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SaveUsage records one invocation of function under usage/{function}/{txID}
+// Every invocation writes its own key without reading, so metering never adds an MVCC conflict
+func SaveUsage(stub shim.ChaincodeStubInterface, function string) error {
+	usageKey, err := stub.CreateCompositeKey(UsagePrefix, []string{function, stub.GetTxID()})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, UsagePrefix, err.Error())
+	}
+
+	err = stub.PutState(usageKey, []byte("1"))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, usageKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetUsageStats returns the number of recorded invocations per function
+// All usage keys are scanned, so the cost grows with the recorded invocations
+func GetUsageStats(stub shim.ChaincodeStubInterface) (map[string]int, error) {
+	usageIterator, err := stub.GetStateByPartialCompositeKey(UsagePrefix, []string{})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, UsagePrefix, err.Error())
+	}
+	defer usageIterator.Close()
+
+	stats := map[string]int{}
+	for usageIterator.HasNext() {
+		usageKV, err := usageIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, UsagePrefix, err.Error())
+		}
+
+		// usage/{function}/{txID}
+		keyParts, err := splitKeyAttributes(stub, usageKV.GetKey(), 2)
+		if err != nil {
+			return nil, err
+		}
+		if keyParts == nil {
+			continue
+		}
+		stats[keyParts[0]]++
+	}
+
+	return stats, nil
+}
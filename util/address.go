@@ -0,0 +1,39 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/erc20/model"
+)
+
+// MaxAddressLength is the max length of address in bytes
+const MaxAddressLength = 128
+
+// ValidateAddress checks address is not empty, only has ASCII letters, digits and "-_.:@" and is at most MaxAddressLength bytes
+// Addresses are attributes of composite keys, so whitespace & control characters like U+0000 (the composite key delimiter) are rejected
+func ValidateAddress(name, address string) error {
+	if len(address) == 0 {
+		return model.NewCustomError(model.ConvertErrorType, name, " cannot be empty")
+	}
+	if len(address) > MaxAddressLength {
+		return model.NewCustomError(model.ConvertErrorType, name, fmt.Sprintf(" cannot be longer than %d bytes", MaxAddressLength))
+	}
+	for i, c := range address {
+		if !isAddressCharacter(c) {
+			return model.NewCustomError(model.ConvertErrorType, name, fmt.Sprintf(" contains invalid character %q at %d", c, i))
+		}
+	}
+
+	return nil
+}
+
+// isAddressCharacter returns whether c is allowed in addresses
+func isAddressCharacter(c rune) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	case c == '-' || c == '_' || c == '.' || c == ':' || c == '@':
+		return true
+	}
+	return false
+}
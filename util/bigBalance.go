@@ -0,0 +1,121 @@
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/erc20/model"
+)
+
+// ParseBigBalance converts decimal string value to non-negative big integer
+// Balances & total supply are stored as decimal strings so they are not limited to 64 bits
+func ParseBigBalance(name, value string) (*big.Int, error) {
+	if !isDecimal(value) {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+	}
+	bigValue, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+	}
+	if bigValue.Sign() < 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " cannot be negative")
+	}
+
+	return bigValue, nil
+}
+
+// FormatBigBalance formats balance as base-10 decimal string without sign or leading zeros,
+// the only format balances are stored & returned in, so it round-trips with ParseBigBalance
+func FormatBigBalance(balance *big.Int) string {
+	return balance.Text(10)
+}
+
+// ConvertToBigPositive converts decimal string value to positive big integer
+func ConvertToBigPositive(name, value string) (*big.Int, error) {
+	bigValue, err := ParseBigBalance(name, value)
+	if err != nil {
+		return nil, err
+	}
+	if bigValue.Sign() == 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be positive")
+	}
+
+	return bigValue, nil
+}
+
+// AddBigBalance returns a + b without modifying a & b
+func AddBigBalance(a, b *big.Int) *big.Int {
+	return new(big.Int).Add(a, b)
+}
+
+// SubBigBalance returns a - b without modifying a & b
+// Returns error if the result is negative
+func SubBigBalance(name string, a, b *big.Int) (*big.Int, error) {
+	if a.Cmp(b) < 0 {
+		return nil, model.NewStatusError(model.StatusConflict, name+" is not sufficient")
+	}
+
+	return new(big.Int).Sub(a, b), nil
+}
+
+// ExceedsLimit returns whether amount is above limit, nil or zero limit is disabled
+// Every amount limit (rate limit, verified threshold, circuit breaker) is compared with it,
+// so limits & amounts stay big integers like balances and never overflow
+func ExceedsLimit(amount, limit *big.Int) bool {
+	return limit != nil && limit.Sign() > 0 && amount.Cmp(limit) > 0
+}
+
+// RemainingBelowCap returns cap - supply as decimal string, model.UnlimitedMintable for nil or zero cap
+// and "0" rather than a negative amount when supply exceeds cap
+func RemainingBelowCap(cap, supply *big.Int) string {
+	if cap == nil || cap.Sign() <= 0 {
+		return model.UnlimitedMintable
+	}
+	if supply.Cmp(cap) >= 0 {
+		return "0"
+	}
+
+	return FormatBigBalance(new(big.Int).Sub(cap, supply))
+}
+
+// MaxFormattedDigits is the max number of digits of a formatted amount, 2^256 has 78 digits
+const MaxFormattedDigits = 96
+
+// FormatDecimals formats amount of the smallest unit with decimals for display
+// e.g. 12345 with 2 decimals is "123.45"
+// Returns error rather than formatting decimals beyond model.MaxDecimals or amounts beyond MaxFormattedDigits
+func FormatDecimals(amount *big.Int, decimals uint8) (string, error) {
+	if decimals > model.MaxDecimals {
+		return "", model.NewCustomError(model.ConvertErrorType, "decimals", fmt.Sprintf(" cannot be larger than %d", model.MaxDecimals))
+	}
+	// log10(2) < 0.30103, so the estimate is never below the number of digits
+	if amount.BitLen()*30103/100000+1 > MaxFormattedDigits {
+		return "", model.NewCustomError(model.ConvertErrorType, "amount", fmt.Sprintf(" cannot be formatted beyond %d digits", MaxFormattedDigits))
+	}
+
+	digits := amount.String()
+	if decimals == 0 {
+		return digits, nil
+	}
+
+	// pad zeros so there is at least one integer digit
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(decimals)
+
+	return digits[:point] + "." + digits[point:], nil
+}
+
+// FormatDisplay fills the display template with the amount formatted with decimals and symbol
+// e.g. 12345 with 2 decimals and "{amount} {symbol}" is "123.45 dt"
+func FormatDisplay(template string, amount *big.Int, decimals uint8, symbol string) (string, error) {
+	formattedAmount, err := FormatDecimals(amount, decimals)
+	if err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer(model.AmountPlaceholder, formattedAmount, model.SymbolPlaceholder, symbol)
+	return replacer.Replace(template), nil
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"github.com/erc20/model"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// statusError is the error carrying the response status (model.CustomError & model.StatusError)
+type statusError interface {
+	Status() int32
+}
+
+// StatusResponse returns the error response with HTTP-like status (see model.StatusBadRequest)
+func StatusResponse(status int32, message string) sc.Response {
+	return sc.Response{Status: status, Message: message}
+}
+
+// BadRequest returns 400 response for invalid params
+func BadRequest(message string) sc.Response {
+	return StatusResponse(model.StatusBadRequest, message)
+}
+
+// Forbidden returns 403 response for unauthorized callers
+func Forbidden(message string) sc.Response {
+	return StatusResponse(model.StatusForbidden, message)
+}
+
+// NotFound returns 404 response for missing functions or state
+func NotFound(message string) sc.Response {
+	return StatusResponse(model.StatusNotFound, message)
+}
+
+// Conflict returns 409 response for insufficient balance & state conflicts
+func Conflict(message string) sc.Response {
+	return StatusResponse(model.StatusConflict, message)
+}
+
+// ErrorStatus returns the response status of err, 500 if err doesn't carry one
+func ErrorStatus(err error) int32 {
+	if statusErr, ok := err.(statusError); ok {
+		return statusErr.Status()
+	}
+	return model.StatusInternalError
+}
+
+// ErrorResponse returns the error response of err with the status of err
+func ErrorResponse(err error) sc.Response {
+	return StatusResponse(ErrorStatus(err), err.Error())
+}
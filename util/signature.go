@@ -0,0 +1,68 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/erc20/model"
+)
+
+// ecdsaSignature is the ASN.1 (DER) format of ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ParsePublicKey parses hex encoded uncompressed P-256 public key
+func ParsePublicKey(publicKeyHex string) (*ecdsa.PublicKey, error) {
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, model.NewCustomError(model.ConvertErrorType, "publicKey", " must be hex encoded")
+	}
+
+	x, y := elliptic.Unmarshal(elliptic.P256(), publicKeyBytes)
+	if x == nil {
+		return nil, model.NewCustomError(model.ConvertErrorType, "publicKey", " must be uncompressed P-256 point")
+	}
+
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+// AddressFromPublicKey derives the address of public key
+// Address is the hex encoding of the last 20 bytes of SHA-256 of the uncompressed public key
+func AddressFromPublicKey(publicKey *ecdsa.PublicKey) string {
+	digest := sha256.Sum256(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+	return hex.EncodeToString(digest[12:])
+}
+
+// VerifySignature verifies hex encoded DER signature of SHA-256 digest of message
+func VerifySignature(publicKey *ecdsa.PublicKey, message []byte, signatureHex string) error {
+	signatureBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return model.NewCustomError(model.ConvertErrorType, "signature", " must be hex encoded")
+	}
+
+	signature := ecdsaSignature{}
+	rest, err := asn1.Unmarshal(signatureBytes, &signature)
+	if err != nil || len(rest) != 0 || signature.R == nil || signature.S == nil {
+		return model.NewCustomError(model.ConvertErrorType, "signature", " must be DER encoded ECDSA signature")
+	}
+
+	digest := sha256.Sum256(message)
+	if !ecdsa.Verify(publicKey, digest[:], signature.R, signature.S) {
+		return model.NewCustomError(model.VerifyErrorType, "signature", "signature does not match the message")
+	}
+
+	return nil
+}
+
+// PermitMessage returns the message the owner signs to permit allowance
+// The nonce makes every permit usable only once, the tokenID binds it to the token
+func PermitMessage(tokenID, owner, spender, amount, deadline string, nonce int64) []byte {
+	return []byte(strings.Join([]string{"permit", tokenID, owner, spender, amount, deadline, strconv.FormatInt(nonce, 10)}, "|"))
+}
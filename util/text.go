@@ -0,0 +1,33 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/erc20/model"
+)
+
+// ValidateText checks value only has ASCII letters, digits, space and "-_." and is at most maxLength bytes
+// Names are used in composite keys and events, so control characters like U+0000 (the composite key delimiter) are rejected
+func ValidateText(name, value string, maxLength int) error {
+	if len(value) > maxLength {
+		return model.NewCustomError(model.ConvertErrorType, name, fmt.Sprintf(" cannot be longer than %d bytes", maxLength))
+	}
+	for i, c := range value {
+		if !isTextCharacter(c) {
+			return model.NewCustomError(model.ConvertErrorType, name, fmt.Sprintf(" contains invalid character %q at %d", c, i))
+		}
+	}
+
+	return nil
+}
+
+// isTextCharacter returns whether c is allowed in names
+func isTextCharacter(c rune) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	case c == ' ' || c == '-' || c == '_' || c == '.':
+		return true
+	}
+	return false
+}
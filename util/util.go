@@ -1,19 +1,91 @@
 package util
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/erc20/model"
 )
 
+// maxInt is the max value of int on the platform
+const maxInt = int64(^uint(0) >> 1)
+
+// isDecimal returns whether value is decimal digits with an optional leading minus sign
+// Prefixes like "0x", "+" and spaces are rejected, so no base is inferred
+func isDecimal(value string) bool {
+	if len(value) > 0 && value[0] == '-' {
+		value = value[1:]
+	}
+	if len(value) == 0 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// AddSeconds returns unix seconds plus duration, saturated at math.MaxInt64
+// so a huge configured duration (e.g. cooldown) never wraps around to the past
+func AddSeconds(seconds, duration int64) int64 {
+	if duration > 0 && seconds > math.MaxInt64-duration {
+		return math.MaxInt64
+	}
+	return seconds + duration
+}
+
+// ParseDecimalInt converts base-10 value to integer between min and max
+// All numeric params which are not amounts are parsed with it
+func ParseDecimalInt(name, value string, min, max int64) (int64, error) {
+	if !isDecimal(value) {
+		return 0, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+	}
+	intValue, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || intValue < min || intValue > max {
+		return 0, model.NewCustomError(model.ConvertErrorType, name, " must be between "+strconv.FormatInt(min, 10)+" and "+strconv.FormatInt(max, 10))
+	}
+
+	return intValue, nil
+}
+
 func ConvertToPositive(name, value string) (*int, error) {
-	intValue, err := strconv.Atoi(value)
+	intValue, err := ParseDecimalInt(name, value, math.MinInt64, maxInt)
 	if err != nil {
-		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
+		return nil, err
 	}
 	if intValue <= 0 {
 		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be positive")
 	}
 
-	return &intValue, nil
+	result := int(intValue)
+	return &result, nil
+}
+
+// ConvertToNonNegative converts value to non-negative integer, zero is allowed (e.g. to disable a limit)
+func ConvertToNonNegative(name, value string) (*int, error) {
+	intValue, err := ParseDecimalInt(name, value, math.MinInt64, maxInt)
+	if err != nil {
+		return nil, err
+	}
+	if intValue < 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " cannot be negative")
+	}
+
+	result := int(intValue)
+	return &result, nil
+}
+
+// SafeSub returns a - b of non-negative int amounts, big integer amounts (balances & allowances) use SubBigBalance
+// Returns error instead of a negative result, so no negative intermediate is computed
+func SafeSub(name string, a, b int) (int, error) {
+	if a < 0 || b < 0 {
+		return 0, model.NewCustomError(model.ConvertErrorType, name, " cannot be negative")
+	}
+	if a < b {
+		return 0, model.NewStatusError(model.StatusConflict, name+" is not sufficient")
+	}
+
+	return a - b, nil
 }
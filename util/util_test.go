@@ -0,0 +1,224 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func Test_ParseBigBalance(t *testing.T) {
+	value, err := ParseBigBalance("balance", "340282366920938463463374607431768211456")
+	if err != nil || value.String() != "340282366920938463463374607431768211456" {
+		t.FailNow()
+	}
+
+	for _, invalid := range []string{"", "-1", "abc", "1.5"} {
+		if _, err := ParseBigBalance("balance", invalid); err == nil {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_FormatBigBalance_roundTrip(t *testing.T) {
+	for _, value := range []string{"0", "18446744073709551615", "18446744073709551616"} {
+		balance, err := ParseBigBalance("balance", value)
+		if err != nil || FormatBigBalance(balance) != value {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_ConvertToBigPositive_zero_failure(t *testing.T) {
+	if _, err := ConvertToBigPositive("amount", "0"); err == nil {
+		t.FailNow()
+	}
+}
+
+func Test_ConvertToNonNegative(t *testing.T) {
+	value, err := ConvertToNonNegative("allowance", "0")
+	if err != nil || *value != 0 {
+		t.FailNow()
+	}
+
+	for _, invalid := range []string{"-1", "abc"} {
+		if _, err := ConvertToNonNegative("allowance", invalid); err == nil {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_AddBigBalance_beyondUint64(t *testing.T) {
+	maxUint64, _ := new(big.Int).SetString("18446744073709551615", 10)
+	result := AddBigBalance(maxUint64, big.NewInt(1))
+	if result.String() != "18446744073709551616" || maxUint64.String() != "18446744073709551615" {
+		t.FailNow()
+	}
+}
+
+func Test_SubBigBalance(t *testing.T) {
+	result, err := SubBigBalance("balance", big.NewInt(10), big.NewInt(10))
+	if err != nil || result.Sign() != 0 {
+		t.FailNow()
+	}
+
+	if _, err := SubBigBalance("balance", big.NewInt(9), big.NewInt(10)); err == nil {
+		t.FailNow()
+	}
+}
+
+func Test_SafeSub(t *testing.T) {
+	result, err := SafeSub("allowance", 10, 10)
+	if err != nil || result != 0 {
+		t.FailNow()
+	}
+
+	// underflow & negative operands are errors
+	for _, operands := range [][2]int{{9, 10}, {0, 1}, {-1, 0}, {10, -1}} {
+		if _, err := SafeSub("allowance", operands[0], operands[1]); err == nil {
+			t.FailNow()
+		}
+	}
+	if _, err := SafeSub("allowance", 9, 10); err.Error() != "allowance is not sufficient" {
+		t.FailNow()
+	}
+}
+
+func Test_RemainingBelowCap(t *testing.T) {
+	if RemainingBelowCap(big.NewInt(1000), big.NewInt(100)) != "900" {
+		t.FailNow()
+	}
+
+	// no cap is unlimited, supply at or beyond cap leaves nothing
+	if RemainingBelowCap(nil, big.NewInt(100)) != "unlimited" || RemainingBelowCap(big.NewInt(0), big.NewInt(100)) != "unlimited" {
+		t.FailNow()
+	}
+	for _, supply := range []int64{100, 150} {
+		if RemainingBelowCap(big.NewInt(100), big.NewInt(supply)) != "0" {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_FormatDecimals(t *testing.T) {
+	cases := map[string]string{"12345": "123.45", "5": "0.05", "0": "0.00", "100": "1.00"}
+	for amount, formatted := range cases {
+		amountBig, _ := new(big.Int).SetString(amount, 10)
+		if result, err := FormatDecimals(amountBig, 2); err != nil || result != formatted {
+			t.FailNow()
+		}
+	}
+
+	if result, _ := FormatDecimals(big.NewInt(12345), 0); result != "12345" {
+		t.FailNow()
+	}
+}
+
+func Test_FormatDecimals_bounds(t *testing.T) {
+	if _, err := FormatDecimals(big.NewInt(1), 255); err == nil {
+		t.FailNow()
+	}
+
+	// 2^256 has 78 digits, 10^200 cannot be formatted
+	if _, err := FormatDecimals(new(big.Int).Lsh(big.NewInt(1), 256), 18); err != nil {
+		t.FailNow()
+	}
+	if _, err := FormatDecimals(new(big.Int).Exp(big.NewInt(10), big.NewInt(200), nil), 0); err == nil {
+		t.FailNow()
+	}
+}
+
+func Test_FormatDisplay(t *testing.T) {
+	if result, _ := FormatDisplay("{amount} {symbol}", big.NewInt(12345), 2, "dt"); result != "123.45 dt" {
+		t.FailNow()
+	}
+	if result, _ := FormatDisplay("{symbol}{amount}", big.NewInt(5), 0, "$"); result != "$5" {
+		t.FailNow()
+	}
+}
+
+func Test_ValidateText(t *testing.T) {
+	if ValidateText("name", "dapp token-1_v2.0", 64) != nil {
+		t.FailNow()
+	}
+	for _, value := range []string{"dapp\x00token", "dapp/token", "tøken", strings.Repeat("a", 65)} {
+		if ValidateText("name", value, 64) == nil {
+			t.FailNow()
+		}
+	}
+}
+
+func Test_VerifySignature(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKeyHex := hex.EncodeToString(elliptic.Marshal(elliptic.P256(), privateKey.X, privateKey.Y))
+	publicKey, err := ParsePublicKey(publicKeyHex)
+	if err != nil || AddressFromPublicKey(publicKey) != AddressFromPublicKey(&privateKey.PublicKey) || len(AddressFromPublicKey(publicKey)) != 40 {
+		t.FailNow()
+	}
+
+	message := PermitMessage("token", "owner", "spender", "100", "0", 0)
+	digest := sha256.Sum256(message)
+	r, s, _ := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+	signature, _ := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	if VerifySignature(publicKey, message, hex.EncodeToString(signature)) != nil {
+		t.FailNow()
+	}
+
+	// signature of other message or malformed signature is rejected
+	if VerifySignature(publicKey, PermitMessage("token", "owner", "spender", "100", "0", 1), hex.EncodeToString(signature)) == nil {
+		t.FailNow()
+	}
+	if VerifySignature(publicKey, message, "zz") == nil || VerifySignature(publicKey, message, "00") == nil {
+		t.FailNow()
+	}
+}
+
+func Test_ParseDecimalInt(t *testing.T) {
+	value, err := ParseDecimalInt("deadline", "1700000000", 0, math.MaxInt64)
+	if err != nil || value != 1700000000 {
+		t.FailNow()
+	}
+
+	// no base is inferred and the range is limited
+	for _, invalid := range []string{"0x10", "+5", " 5", "1e3", "010x", "-1", "9223372036854775808", ""} {
+		if _, err := ParseDecimalInt("deadline", invalid, 0, math.MaxInt64); err == nil {
+			t.Fatalf("%q is accepted", invalid)
+		}
+	}
+	for _, invalid := range []string{"0x10", "+5", "0b1", "0o7"} {
+		if _, err := ParseBigBalance("balance", invalid); err == nil {
+			t.Fatalf("%q is accepted", invalid)
+		}
+		if _, err := ConvertToPositive("amount", invalid); err == nil {
+			t.Fatalf("%q is accepted", invalid)
+		}
+	}
+}
+
+func Test_ExceedsLimit_nearUint64(t *testing.T) {
+	maxUint64, _ := new(big.Int).SetString("18446744073709551615", 10)
+	beyond := AddBigBalance(maxUint64, big.NewInt(1))
+	if ExceedsLimit(maxUint64, maxUint64) || !ExceedsLimit(beyond, maxUint64) || ExceedsLimit(maxUint64, beyond) {
+		t.FailNow()
+	}
+
+	// nil & zero limits are disabled
+	if ExceedsLimit(beyond, nil) || ExceedsLimit(beyond, big.NewInt(0)) {
+		t.FailNow()
+	}
+}
+
+func Test_AddSeconds_saturates(t *testing.T) {
+	if AddSeconds(1700000000, 60) != 1700000060 || AddSeconds(1700000000, 0) != 1700000000 {
+		t.FailNow()
+	}
+	if AddSeconds(1700000000, math.MaxInt64) != math.MaxInt64 || AddSeconds(math.MaxInt64, 1) != math.MaxInt64 {
+		t.FailNow()
+	}
+}